@@ -74,13 +74,16 @@ const (
 	AudioEventTimerFinished
 	AudioEventReceivedMessage
 	AudioEventAlert
+	AudioEventDeparture
+	AudioEventLanding
+	AudioEventGoAround
 	AudioEventCount
 )
 
 func (ae AudioEvent) String() string {
 	return [...]string{"Flight Plan Filed", "New Arrival", "Conflict Alert", "Updated ATIS",
 		"Point Out", "Handoff Request", "Handoff Rejected", "Handoff Accepted",
-		"Timer Finished", "Message Received", "Alert"}[ae]
+		"Timer Finished", "Message Received", "Alert", "Departure", "Landing", "Go Around"}[ae]
 }
 
 type AudioSettings struct {
@@ -81,6 +81,51 @@ func ParseMETAR(str string) (*METAR, error) {
 	return m, nil
 }
 
+// ParseWind decodes the METAR's wind field, returning the direction the
+// wind is from (in degrees true), its speed, and the gust speed, if any;
+// speeds are in knots. For variable winds, the returned direction is -1.
+func (m METAR) ParseWind() (dir int, speed int, gust int, err error) {
+	w := m.Wind
+	scale := float32(1)
+	if strings.HasSuffix(w, "KT") {
+		w = strings.TrimSuffix(w, "KT")
+	} else if strings.HasSuffix(w, "MPS") {
+		w = strings.TrimSuffix(w, "MPS")
+		scale = 1.94384
+	} else {
+		err = fmt.Errorf("%s: unexpected units in wind", m.Wind)
+		return
+	}
+
+	if len(w) < 5 {
+		err = fmt.Errorf("%s: malformed wind", m.Wind)
+		return
+	}
+
+	if w[:3] == "VRB" {
+		dir = -1
+	} else if dir, err = strconv.Atoi(w[:3]); err != nil {
+		return
+	}
+
+	w = w[3:]
+	if g := strings.IndexByte(w, 'G'); g != -1 {
+		if gust, err = strconv.Atoi(w[g+1:]); err != nil {
+			return
+		}
+		w = w[:g]
+	}
+	if speed, err = strconv.Atoi(w); err != nil {
+		return
+	}
+
+	if scale != 1 {
+		speed = int(float32(speed)*scale + 0.5)
+		gust = int(float32(gust)*scale + 0.5)
+	}
+	return
+}
+
 type ATIS struct {
 	Airport  string
 	AppDep   string
@@ -250,6 +295,22 @@ type Aircraft struct {
 
 	hoursOnNetwork float32
 	requestedHours bool
+
+	// Cached result of FrequencyCheck() and when it was computed.
+	frequencyCheck          FrequencyCheck
+	frequencyCheckTime      time.Time
+	frequencyCheckTrackTime time.Time
+
+	// Cached result of MSAW() and the track it was computed for.
+	msaw          MSAWAlert
+	msawActive    bool
+	msawTrackTime time.Time
+
+	// Cached result of SUAAlerts() and the track and lookahead it was
+	// computed for.
+	suaAlerts          []SUAAlert
+	suaAlertMinutes    float32
+	suaAlertsTrackTime time.Time
 }
 
 type VATSIMTime time.Time
@@ -536,7 +597,7 @@ func (a *Aircraft) Groundspeed() int {
 
 // Note: returned value includes the magnetic correction
 func (a *Aircraft) Heading() float32 {
-	return a.Tracks[0].Heading + database.MagneticVariation
+	return a.Tracks[0].Heading + database.MagneticCorrection(a.Tracks[0].Position)
 }
 
 // Perhaps confusingly, the vector returned by HeadingVector() is not
@@ -546,14 +607,16 @@ func (a *Aircraft) Heading() float32 {
 // minute in the future.
 func (a *Aircraft) HeadingVector() Point2LL {
 	var v [2]float32
+	var nm float32
 	if !a.HaveHeading() {
 		v = [2]float32{cos(radians(a.Heading())), sin(radians(a.Heading()))}
+		nm = nmlength2ll(v, nmPerLongitudeAt(a.Position()[1]))
 	} else {
 		p0, p1 := a.Tracks[0].Position, a.Tracks[1].Position
 		v = sub2ll(p0, p1)
+		nm = nmdistance2ll(p0, p1)
 	}
 
-	nm := nmlength2ll(v)
 	// v's length should be groundspeed / 60 nm.
 	return scale2ll(v, float32(a.Groundspeed())/(60*nm))
 }
@@ -571,7 +634,7 @@ func (a *Aircraft) ExtrapolatedHeadingVector(lag float32) Point2LL {
 }
 
 func (a *Aircraft) HeadingTo(p Point2LL) float32 {
-	return headingp2ll(a.Position(), p, database.MagneticVariation)
+	return headingp2ll(a.Position(), p, database.MagneticCorrection(a.Position()))
 }
 
 func (a *Aircraft) LostTrack(now time.Time) bool {
@@ -620,6 +683,78 @@ func (a *Aircraft) OnGround() bool {
 	return false
 }
 
+// RouteDistance returns the distance in nm that the aircraft has left to
+// fly to the given fix or airport. If the fix is the destination airport
+// or is along the filed route, the distance follows the route; otherwise
+// it is the direct distance.
+func (a *Aircraft) RouteDistance(fix string) (float32, bool) {
+	target, ok := database.Locate(fix)
+	if !ok || !a.HaveTrack() {
+		return 0, false
+	}
+
+	var route []Point2LL
+	if fp := a.FlightPlan; fp != nil {
+		found := false
+		for _, f := range strings.Fields(fp.Route) {
+			// Strip speed and altitude changes, e.g. "MERIT/N0450F350".
+			f, _, _ = strings.Cut(f, "/")
+			if f == fix {
+				found = true
+				break
+			}
+			// Airways, SIDs, STARs, and the like won't be found, which is
+			// fine.
+			if p, ok := database.Locate(f); ok {
+				route = append(route, p)
+			}
+		}
+		if !found && fix != fp.ArrivalAirport {
+			route = nil
+		}
+	}
+	route = append(route, target)
+
+	// Find the closest point along the route; if the aircraft has already
+	// passed it, start from the one after it.
+	pos := a.Position()
+	closest := 0
+	for i, p := range route {
+		if nmdistance2ll(pos, p) < nmdistance2ll(pos, route[closest]) {
+			closest = i
+		}
+	}
+	if closest+1 < len(route) {
+		nmPerLongitude := nmPerLongitudeAt(pos[1])
+		p0, p1, pa := ll2nm(route[closest], nmPerLongitude), ll2nm(route[closest+1], nmPerLongitude),
+			ll2nm(pos, nmPerLongitude)
+		v, leg := sub2f(pa, p0), sub2f(p1, p0)
+		if v[0]*leg[0]+v[1]*leg[1] > 0 {
+			closest++
+		}
+	}
+
+	d := nmdistance2ll(pos, route[closest])
+	for i := closest; i+1 < len(route); i++ {
+		d += nmdistance2ll(route[i], route[i+1])
+	}
+	return d, true
+}
+
+// ETA returns the estimated time that the aircraft will reach the given
+// fix or airport, based on its current groundspeed and RouteDistance.
+func (a *Aircraft) ETA(fix string, now time.Time) (time.Time, bool) {
+	gs := a.Groundspeed()
+	if gs < 40 {
+		return time.Time{}, false
+	}
+	d, ok := a.RouteDistance(fix)
+	if !ok {
+		return time.Time{}, false
+	}
+	return now.Add(time.Duration(float64(d) / float64(gs) * float64(time.Hour))), true
+}
+
 func (a *Aircraft) GetFormattedFlightPlan(includeRemarks bool) (contents string, indent int) {
 	if plan := a.FlightPlan; plan == nil {
 		contents = "No flight plan"
@@ -63,3 +63,31 @@ func TestRECATDistances(t *testing.T) {
 		lg.Errorf("did not get error for invalid RECAT category")
 	}
 }
+
+func TestMETARParseWind(t *testing.T) {
+	type W struct {
+		wind             string
+		dir, speed, gust int
+	}
+
+	for _, w := range []W{W{wind: "27015G25KT", dir: 270, speed: 15, gust: 25},
+		W{wind: "00000KT", dir: 0, speed: 0, gust: 0},
+		W{wind: "VRB03KT", dir: -1, speed: 3, gust: 0},
+		W{wind: "09005MPS", dir: 90, speed: 10, gust: 0},
+	} {
+		m := METAR{Wind: w.wind}
+		if dir, speed, gust, err := m.ParseWind(); err != nil {
+			t.Errorf("%s: unexpected error %v", w.wind, err)
+		} else if dir != w.dir || speed != w.speed || gust != w.gust {
+			t.Errorf("%s: got dir %d speed %d gust %d; expected %d %d %d", w.wind,
+				dir, speed, gust, w.dir, w.speed, w.gust)
+		}
+	}
+
+	for _, wind := range []string{"", "27015", "2701KT", "ABC10KT"} {
+		m := METAR{Wind: wind}
+		if _, _, _, err := m.ParseWind(); err == nil {
+			t.Errorf("%s: expected error for invalid wind", wind)
+		}
+	}
+}
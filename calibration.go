@@ -0,0 +1,262 @@
+// calibration.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrTooFewCalibrationPoints = errors.New("At least two calibration points are needed")
+	ErrDegenerateCalibration   = errors.New("Calibration points are collinear or coincident")
+)
+
+// locateCalibrationPoint returns the location of a calibration point,
+// which may be given as the name of a fix, navaid, or airport, or as a
+// latitude-longitude pair.
+func locateCalibrationPoint(loc string) (Point2LL, bool) {
+	if p, ok := database.Locate(loc); ok {
+		return p, true
+	}
+	p, err := ParseLatLong(loc)
+	return p, err == nil
+}
+
+// ChartCalibrationPoint associates a point in an image with a location.
+type ChartCalibrationPoint struct {
+	// Fix, navaid, or airport, or a latitude-longitude pair (see
+	// ParseLatLong).
+	Location string
+	// Normalized [0,1]^2 image coordinates, with (0,0) at the lower-left
+	// corner of the image.
+	Image [2]float32
+}
+
+// ChartCalibration georeferences an image using a set of points at
+// known locations.
+type ChartCalibration struct {
+	Points []ChartCalibrationPoint
+	// If set and there are at least four points, a projective
+	// transformation is fit, which handles images that have been
+	// scanned at an angle to the page.
+	Projective bool
+}
+
+func (c *ChartCalibration) Duplicate() *ChartCalibration {
+	return &ChartCalibration{Points: DuplicateSlice(c.Points), Projective: c.Projective}
+}
+
+// LatLongFromImage returns a matrix that maps normalized image
+// coordinates to latitude-longitude; projectPoint should be used to
+// apply it.  With two points, the image is assumed to only be rotated and
+// uniformly scaled; with three or more, a least-squares affine fit is
+// used, which also handles shear and non-uniform scale.
+func (c *ChartCalibration) LatLongFromImage() (Matrix3, error) {
+	var img, nm [][2]float32
+	for _, pt := range c.Points {
+		if p, ok := locateCalibrationPoint(pt.Location); ok {
+			img = append(img, pt.Image)
+			nm = append(nm, nmFromLatLong(p))
+		}
+	}
+
+	var nmFromImage Matrix3
+	var err error
+	if len(img) < 2 {
+		return Matrix3{}, ErrTooFewCalibrationPoints
+	} else if len(img) == 2 {
+		nmFromImage, err = fitSimilarity(img, nm)
+	} else if len(img) >= 4 && c.Projective {
+		nmFromImage, err = fitProjective(img, nm)
+	} else {
+		nmFromImage, err = fitAffine(img, nm)
+	}
+	if err != nil {
+		return Matrix3{}, err
+	}
+
+	nmPerLongitude, nmPerLatitude := nmScale()
+	return Identity3x3().Scale(1/nmPerLongitude, 1/nmPerLatitude).PostMultiply(nmFromImage), nil
+}
+
+// Residuals returns the distance in nautical miles between each
+// calibration point's location and where the calibration maps its image
+// position to, or -1 for points whose location is unknown.
+func (c *ChartCalibration) Residuals() ([]float32, error) {
+	m, err := c.LatLongFromImage()
+	if err != nil {
+		return nil, err
+	}
+
+	var r []float32
+	for _, pt := range c.Points {
+		if p, ok := locateCalibrationPoint(pt.Location); ok {
+			r = append(r, nmdistance2ll(p, projectPoint(m, pt.Image)))
+		} else {
+			r = append(r, -1)
+		}
+	}
+	return r, nil
+}
+
+// projectPoint applies a projective transformation to a point; for
+// affine transformations, it gives the same result as
+// Matrix3.TransformPoint.
+func projectPoint(m Matrix3, p [2]float32) [2]float32 {
+	w := m[2][0]*p[0] + m[2][1]*p[1] + m[2][2]
+	q := m.TransformPoint(p)
+	return [2]float32{q[0] / w, q[1] / w}
+}
+
+// nmScale returns the number of nautical miles per degree of longitude
+// and latitude; calibration is done in nm so that fits aren't skewed by
+// degrees of longitude being shorter than degrees of latitude.
+func nmScale() (float32, float32) {
+	if database.NmPerLongitude == 0 || database.NmPerLatitude == 0 {
+		return 1, 1
+	}
+	return database.NmPerLongitude, database.NmPerLatitude
+}
+
+func nmFromLatLong(p Point2LL) [2]float32 {
+	nmPerLongitude, nmPerLatitude := nmScale()
+	return [2]float32{p[0] * nmPerLongitude, p[1] * nmPerLatitude}
+}
+
+// fitSimilarity returns the rotation, uniform scale, and translation that
+// maps the two src points to the two dst points.
+func fitSimilarity(src, dst [][2]float32) (Matrix3, error) {
+	ds, dd := sub2f(src[1], src[0]), sub2f(dst[1], dst[0])
+	if length2f(ds) == 0 || length2f(dd) == 0 {
+		return Matrix3{}, ErrDegenerateCalibration
+	}
+
+	theta := atan2(dd[1], dd[0]) - atan2(ds[1], ds[0])
+	scale := length2f(dd) / length2f(ds)
+	return Identity3x3().
+		Translate(dst[0][0], dst[0][1]).
+		Scale(scale, scale).
+		Rotate(theta).
+		Translate(-src[0][0], -src[0][1]), nil
+}
+
+// fitAffine returns the affine transformation that maps the src points to
+// the dst points with minimum squared error.
+func fitAffine(src, dst [][2]float32) (Matrix3, error) {
+	// Solve the normal equations for each of the two output coordinates:
+	// dst = a*src.x + b*src.y + c.
+	var ata [3][3]float64
+	var atb [2][3]float64
+	for i := range src {
+		row := [3]float64{float64(src[i][0]), float64(src[i][1]), 1}
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				ata[j][k] += row[j] * row[k]
+			}
+			atb[0][j] += row[j] * float64(dst[i][0])
+			atb[1][j] += row[j] * float64(dst[i][1])
+		}
+	}
+
+	var m Matrix3
+	for c := 0; c < 2; c++ {
+		a := [][]float64{ata[0][:], ata[1][:], ata[2][:]}
+		x, ok := solveLinearSystem(a, atb[c][:])
+		if !ok {
+			return Matrix3{}, ErrDegenerateCalibration
+		}
+		m[c] = [3]float32{float32(x[0]), float32(x[1]), float32(x[2])}
+	}
+	m[2] = [3]float32{0, 0, 1}
+	return m, nil
+}
+
+// fitProjective returns the projective transformation that maps the src
+// points to the dst points with minimum squared algebraic error; at least
+// four points are required.
+func fitProjective(src, dst [][2]float32) (Matrix3, error) {
+	// Work relative to the centroid of the destination points for
+	// better numerical conditioning.
+	var c [2]float64
+	for _, p := range dst {
+		c[0] += float64(p[0]) / float64(len(dst))
+		c[1] += float64(p[1]) / float64(len(dst))
+	}
+
+	// With h22 = 1, each point gives two equations that are linear in
+	// the remaining eight unknowns:
+	// u = h0 x + h1 y + h2 - h6 x u - h7 y u
+	// v = h3 x + h4 y + h5 - h6 x v - h7 y v
+	ata := make([][]float64, 8)
+	for i := range ata {
+		ata[i] = make([]float64, 8)
+	}
+	atb := make([]float64, 8)
+	addRow := func(row [8]float64, b float64) {
+		for j := 0; j < 8; j++ {
+			for k := 0; k < 8; k++ {
+				ata[j][k] += row[j] * row[k]
+			}
+			atb[j] += row[j] * b
+		}
+	}
+	for i := range src {
+		x, y := float64(src[i][0]), float64(src[i][1])
+		u, v := float64(dst[i][0])-c[0], float64(dst[i][1])-c[1]
+		addRow([8]float64{x, y, 1, 0, 0, 0, -x * u, -y * u}, u)
+		addRow([8]float64{0, 0, 0, x, y, 1, -x * v, -y * v}, v)
+	}
+
+	h, ok := solveLinearSystem(ata, atb)
+	if !ok {
+		return Matrix3{}, ErrDegenerateCalibration
+	}
+	m := MakeMatrix3(float32(h[0]), float32(h[1]), float32(h[2]),
+		float32(h[3]), float32(h[4]), float32(h[5]),
+		float32(h[6]), float32(h[7]), 1)
+	return Identity3x3().Translate(float32(c[0]), float32(c[1])).PostMultiply(m), nil
+}
+
+// solveLinearSystem solves the square linear system ax=b using Gaussian
+// elimination with partial pivoting. It returns false if the system is
+// singular. Neither a nor b is modified.
+func solveLinearSystem(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append(DuplicateSlice(a[i]), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for row := col + 1; row < n; row++ {
+			f := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= f * m[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		s := m[row][n]
+		for k := row + 1; k < n; k++ {
+			s -= m[row][k] * x[k]
+		}
+		x[row] = s / m[row][row]
+	}
+	return x, true
+}
@@ -0,0 +1,74 @@
+// calibration_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestFitAffine(t *testing.T) {
+	m := Identity3x3().Translate(3, -2).Rotate(0.3).Scale(2, 0.5)
+	m[0][1] += 0.1 // add some shear
+
+	src := [][2]float32{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0.25, 0.75}}
+	var dst [][2]float32
+	for _, p := range src {
+		dst = append(dst, m.TransformPoint(p))
+	}
+
+	fit, err := fitAffine(src, dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			if abs(fit[i][j]-m[i][j]) > 1e-4 {
+				t.Errorf("fit[%d][%d] = %f, expected %f", i, j, fit[i][j], m[i][j])
+			}
+		}
+	}
+
+	if _, err := fitAffine([][2]float32{{0, 0}, {1, 1}, {2, 2}}, dst[:3]); err == nil {
+		t.Errorf("expected error for collinear points")
+	}
+}
+
+func TestFitSimilarity(t *testing.T) {
+	src := [][2]float32{{0, 0}, {1, 0}}
+	dst := [][2]float32{{5, 5}, {5, 7}}
+	m, err := fitSimilarity(src, dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range src {
+		if p := m.TransformPoint(src[i]); distance2f(p, dst[i]) > 1e-4 {
+			t.Errorf("%v: mapped to %v, expected %v", src[i], p, dst[i])
+		}
+	}
+	// The perpendicular should be rotated and scaled as well.
+	if p := m.TransformPoint([2]float32{0, 1}); distance2f(p, [2]float32{3, 5}) > 1e-4 {
+		t.Errorf("(0,1) mapped to %v, expected (3,5)", p)
+	}
+}
+
+func TestFitProjective(t *testing.T) {
+	m := MakeMatrix3(2, 0.3, 100, -0.2, 1.5, -50, 0.1, -0.05, 1)
+
+	src := [][2]float32{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0.5, 0.25}, {0.2, 0.9}}
+	var dst [][2]float32
+	for _, p := range src {
+		dst = append(dst, projectPoint(m, p))
+	}
+
+	fit, err := fitProjective(src, dst)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range append(src, [2]float32{0.7, 0.3}) {
+		if q, r := projectPoint(fit, p), projectPoint(m, p); distance2f(q, r) > 1e-3 {
+			t.Errorf("%v: mapped to %v, expected %v", p, q, r)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// chartoverlay.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// ChartOverlay draws a georeferenced image (an airport diagram, approach
+// plate, sectional, ...) in a RadarScopePane underneath the traffic.
+type ChartOverlay struct {
+	Enabled     bool
+	Filename    string
+	Opacity     float32
+	Invert      bool
+	Calibration ChartCalibration
+
+	texId     uint32
+	imageSize [2]int
+	loadChan  chan LoadedImage
+	cancel    context.CancelFunc
+
+	fileSelectDialog *FileSelectDialogBox
+}
+
+func NewChartOverlay() ChartOverlay {
+	return ChartOverlay{Opacity: 0.5}
+}
+
+func (co *ChartOverlay) Duplicate() ChartOverlay {
+	dupe := ChartOverlay{
+		Enabled:     co.Enabled,
+		Filename:    co.Filename,
+		Opacity:     co.Opacity,
+		Invert:      co.Invert,
+		Calibration: *co.Calibration.Duplicate(),
+	}
+	dupe.Activate()
+	return dupe
+}
+
+func (co *ChartOverlay) Activate() {
+	if co.Opacity == 0 {
+		co.Opacity = 0.5
+	}
+	co.load()
+}
+
+func (co *ChartOverlay) Deactivate() {
+	co.unload()
+}
+
+func (co *ChartOverlay) load() {
+	if co.Filename == "" || !co.Enabled {
+		return
+	}
+
+	var ctx context.Context
+	ctx, co.cancel = context.WithCancel(context.Background())
+	co.loadChan = make(chan LoadedImage, 1)
+	go loadImage(ctx, co.Filename, co.Invert, co.loadChan)
+}
+
+func (co *ChartOverlay) unload() {
+	if co.cancel != nil {
+		co.cancel()
+		co.cancel = nil
+	}
+	co.loadChan = nil
+	if co.texId != 0 {
+		renderer.DestroyTexture(co.texId)
+		co.texId = 0
+	}
+}
+
+func (co *ChartOverlay) reload() {
+	co.unload()
+	co.load()
+}
+
+// imageFromPixel converts pixel coordinates, with (0,0) at the upper left
+// of the image, to normalized image coordinates.
+func (co *ChartOverlay) imageFromPixel(p [2]float32) [2]float32 {
+	return [2]float32{p[0] / float32(co.imageSize[0]), 1 - p[1]/float32(co.imageSize[1])}
+}
+
+func (co *ChartOverlay) pixelFromImage(p [2]float32) [2]float32 {
+	return [2]float32{p[0] * float32(co.imageSize[0]), (1 - p[1]) * float32(co.imageSize[1])}
+}
+
+func (co *ChartOverlay) DrawUI() {
+	if imgui.Checkbox("Draw chart", &co.Enabled) {
+		co.reload()
+	}
+	if !co.Enabled {
+		return
+	}
+
+	imgui.Text("Chart: " + Select(co.Filename == "", "(none)", filepath.Base(co.Filename)))
+	imgui.SameLine()
+	if imgui.Button("Select...##chart") {
+		if co.fileSelectDialog == nil {
+			co.fileSelectDialog = NewFileSelectDialogBox("Select chart image...",
+				[]string{".png", ".jpg", ".jpeg"}, co.Filename,
+				func(fn string) {
+					co.Filename = fn
+					co.reload()
+				})
+		}
+		co.fileSelectDialog.Activate()
+	}
+	if co.fileSelectDialog != nil {
+		co.fileSelectDialog.Draw()
+	}
+
+	imgui.SliderFloatV("Opacity", &co.Opacity, 0.05, 1, "%.2f", 0)
+	if imgui.Checkbox("Invert chart", &co.Invert) {
+		co.reload()
+	}
+
+	if co.texId == 0 {
+		imgui.Text("Loading chart...")
+		return
+	}
+
+	imgui.Text("Calibration points (pixel coordinates from the upper left):")
+	flags := imgui.TableFlagsBordersV | imgui.TableFlagsBordersOuterH | imgui.TableFlagsRowBg
+	remove := -1
+	if imgui.BeginTableV("calibration", 3, flags, imgui.Vec2{}, 0) {
+		imgui.TableSetupColumn("Fix")
+		imgui.TableSetupColumn("Pixel")
+		imgui.TableSetupColumn("")
+		imgui.TableHeadersRow()
+
+		for i := range co.Calibration.Points {
+			pt := &co.Calibration.Points[i]
+			imgui.PushID(fmt.Sprintf("%d", i))
+			imgui.TableNextRow()
+			imgui.TableNextColumn()
+			imgui.InputTextV("##fix", &pt.Location, imgui.InputTextFlagsCharsUppercase, nil)
+			if _, ok := locateCalibrationPoint(pt.Location); !ok && pt.Location != "" {
+				imgui.SameLine()
+				imgui.Text(FontAwesomeIconExclamationTriangle)
+			}
+			imgui.TableNextColumn()
+			p := co.pixelFromImage(pt.Image)
+			if imgui.DragFloat2V("##pixel", &p, 1, 0, 0, "%.0f", 0) {
+				pt.Image = co.imageFromPixel(p)
+			}
+			imgui.TableNextColumn()
+			if imgui.Button(FontAwesomeIconTrash) {
+				remove = i
+			}
+			imgui.PopID()
+		}
+		imgui.EndTable()
+	}
+	if remove != -1 {
+		co.Calibration.Points = DeleteSliceElement(co.Calibration.Points, remove)
+	}
+
+	if imgui.Button("Add point") {
+		co.Calibration.Points = append(co.Calibration.Points, ChartCalibrationPoint{})
+	}
+
+	// Allow picking up a calibration that was done in an image viewer.
+	var ivCal *ChartCalibration
+	positionConfig.DisplayRoot.VisitPanes(func(p Pane) {
+		if iv, ok := p.(*ImageViewPane); ok {
+			if cal, ok := iv.Calibrations[iv.SelectedImage]; ok &&
+				filepath.Join(iv.Directory, iv.SelectedImage) == co.Filename {
+				ivCal = cal
+			}
+		}
+	})
+	if ivCal != nil && len(ivCal.Points) > 0 {
+		imgui.SameLine()
+		if imgui.Button("Copy from image viewer") {
+			co.Calibration = *ivCal.Duplicate()
+		}
+	}
+	if len(co.Calibration.Points) >= 4 {
+		imgui.Checkbox("Projective fit", &co.Calibration.Projective)
+	}
+
+	if _, err := co.Calibration.LatLongFromImage(); err != nil {
+		imgui.Text(err.Error())
+	}
+}
+
+// Draw draws the chart; the current viewing matrices may be changed.
+func (co *ChartOverlay) Draw(transforms ScopeTransformations, cb *CommandBuffer) {
+	if co.loadChan != nil {
+		select {
+		case im := <-co.loadChan:
+			co.texId = renderer.CreateTextureFromImages(im.Pyramid)
+			b := im.Pyramid[0].Bounds()
+			co.imageSize = [2]int{b.Dx(), b.Dy()}
+			co.loadChan = nil
+		default:
+		}
+	}
+
+	if !co.Enabled || co.texId == 0 {
+		return
+	}
+	llFromImage, err := co.Calibration.LatLongFromImage()
+	if err != nil {
+		return
+	}
+
+	// Map a grid over the image to lat-long and draw textured quads for
+	// its cells. Texture coordinates are interpolated linearly, so a
+	// single quad would be fine for affine calibrations, but projective
+	// ones need the subdivision.
+	const n = 16
+	td := GetTexturedTrianglesDrawBuilder()
+	defer ReturnTexturedTrianglesDrawBuilder(td)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var p, uv [4][2]float32
+			for i, d := range [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+				pi := [2]float32{float32(x+d[0]) / n, float32(y+d[1]) / n}
+				p[i] = projectPoint(llFromImage, pi)
+				uv[i] = [2]float32{pi[0], 1 - pi[1]}
+			}
+			td.AddQuad(p[0], p[1], p[2], p[3], uv[0], uv[1], uv[2], uv[3])
+		}
+	}
+
+	transforms.LoadLatLongViewingMatrices(cb)
+	cb.SetRGBA(RGBA{1, 1, 1, co.Opacity})
+	cb.Blend()
+	td.GenerateCommands(co.texId, cb)
+	cb.DisableBlend()
+}
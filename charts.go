@@ -0,0 +1,221 @@
+// charts.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ChartIndex organizes the images in a chart directory by airport and
+// procedure so that they can be searched. The airport and procedure for
+// each image are found by filename conventions: the airport may be given
+// by a directory name ("KJFK/ILS 4R.png") or by the first word of the
+// filename ("KJFK-ILS-RWY-04R.png"), and the rest of the filename is taken
+// to be the procedure. Alternatively, a charts.json file in the directory
+// may give them explicitly:
+//
+//	{ "00610IL4R.png": { "Airport": "KJFK", "Procedure": "ILS RWY 04R" } }
+type ChartIndex struct {
+	Entries []ChartIndexEntry
+}
+
+type ChartIndexEntry struct {
+	// Relative to the chart directory
+	Filename  string
+	Airport   string
+	Procedure string
+}
+
+const chartIndexSidecarFilename = "charts.json"
+
+func (e ChartIndexEntry) Label() string {
+	if e.Airport == "" && e.Procedure == "" {
+		return e.Filename
+	}
+	return strings.TrimSpace(e.Airport + " " + e.Procedure)
+}
+
+// NewChartIndex returns an index of the given images in the directory,
+// which are specified relative to it.
+func NewChartIndex(dir string, filenames []string) *ChartIndex {
+	var sidecar map[string]ChartIndexEntry
+	if b, err := os.ReadFile(filepath.Join(dir, chartIndexSidecarFilename)); err == nil {
+		if err := json.Unmarshal(b, &sidecar); err != nil {
+			lg.Errorf("%s: %v", chartIndexSidecarFilename, err)
+		}
+	}
+
+	ci := &ChartIndex{}
+	for _, fn := range filenames {
+		e, ok := sidecar[filepath.ToSlash(fn)]
+		if ok {
+			e.Airport = strings.ToUpper(e.Airport)
+			e.Procedure = strings.ToUpper(e.Procedure)
+		} else {
+			e.Airport, e.Procedure = parseChartFilename(fn)
+		}
+		e.Filename = fn
+		ci.Entries = append(ci.Entries, e)
+	}
+	sort.Slice(ci.Entries, func(i, j int) bool {
+		a, b := ci.Entries[i], ci.Entries[j]
+		if a.Airport != b.Airport {
+			return a.Airport < b.Airport
+		}
+		return a.Label() < b.Label()
+	})
+	return ci
+}
+
+// isChartAirport reports whether the given filename component names a
+// known airport, returning its ICAO code if so.
+func isChartAirport(s string) (string, bool) {
+	s = strings.ToUpper(s)
+	if _, ok := database.airports[s]; ok {
+		return s, true
+	}
+	if len(s) == 3 {
+		if _, ok := database.airports["K"+s]; ok {
+			return "K" + s, true
+		}
+	}
+	return "", false
+}
+
+// parseChartFilename returns the airport and procedure for a chart image
+// based on its filename (relative to the chart directory).
+func parseChartFilename(fn string) (airport, procedure string) {
+	fn = filepath.ToSlash(strings.TrimSuffix(fn, filepath.Ext(fn)))
+	dirs := strings.Split(fn, "/")
+	base := dirs[len(dirs)-1]
+	for _, d := range dirs[:len(dirs)-1] {
+		if ap, ok := isChartAirport(d); ok {
+			airport = ap
+		}
+	}
+
+	words := strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+	if len(words) > 1 {
+		if ap, ok := isChartAirport(words[0]); ok {
+			airport = ap
+			words = words[1:]
+		}
+	}
+	return airport, strings.ToUpper(strings.Join(words, " "))
+}
+
+// chartSearchTokens splits a string into normalized tokens for chart
+// searches: "RWY04R" and "RWY 4R" both give the single token "4R", and
+// "ILS4R" gives "ILS" and "4R".
+func chartSearchTokens(s string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		// Split at transitions from letters to digits.
+		start := 0
+		for i := 1; i <= len(word); i++ {
+			if i == len(word) || (unicode.IsLetter(rune(word[i-1])) && unicode.IsDigit(rune(word[i]))) {
+				t := word[start:i]
+				start = i
+				if t == "RWY" || t == "RUNWAY" {
+					continue
+				}
+				if unicode.IsDigit(rune(t[0])) {
+					if u := strings.TrimLeft(t, "0"); u != "" && unicode.IsDigit(rune(u[0])) {
+						t = u
+					}
+				}
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// matchChartToken returns a score for how well the query token matches the
+// chart token, or 0 if it doesn't match at all.
+func matchChartToken(q, t string) int {
+	if q == t {
+		return 2
+	}
+	if unicode.IsDigit(rune(q[0])) {
+		// A runway number matches all of its parallel runways.
+		if len(t) == len(q)+1 && strings.HasPrefix(t, q) && strings.ContainsAny(t[len(q):], "LRC") {
+			return 1
+		}
+		return 0
+	}
+	if strings.HasPrefix(t, q) {
+		return 1
+	}
+	return 0
+}
+
+// Search returns the charts that match the query (e.g., "KJFK ILS 4R"),
+// best matches first. All of the query's words must match.
+func (ci *ChartIndex) Search(query string) []ChartIndexEntry {
+	qt := chartSearchTokens(query)
+	if len(qt) == 0 {
+		return nil
+	}
+
+	type match struct {
+		entry ChartIndexEntry
+		score int
+	}
+	var matches []match
+	for _, e := range ci.Entries {
+		et := chartSearchTokens(e.Procedure + " " + filepath.Base(e.Filename))
+		if e.Airport != "" {
+			et = append(et, e.Airport)
+			if len(e.Airport) == 4 && e.Airport[0] == 'K' {
+				et = append(et, e.Airport[1:])
+			}
+		}
+
+		score := 0
+		for _, q := range qt {
+			best := 0
+			for _, t := range et {
+				best = max(best, matchChartToken(q, t))
+			}
+			if best == 0 {
+				score = 0
+				break
+			}
+			score += best
+		}
+		if score > 0 {
+			matches = append(matches, match{entry: e, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return MapSlice(matches, func(m match) ChartIndexEntry { return m.entry })
+}
+
+// ForAirport returns all of the charts for the given airport.
+func (ci *ChartIndex) ForAirport(icao string) []ChartIndexEntry {
+	return FilterSlice(ci.Entries, func(e ChartIndexEntry) bool { return e.Airport == icao })
+}
+
+// SuggestedCharts returns the charts for the aircraft's departure and
+// arrival airports.
+func (ci *ChartIndex) SuggestedCharts(ac *Aircraft) []ChartIndexEntry {
+	if ac == nil || ac.FlightPlan == nil {
+		return nil
+	}
+	charts := ci.ForAirport(ac.FlightPlan.DepartureAirport)
+	if ac.FlightPlan.ArrivalAirport != ac.FlightPlan.DepartureAirport {
+		charts = append(charts, ci.ForAirport(ac.FlightPlan.ArrivalAirport)...)
+	}
+	return charts
+}
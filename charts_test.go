@@ -0,0 +1,44 @@
+// charts_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestChartSearch(t *testing.T) {
+	ci := &ChartIndex{Entries: []ChartIndexEntry{
+		{Filename: "a.png", Airport: "KJFK", Procedure: "ILS OR LOC RWY 04R"},
+		{Filename: "b.png", Airport: "KJFK", Procedure: "ILS OR LOC RWY 04L"},
+		{Filename: "c.png", Airport: "KJFK", Procedure: "RNAV (GPS) RWY 13L"},
+		{Filename: "d.png", Airport: "KLGA", Procedure: "ILS RWY 4"},
+		{Filename: "e.png", Airport: "KJFK", Procedure: "AIRPORT DIAGRAM"},
+	}}
+
+	for _, test := range []struct {
+		query    string
+		expected []string
+	}{
+		{"KJFK ILS 4R", []string{"a.png"}},
+		{"jfk ils rwy04r", []string{"a.png"}},
+		{"KJFK ILS 4", []string{"a.png", "b.png"}},
+		{"ILS 4", []string{"d.png", "a.png", "b.png"}},
+		{"KJFK RNAV 13L", []string{"c.png"}},
+		{"JFK DIAG", []string{"e.png"}},
+		{"KJFK VOR", nil},
+	} {
+		results := MapSlice(ci.Search(test.query), func(e ChartIndexEntry) string { return e.Filename })
+		if len(results) != len(test.expected) {
+			t.Errorf("%s: got %v, expected %v", test.query, results, test.expected)
+			continue
+		}
+		for i := range results {
+			if results[i] != test.expected[i] {
+				t.Errorf("%s: got %v, expected %v", test.query, results, test.expected)
+				break
+			}
+		}
+	}
+}
@@ -102,6 +102,10 @@ type CLIPane struct {
 	FontIdentifier FontIdentifier
 	font           *Font
 
+	// Takeoffs, landings, go-arounds, and holds at these airports are
+	// logged to the console.
+	MovementAirports map[string]interface{}
+
 	input    CLIInput
 	status   string
 	eventsId EventSubscriberId
@@ -118,10 +122,11 @@ func NewCLIPane() *CLIPane {
 
 func (cli *CLIPane) Duplicate(nameAsCopy bool) Pane {
 	return &CLIPane{
-		FontIdentifier: cli.FontIdentifier,
-		font:           cli.font,
-		console:        NewRingBuffer[*ConsoleEntry](consoleLimit),
-		eventsId:       eventStream.Subscribe(),
+		FontIdentifier:   cli.FontIdentifier,
+		font:             cli.font,
+		MovementAirports: DuplicateMap(cli.MovementAirports),
+		console:          NewRingBuffer[*ConsoleEntry](consoleLimit),
+		eventsId:         eventStream.Subscribe(),
 	}
 }
 
@@ -133,6 +138,9 @@ func (cli *CLIPane) Activate() {
 	if cli.console == nil {
 		cli.console = NewRingBuffer[*ConsoleEntry](consoleLimit)
 	}
+	if cli.MovementAirports == nil {
+		cli.MovementAirports = make(map[string]interface{})
+	}
 	if *devmode {
 		lg.RegisterErrorMonitor(cli)
 	}
@@ -210,10 +218,36 @@ func (cli *CLIPane) processEvents(es *EventStream) {
 		case *CanceledHandoffEvent:
 			cli.AddConsoleEntry([]string{v.controller, ": canceled handoff offer " + v.ac.Callsign},
 				[]ConsoleTextStyle{ConsoleTextEmphasized, ConsoleTextRegular})
+
+		case *DepartedEvent:
+			cli.logMovement(v.ac, v.airport, "departed", v.runway, v.time)
+
+		case *LandedEvent:
+			cli.logMovement(v.ac, v.airport, "landed", v.runway, v.time)
+
+		case *GoAroundEvent:
+			cli.logMovement(v.ac, v.airport, "went around", v.runway, v.time)
+
+		case *HoldingEvent:
+			cli.logMovement(v.ac, v.airport, "holding", "", v.time)
 		}
 	}
 }
 
+func (cli *CLIPane) logMovement(ac *Aircraft, airport string, what string, runway string, t time.Time) {
+	if _, ok := cli.MovementAirports[airport]; !ok {
+		return
+	}
+
+	msg := ": " + what
+	if runway != "" {
+		msg += " runway " + runway
+	}
+	msg += " at " + t.UTC().Format("1504Z")
+	cli.AddConsoleEntry([]string{airport + " " + ac.Callsign, msg},
+		[]ConsoleTextStyle{ConsoleTextEmphasized, ConsoleTextRegular})
+}
+
 func (cli *CLIPane) getReplyId(m *TextMessage) int {
 	if strings.ToUpper(m.sender) == "SERVER" {
 		return -1
@@ -268,6 +302,7 @@ func (cli *CLIPane) DrawUI() {
 	if newFont, changed := DrawFontPicker(&cli.FontIdentifier, "Font"); changed {
 		cli.font = newFont
 	}
+	cli.MovementAirports, _ = drawAirportSelector(cli.MovementAirports, "Log movements at airports")
 }
 
 func (cli *CLIPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
@@ -616,10 +651,8 @@ func (cli *CLIPane) updateInput(consoleLinesVisible int, keyboard *KeyboardState
 			if !cli.input.TabPrev() {
 				cli.status = "no parameter stops"
 			}
-		} else {
-			if !cli.input.TabNext() {
-				cli.status = "no parameter stops"
-			}
+		} else if !cli.input.TabNext() && !cli.completeInput() {
+			cli.status = "no parameter stops"
 		}
 	}
 
@@ -648,6 +681,53 @@ func (cli *CLIPane) updateInput(consoleLinesVisible int, keyboard *KeyboardState
 	return keyboard.IsPressed(KeyEnter)
 }
 
+// completeInput tries to complete the last word of the input if the
+// command supports completions. It returns false if it doesn't.
+func (cli *CLIPane) completeInput() bool {
+	fields := strings.Fields(cli.input.cmd)
+	if len(fields) < 2 || cli.input.cursor != len(cli.input.cmd) || strings.HasSuffix(cli.input.cmd, " ") {
+		return false
+	}
+	cmd, ok := lookupCommand(fields[0]).(CLICommandCompleter)
+	if !ok {
+		return false
+	}
+
+	word := fields[len(fields)-1]
+	completions := cmd.Complete(word)
+	if len(completions) == 0 {
+		cli.status = "no completions"
+		return true
+	}
+
+	// Complete as much as is common to all of the completions; for
+	// approximate matches, there may be nothing in common.
+	common := completions[0]
+	for _, c := range completions[1:] {
+		n := 0
+		for n < len(common) && n < len(c) && common[n] == c[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(common) > len(word) || len(completions) == 1 {
+		cli.input.cmd = strings.TrimSuffix(cli.input.cmd, word) + common
+		if len(completions) == 1 {
+			cli.input.cmd += " "
+		}
+		cli.input.cursor = len(cli.input.cmd)
+	}
+	if len(completions) > 1 {
+		if len(completions) > 10 {
+			completions = append(completions[:10], "...")
+		}
+		cli.status = strings.Join(completions, " ")
+	} else {
+		cli.status = ""
+	}
+	return true
+}
+
 func matchingAircraft(s string) []*Aircraft {
 	s = strings.ToUpper(s)
 
@@ -833,7 +913,7 @@ func (cli *CLIPane) expandVariables(cmd string) (expanded string, err error) {
 			// this currently gives the direction to fix with respect to
 			// the aircraft, so e.g. "Kennedy is to your $bear(kjfk)"
 			fixarg(funarg(), func(ac *Aircraft, p Point2LL) string {
-				heading := headingp2ll(ac.Position(), p, database.MagneticVariation)
+				_, heading := ellipsoidalHeading(ac.Position(), p)
 				return compass(heading)
 			})
 
@@ -860,7 +940,7 @@ func (cli *CLIPane) expandVariables(cmd string) (expanded string, err error) {
 
 		case "dist":
 			fixarg(funarg(), func(ac *Aircraft, p Point2LL) string {
-				dist := nmdistance2ll(ac.Position(), p)
+				dist, _ := ellipsoidalInverse(ac.Position(), p)
 				idist := int(dist + 0.5)
 				if idist <= 1 {
 					return "1 mile"
@@ -891,7 +971,8 @@ func (cli *CLIPane) expandVariables(cmd string) (expanded string, err error) {
 
 		case "oclock":
 			fixarg(funarg(), func(ac *Aircraft, p Point2LL) string {
-				heading := headingp2ll(ac.Position(), p, database.MagneticVariation) - ac.Heading()
+				_, heading := ellipsoidalHeading(ac.Position(), p)
+				heading -= ac.Heading()
 				return fmt.Sprintf("%d", headingAsHour(heading))
 			})
 
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -33,6 +34,12 @@ type CLICommand interface {
 	Run(cmd string, ac *Aircraft, ctrl *Controller, args []string, cli *CLIPane) []*ConsoleEntry
 }
 
+// CLICommandCompleter may be implemented by CLICommands that can suggest
+// completions for a partially-typed argument.
+type CLICommandCompleter interface {
+	Complete(arg string) []string
+}
+
 var (
 	cliCommands []CLICommand = []CLICommand{
 		&NYPRDCommand{},
@@ -41,6 +48,9 @@ var (
 		&DrawRouteCommand{},
 		&FlagAircraftCommand{},
 		&InfoCommand{},
+		&NotesCommand{},
+		&FilterCommand{},
+		&ChartCommand{},
 	}
 )
 
@@ -288,8 +298,8 @@ func (*FindCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 		return ErrorStringConsoleEntry("Multiple aircraft match: " + strings.Join(callsigns, ", "))
 	} else {
 		var ok bool
-		if pos, ok = database.Locate(name); !ok {
-			return ErrorStringConsoleEntry(args[0] + ": no matches found")
+		if pos, ok = database.LocateNear(name, scopeCenter()); !ok {
+			return ErrorStringConsoleEntry(args[0] + ": no matches found" + suggestNames(name))
 		}
 	}
 
@@ -298,6 +308,49 @@ func (*FindCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 	return nil
 }
 
+func (*FindCommand) Complete(arg string) []string { return completeObjectName(arg) }
+
+// scopeCenter returns the center of the first radar scope in the display,
+// or the sector file's center if there isn't one.
+func scopeCenter() Point2LL {
+	center, found := database.defaultCenter, false
+	positionConfig.DisplayRoot.VisitPanes(func(p Pane) {
+		if rs, ok := p.(*RadarScopePane); ok && !found {
+			center, found = rs.Center, true
+		}
+	})
+	return center
+}
+
+// completeObjectName returns possible completions for a partially-typed
+// aircraft callsign or name of a fix, navaid, airport, etc.
+func completeObjectName(arg string) []string {
+	arg = strings.ToUpper(arg)
+	var names []string
+	for _, ac := range matchingAircraft(arg) {
+		if strings.HasPrefix(ac.Callsign, arg) {
+			names = append(names, ac.Callsign)
+		}
+	}
+	sort.Strings(names)
+	for _, r := range database.Search(arg, scopeCenter(), 20) {
+		if FindIf(names, func(n string) bool { return n == r.Name }) == -1 {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// suggestNames returns a suggestion of similarly-named things in the
+// database for use in error messages, if there are any.
+func suggestNames(name string) string {
+	results := database.Search(name, scopeCenter(), 5)
+	if len(results) == 0 {
+		return ""
+	}
+	return ". Did you mean: " + strings.Join(MapSlice(results, func(r SearchResult) string { return r.Name }), ", ") + "?"
+}
+
 type DrawRouteCommand struct{}
 
 func (*DrawRouteCommand) Names() []string                    { return []string{"drawroute", "dr"} }
@@ -360,6 +413,7 @@ func (*InfoCommand) AdditionalArgs() (min int, max int) { return 0, 1 }
 func (*InfoCommand) Help() string {
 	return "Prints available information about the specified object."
 }
+func (*InfoCommand) Complete(arg string) []string { return completeObjectName(arg) }
 func (*InfoCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []string, cli *CLIPane) []*ConsoleEntry {
 	acInfo := func(ac *Aircraft) string {
 		var result string
@@ -404,11 +458,34 @@ func (*InfoCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 		}
 		if ac.HaveTrack() {
 			result += fmt.Sprintf("\n%scralt: %d", indstr, ac.Altitude())
+			if airspace := database.AirspaceContaining(ac.Position(), ac.Altitude()); len(airspace) > 0 {
+				result += fmt.Sprintf("\n%sairsp: %s", indstr, strings.Join(airspace, ", "))
+			}
+			if name, minutes, ok := ac.NextBoundaryCrossing(30); ok {
+				result += fmt.Sprintf("\n%sbndry: %s in %d min", indstr, name, int(minutes+0.5))
+			}
+			if fc := ac.FrequencyCheck(); fc.Owner != "" {
+				result += fmt.Sprintf("\n%sowner: %s %s (%s)", indstr, fc.Owner, fc.OwnerFrequency, fc.Sector)
+			}
+			if mva, ok := database.MinimumAltitude(ac.Position()); ok {
+				result += fmt.Sprintf("\n%smva:   %d", indstr, mva)
+			}
 		}
 		result += fmt.Sprintf("\n%shours: %d", indstr, int(ac.HoursOnNetwork(true)))
 		if ac.Squawk != ac.AssignedSquawk {
 			result += fmt.Sprintf("\n%s*** Actual squawk: %s", indstr, ac.Squawk)
 		}
+		if fc := ac.FrequencyCheck(); fc.Problem != "" {
+			result += fmt.Sprintf("\n%s*** Wrong frequency: %s", indstr, fc.Problem)
+		}
+		if alert, ok := ac.MSAW(); ok {
+			if alert.Minutes == 0 {
+				result += fmt.Sprintf("\n%s*** Low altitude: below MVA %d", indstr, alert.Minimum)
+			} else {
+				result += fmt.Sprintf("\n%s*** Low altitude: below MVA %d in %.0f sec", indstr, alert.Minimum,
+					60*alert.Minutes)
+			}
+		}
 		if ac.LostTrack(server.CurrentTime()) {
 			result += fmt.Sprintf("\n%s*** Lost Track!", indstr)
 		}
@@ -427,6 +504,13 @@ func (*InfoCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 		if fix, ok := database.FAA.fixes[name]; ok {
 			info = append(info, fmt.Sprintf("%s: Fix %s", name, fix.Location.DMSString()))
 		}
+		for _, navaid := range database.world.navaids[name] {
+			info = append(info, fmt.Sprintf("%s: %s %s %s", name, stopShouting(navaid.Name),
+				navaid.Type, navaid.Location.DMSString()))
+		}
+		for _, fix := range database.world.fixes[name] {
+			info = append(info, fmt.Sprintf("%s: Fix %s", name, fix.Location.DMSString()))
+		}
 		if ap, ok := database.airports[name]; ok {
 			info = append(info, fmt.Sprintf("%s: %s: %s, alt %d", name, stopShouting(ap.Name),
 				ap.Location.DMSString(), ap.Elevation))
@@ -461,7 +545,7 @@ func (*InfoCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 			callsigns := MapSlice(aircraft, func(a *Aircraft) string { return a.Callsign })
 			return ErrorStringConsoleEntry("Multiple aircraft match: " + strings.Join(callsigns, ", "))
 		} else {
-			return ErrorStringConsoleEntry(name + ": unknown")
+			return ErrorStringConsoleEntry(name + ": unknown" + suggestNames(name))
 		}
 	} else if positionConfig.selectedAircraft != nil {
 		return StringConsoleEntry(acInfo(positionConfig.selectedAircraft))
@@ -469,3 +553,145 @@ func (*InfoCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []strin
 		return ErrorStringConsoleEntry(cmd + ": must either specify a fix/VOR/etc. or select an aircraft")
 	}
 }
+
+type NotesCommand struct{}
+
+func (*NotesCommand) Names() []string                    { return []string{"notes"} }
+func (*NotesCommand) Usage() string                      { return "<topic>" }
+func (*NotesCommand) TakesAircraft() bool                { return false }
+func (*NotesCommand) TakesController() bool              { return false }
+func (*NotesCommand) AdditionalArgs() (min int, max int) { return 0, 10 }
+func (*NotesCommand) Help() string {
+	return "Shows the section of the notes file with the given topic in any notes panes, " +
+		"or prints it if there are none. With no topic, lists the top-level topics."
+}
+func (*NotesCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []string, cli *CLIPane) []*ConsoleEntry {
+	root := globalConfig.notesRoot
+	if root == nil {
+		return ErrorStringConsoleEntry("notes: no notes file has been loaded")
+	}
+
+	if len(args) == 0 {
+		titles := MapSlice(root.children, func(n *NotesNode) string { return n.title })
+		return StringConsoleEntry(strings.Join(titles, "\n"))
+	}
+
+	topic := strings.Join(args, " ")
+	node, path := root.FindTopic(topic)
+	if node == nil {
+		return ErrorStringConsoleEntry(topic + ": no matching notes found")
+	}
+
+	shown := false
+	positionConfig.DisplayRoot.VisitPanes(func(p Pane) {
+		if np, ok := p.(*NotesPane); ok {
+			np.JumpTo(path)
+			shown = true
+		}
+	})
+	if shown {
+		return nil
+	}
+	return StringConsoleEntry(strings.Join(append([]string{strings.Join(path, " > ")}, node.text...), "\n"))
+}
+
+type FilterCommand struct{}
+
+func (*FilterCommand) Names() []string                    { return []string{"filter"} }
+func (*FilterCommand) Usage() string                      { return "<expression>" }
+func (*FilterCommand) TakesAircraft() bool                { return false }
+func (*FilterCommand) TakesController() bool              { return false }
+func (*FilterCommand) AdditionalArgs() (min int, max int) { return 0, 100 }
+func (*FilterCommand) Help() string {
+	return "Lists the aircraft that match the given filter expression (e.g., \"arr=KJFK & alt<10000\"). " +
+		"With no expression, lists the saved filters."
+}
+func (*FilterCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []string, cli *CLIPane) []*ConsoleEntry {
+	if len(args) == 0 {
+		if len(globalConfig.AircraftFilters) == 0 {
+			return StringConsoleEntry("No saved filters.")
+		}
+		var lines []string
+		for _, name := range SortedMapKeys(globalConfig.AircraftFilters) {
+			lines = append(lines, "@"+name+": "+globalConfig.AircraftFilters[name])
+		}
+		return StringConsoleEntry(strings.Join(lines, "\n"))
+	}
+
+	filter, err := CompileAircraftFilter(strings.Join(args, " "))
+	if err != nil {
+		return ErrorStringConsoleEntry("filter: " + err.Error())
+	}
+
+	now := server.CurrentTime()
+	matches := server.GetFilteredAircraft(func(ac *Aircraft) bool {
+		return !ac.LostTrack(now) && filter(ac)
+	})
+	if len(matches) == 0 {
+		return StringConsoleEntry("No matching aircraft.")
+	}
+
+	callsigns := MapSlice(matches, func(ac *Aircraft) string { return ac.Callsign })
+	sort.Strings(callsigns)
+	var lines []string
+	for len(callsigns) > 0 {
+		n := min(8, len(callsigns))
+		lines = append(lines, strings.Join(callsigns[:n], " "))
+		callsigns = callsigns[n:]
+	}
+	lines = append(lines, fmt.Sprintf("%d aircraft", len(matches)))
+	return StringConsoleEntry(strings.Join(lines, "\n"))
+}
+
+type ChartCommand struct{}
+
+func (*ChartCommand) Names() []string                    { return []string{"chart"} }
+func (*ChartCommand) Usage() string                      { return "<airport> <procedure...>" }
+func (*ChartCommand) TakesAircraft() bool                { return true }
+func (*ChartCommand) TakesController() bool              { return false }
+func (*ChartCommand) AdditionalArgs() (min int, max int) { return 0, 100 }
+func (*ChartCommand) Help() string {
+	return "Opens the best matching chart (e.g., \"chart KJFK ILS 4R\") in an image viewer. " +
+		"With no arguments, lists the charts for the selected aircraft's departure and arrival airports."
+}
+func (*ChartCommand) Run(cmd string, ac *Aircraft, ctrl *Controller, args []string, cli *CLIPane) []*ConsoleEntry {
+	var viewers []*ImageViewPane
+	positionConfig.DisplayRoot.VisitPanes(func(p Pane) {
+		if iv, ok := p.(*ImageViewPane); ok && iv.charts != nil {
+			viewers = append(viewers, iv)
+		}
+	})
+	if len(viewers) == 0 {
+		return ErrorStringConsoleEntry("chart: no image viewer panes are open")
+	}
+
+	if len(args) == 0 {
+		if ac.FlightPlan == nil {
+			return ErrorConsoleEntry(ErrNoFlightPlan)
+		}
+		var labels []string
+		for _, iv := range viewers {
+			for _, e := range iv.charts.SuggestedCharts(ac) {
+				labels = append(labels, e.Label())
+			}
+		}
+		if len(labels) == 0 {
+			return StringConsoleEntry("No charts found for " + ac.FlightPlan.DepartureAirport + " or " +
+				ac.FlightPlan.ArrivalAirport + ".")
+		}
+		return StringConsoleEntry(strings.Join(labels, "\n"))
+	}
+
+	query := strings.Join(args, " ")
+	for _, iv := range viewers {
+		if results := iv.charts.Search(query); len(results) > 0 {
+			iv.SelectImage(results[0].Filename)
+			msg := "Opened " + results[0].Label()
+			if len(results) > 1 {
+				msg += fmt.Sprintf(" (%d other matches)", len(results)-1)
+			}
+			return StringConsoleEntry(msg)
+		}
+	}
+	return ErrorStringConsoleEntry("chart: " + query + ": no matching charts")
+}
@@ -23,6 +23,16 @@ import (
 type GlobalConfig struct {
 	NotesFile   string
 	AliasesFile string
+	// OurAirports CSV and ARINC 424 files with worldwide airports,
+	// navaids, and fixes.
+	NavdataFiles []string
+	// OpenAir and AIXM files with special use airspace and TFRs.
+	SUAFiles []string
+	// FAA MVA AIXM or GeoJSON files with minimum vectoring altitudes.
+	MVAFiles []string
+	// NASR subscription zip file or directory; if empty, the FAA data
+	// built into the binary is used.
+	NASRPath string
 
 	PositionConfigs       map[string]*PositionConfig
 	ActivePosition        string
@@ -32,10 +42,16 @@ type GlobalConfig struct {
 	ImGuiSettings         string
 	AudioSettings         AudioSettings
 	UIFontSize            int
+	// Saved aircraft filter expressions, indexed by name.
+	AircraftFilters map[string]string
 
 	aliases map[string]string
 
 	notesRoot *NotesNode
+	// Modification time of the notes file when it was last loaded and
+	// when we last checked it for changes.
+	notesModTime   time.Time
+	notesLastCheck time.Time
 }
 
 type NotesNode struct {
@@ -49,6 +65,8 @@ type PositionConfig struct {
 	PositionFile    string
 	ColorSchemeName string
 	DisplayRoot     *DisplayNode
+	// User-defined datablock templates, indexed by name.
+	DatablockTemplates map[string]string
 
 	selectedAircraft *Aircraft
 	flaggedAircraft  []string
@@ -128,6 +146,82 @@ func (c *GlobalConfig) DrawFilesUI() {
 			c.LoadNotesFile()
 		}
 
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.Text("NASR data: ")
+		imgui.TableNextColumn()
+		imgui.Text(Select(c.NASRPath == "", "(built-in)", c.NASRPath))
+		imgui.TableNextColumn()
+		if imgui.Button("Zip...##nasr") {
+			ui.openNASRZipDialog.Activate()
+		}
+		imgui.SameLine()
+		if imgui.Button("Directory...##nasr") {
+			ui.openNASRDirectoryDialog.Activate()
+		}
+		imgui.TableNextColumn()
+		if c.NASRPath != "" && imgui.Button("Use built-in##nasr") {
+			// The built-in data will be used at the next startup.
+			c.NASRPath = ""
+		}
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.TableNextColumn()
+		cycle, expired := database.FAACycleDescription()
+		if expired {
+			imgui.PushStyleColor(imgui.StyleColorText, positionConfig.GetColorScheme().Error.imgui())
+			imgui.Text(cycle)
+			imgui.PopStyleColor()
+		} else {
+			imgui.Text(cycle)
+		}
+
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.Text("Navdata files: ")
+		imgui.TableNextColumn()
+		imgui.Text(strings.Join(c.NavdataFiles, "\n"))
+		imgui.TableNextColumn()
+		if imgui.Button("Add...##navdatafile") {
+			ui.openNavdataFileDialog.Activate()
+		}
+		imgui.TableNextColumn()
+		if len(c.NavdataFiles) > 0 && imgui.Button("Clear##navdatafile") {
+			// The navdata has already been merged into the database, so
+			// it will still be present until vice is restarted.
+			c.NavdataFiles = nil
+		}
+
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.Text("SUA files: ")
+		imgui.TableNextColumn()
+		imgui.Text(strings.Join(c.SUAFiles, "\n"))
+		imgui.TableNextColumn()
+		if imgui.Button("Add...##suafile") {
+			ui.openSUAFileDialog.Activate()
+		}
+		imgui.TableNextColumn()
+		if len(c.SUAFiles) > 0 && imgui.Button("Clear##suafile") {
+			c.SUAFiles = nil
+			database.LoadSUAFiles(nil)
+		}
+
+		imgui.TableNextRow()
+		imgui.TableNextColumn()
+		imgui.Text("MVA files: ")
+		imgui.TableNextColumn()
+		imgui.Text(strings.Join(c.MVAFiles, "\n"))
+		imgui.TableNextColumn()
+		if imgui.Button("Add...##mvafile") {
+			ui.openMVAFileDialog.Activate()
+		}
+		imgui.TableNextColumn()
+		if len(c.MVAFiles) > 0 && imgui.Button("Clear##mvafile") {
+			c.MVAFiles = nil
+			database.LoadMVAFiles(nil)
+		}
+
 		imgui.EndTable()
 	}
 }
@@ -185,7 +279,30 @@ func (gc *GlobalConfig) LoadNotesFile() {
 		ShowErrorDialog("Unable to read notes file: %v.", err)
 	} else {
 		gc.notesRoot = parseNotes(string(notes))
+		if info, err := os.Stat(gc.NotesFile); err == nil {
+			gc.notesModTime = info.ModTime()
+		}
+	}
+}
+
+// ReloadNotesIfChanged checks (at most once a second) whether the notes
+// file has been modified since it was loaded and reloads it if so. It
+// returns true if the notes were reloaded.
+func (gc *GlobalConfig) ReloadNotesIfChanged() bool {
+	if gc.NotesFile == "" || time.Since(gc.notesLastCheck) < time.Second {
+		return false
+	}
+	gc.notesLastCheck = time.Now()
+
+	info, err := os.Stat(gc.NotesFile)
+	if err != nil || info.ModTime().Equal(gc.notesModTime) {
+		return false
 	}
+	// Record the new time up front so that we don't keep trying (and
+	// reporting errors) if the file can't be read.
+	gc.notesModTime = info.ModTime()
+	gc.LoadNotesFile()
+	return true
 }
 
 func configFilePath() string {
@@ -320,6 +437,7 @@ func (c *PositionConfig) Duplicate() *PositionConfig {
 	nc := &PositionConfig{}
 	*nc = *c
 	nc.DisplayRoot = c.DisplayRoot.Duplicate()
+	nc.DatablockTemplates = DuplicateMap(c.DatablockTemplates)
 
 	nc.eventsId = InvalidEventSubscriberId
 
@@ -125,6 +125,10 @@ type ATCServer interface {
 	// to specify the airport ahead of time.
 	GetAirportATIS(airport string) []ATIS
 
+	// GetControllerATIS returns the controller ATIS text for the specified
+	// controller, or an empty string if there is none.
+	GetControllerATIS(controller string) string
+
 	GetUser(callsign string) *User
 	GetController(callsign string) *Controller
 	GetAllControllers() []*Controller
@@ -296,6 +300,10 @@ func (d *DisconnectedATCServer) GetAirportATIS(airport string) []ATIS {
 	return nil
 }
 
+func (d *DisconnectedATCServer) GetControllerATIS(controller string) string {
+	return ""
+}
+
 func (d *DisconnectedATCServer) GetUser(callsign string) *User {
 	return nil
 }
@@ -13,8 +13,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime/debug"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +33,16 @@ type StaticDatabase struct {
 		navaids map[string]Navaid
 		fixes   map[string]Fix
 		prd     map[AirportPair][]PRDEntry
+		airways map[string][]string // airway -> fixes along it
+		// Where the data came from: either the built-in databases or a
+		// NASR subscription loaded at runtime (see nasr.go).
+		source    string
+		effective time.Time
+	}
+	// From user-supplied worldwide databases; see navdata.go.
+	world struct {
+		navaids map[string][]Navaid
+		fixes   map[string][]Fix
 	}
 	airports            map[string]Airport
 	callsigns           map[string]Callsign
@@ -40,8 +50,10 @@ type StaticDatabase struct {
 	AircraftTypeAliases map[string]string
 
 	// From the sector file
-	NmPerLatitude     float32
-	NmPerLongitude    float32
+	NmPerLatitude  float32
+	NmPerLongitude float32
+	// Only used if the World Magnetic Model isn't available; see
+	// MagneticCorrection().
 	MagneticVariation float32
 
 	defaultAirport string
@@ -76,6 +88,20 @@ type StaticDatabase struct {
 	labelColorBufferIndex             ColorBufferIndex
 	labels                            []Label
 
+	// From the EuroScope .ese file that accompanies the sector file, if
+	// there is one.
+	freeText   []ESEFreeText
+	eseSectors []ESESector
+
+	// Special use airspace and TFRs; see sua.go.
+	sua []SUA
+	// Minimum vectoring altitudes, for MSAW; see msaw.go.
+	mva []MVASector
+
+	// Spatial index of fixes, navaids, and airports, built on demand; see
+	// search.go.
+	objectIndex *locatedObjectIndex
+
 	// From the position file
 	positions             map[string][]Position // map key is e.g. JFK_TWR
 	positionFileLoadError error
@@ -97,6 +123,12 @@ type StaticDrawable struct {
 	// Bounding box in latitude-longitude coordinates
 	bounds           Extent2D
 	colorBufferIndex ColorBufferIndex
+	// The geometry that is drawn, for spatial queries; see
+	// sectorgeometry.go.
+	geometry StaticGeometry
+	// For SIDs and STARs from an .ese file, the airport they are for;
+	// they are grouped by airport in the UI.
+	airport string
 }
 
 // ColorBufferIndex provides an efficient encoding of named sections of an
@@ -159,11 +191,17 @@ func InitializeStaticDatabase(dbChan chan *StaticDatabase) {
 	db := &StaticDatabase{}
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go func() { db.FAA.navaids = parseNavaids(); wg.Done() }()
+	go func() {
+		navs := decompressZstd(navBaseRaw)
+		db.FAA.navaids = parseNavaids(navs)
+		db.FAA.effective = nasrEffectiveDate(navs)
+		db.FAA.source = "built-in"
+		wg.Done()
+	}()
 	wg.Add(1)
 	go func() { db.airports = parseAirports(); wg.Done() }()
 	wg.Add(1)
-	go func() { db.FAA.fixes = parseFixes(); wg.Done() }()
+	go func() { db.FAA.fixes = parseFixes(decompressZstd(fixesRaw)); wg.Done() }()
 	wg.Add(1)
 	go func() { db.FAA.prd = parsePRD(); wg.Done() }()
 	wg.Add(1)
@@ -174,6 +212,19 @@ func InitializeStaticDatabase(dbChan chan *StaticDatabase) {
 
 	lg.Printf("Parsed built-in databases in %v", time.Since(start))
 
+	// Replace the built-in FAA data with a more recent NASR cycle if the
+	// user has provided one.
+	if globalConfig.NASRPath != "" {
+		if err := db.LoadNASR(globalConfig.NASRPath); err != nil {
+			lg.Errorf("%s: %v", globalConfig.NASRPath, err)
+			uiAddError("Unable to load NASR data; using built-in FAA data. See Settings/Files...",
+				func() bool { return db.FAA.source != "built-in" || globalConfig.NASRPath == "" })
+		}
+	}
+	db.LoadNavdataFiles(globalConfig.NavdataFiles)
+	db.LoadSUAFiles(globalConfig.SUAFiles)
+	db.LoadMVAFiles(globalConfig.MVAFiles)
+
 	// These errors will appear the first time vice is launched and the
 	// user hasn't yet set these up.  (And also if the chosen files are
 	// moved or deleted, etc...)
@@ -257,11 +308,16 @@ func mungeCSV(filename string, raw string, fields []string, callback func([]stri
 	}
 }
 
-func parseNavaids() map[string]Navaid {
+// The columns of the NAV_BASE and FIX_BASE tables that are used.
+var (
+	navaidFields = []string{"NAV_ID", "NAV_TYPE", "NAME", "LONG_DECIMAL", "LAT_DECIMAL"}
+	fixFields    = []string{"FIX_ID", "LONG_DECIMAL", "LAT_DECIMAL"}
+)
+
+func parseNavaids(raw string) map[string]Navaid {
 	navaids := make(map[string]Navaid)
 
-	mungeCSV("navaids", decompressZstd(navBaseRaw),
-		[]string{"NAV_ID", "NAV_TYPE", "NAME", "LONG_DECIMAL", "LAT_DECIMAL"},
+	mungeCSV("navaids", raw, navaidFields,
 		func(s []string) {
 			n := Navaid{
 				Id:       s[0],
@@ -298,11 +354,10 @@ func parseAirports() map[string]Airport {
 	return airports
 }
 
-func parseFixes() map[string]Fix {
+func parseFixes(raw string) map[string]Fix {
 	fixes := make(map[string]Fix)
 
-	mungeCSV("fixes", decompressZstd(fixesRaw),
-		[]string{"FIX_ID", "LONG_DECIMAL", "LAT_DECIMAL"},
+	mungeCSV("fixes", raw, fixFields,
 		func(s []string) {
 			f := Fix{
 				Id:       s[0],
@@ -441,6 +496,9 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 	db.highAirwayCommandBuffer = CommandBuffer{}
 	db.labelColorBufferIndex = ColorBufferIndex{}
 	db.labels = nil
+	db.freeText = nil
+	db.eseSectors = nil
+	db.objectIndex = nil
 
 	// First initialize various databases from the sector file. Start with
 	// named locations--VORs, airports, etc.
@@ -475,21 +533,28 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 		}
 	}
 
+	// EuroScope sector files come with an .ese file that has additional
+	// information; load it if it's present.
+	eseSIDs, eseSTARs := db.loadESE(filename)
+
 	// Now initialize assorted StaticDrawables for things in the sector
 	// file.
 	setupARTCC := func(sfARTCC []sct2.ARTCC) []StaticDrawable {
 		var artccs []StaticDrawable
 		for _, artcc := range sfARTCC {
 			ld := LinesDrawBuilder{}
+			var segs [][2]Point2LL
 			for _, seg := range artcc.Segs {
 				if seg.P[0].Latitude != 0 || seg.P[0].Longitude != 0 {
 					v0 := Point2LLFromSct2(seg.P[0])
 					v1 := Point2LLFromSct2(seg.P[1])
 					ld.AddLine(v0, v1)
+					segs = append(segs, [2]Point2LL{v0, v1})
 				}
 			}
 
-			sd := StaticDrawable{name: artcc.Name, bounds: ld.Bounds()}
+			sd := StaticDrawable{name: artcc.Name, bounds: ld.Bounds(),
+				geometry: NewStaticGeometry(segs, polygonsFromSegments(segs))}
 			ld.GenerateCommands(&sd.cb)
 			artccs = append(artccs, sd)
 		}
@@ -513,6 +578,7 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 	// seem to be organized.)
 	currentRegionName := "___UNSET___"
 	td := TrianglesDrawBuilder{}
+	var regionPolygons [][]Point2LL
 	for i, r := range sectorFile.Regions {
 		if len(r.P) == 0 {
 			lg.Printf("zero vertices in region \"%s\"?", r.Name)
@@ -523,20 +589,25 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 			// We've come across a new region name; flush out any accumulated triangles
 			// from the previous region
 			if len(td.indices) > 0 {
-				region := StaticDrawable{name: currentRegionName, bounds: td.Bounds()}
+				region := StaticDrawable{name: currentRegionName, bounds: td.Bounds(),
+					geometry: NewStaticGeometry(nil, regionPolygons)}
 				td.GenerateCommands(&region.cb)
 				db.regions = append(db.regions, region)
 				td.Reset()
 			}
+			regionPolygons = nil
 			currentRegionName = r.Name
 		}
 
 		// Triangulate
 		var poly earcut.Polygon
+		var rp []Point2LL
 		for _, p := range r.P {
 			v := earcut.Vertex{P: [2]float64{p.Longitude, p.Latitude}}
 			poly.Vertices = append(poly.Vertices, v)
+			rp = append(rp, Point2LLFromSct2(p))
 		}
+		regionPolygons = append(regionPolygons, rp)
 		tris := earcut.Triangulate(poly)
 
 		for _, tri := range tris {
@@ -549,7 +620,8 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 		// And when we're at the last region, also flush out its
 		// StaticDrawable.
 		if i+1 == len(sectorFile.Regions) && len(td.indices) > 0 {
-			region := StaticDrawable{name: r.Name, bounds: td.Bounds()}
+			region := StaticDrawable{name: r.Name, bounds: td.Bounds(),
+				geometry: NewStaticGeometry(nil, regionPolygons)}
 			td.GenerateCommands(&region.cb)
 			db.regions = append(db.regions, region)
 		}
@@ -584,6 +656,7 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 		ld := GetColoredLinesDrawBuilder()
 		defer ReturnColoredLinesDrawBuilder(ld)
 		colorBufferIndex := NewColorBufferIndex()
+		var segs [][2]Point2LL
 
 		for _, seg := range cs {
 			// Ignore (0,0) positions, which are sometimes left in sector
@@ -595,7 +668,9 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 				} else {
 					colorBufferIndex.Add(defaultColorName)
 				}
-				ld.AddLine(Point2LLFromSct2(seg.P[0]), Point2LLFromSct2(seg.P[1]), RGB{})
+				p0, p1 := Point2LLFromSct2(seg.P[0]), Point2LLFromSct2(seg.P[1])
+				ld.AddLine(p0, p1, RGB{})
+				segs = append(segs, [2]Point2LL{p0, p1})
 			}
 		}
 		cb := CommandBuffer{}
@@ -606,7 +681,8 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 			cb:               cb,
 			rgbSlice:         cb.FloatSlice(start, len),
 			bounds:           ld.Bounds(),
-			colorBufferIndex: colorBufferIndex}
+			colorBufferIndex: colorBufferIndex,
+			geometry:         NewStaticGeometry(segs, nil)}
 	}
 	// We'll also make a StaticDrawable for such things that doesn't
 	// include any color settings, for the use of scopes that prefer to set
@@ -633,10 +709,20 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 		db.SIDs = append(db.SIDs, staticColoredLines(sid.Name, sid.Segs, "SID"))
 		db.SIDsNoColor = append(db.SIDsNoColor, staticLines(sid.Name, sid.Segs))
 	}
+	for _, sid := range eseSIDs {
+		d, dnc := staticColoredLines(sid.Name, sid.Segs, "SID"), staticLines(sid.Name, sid.Segs)
+		d.airport, dnc.airport = sid.airport, sid.airport
+		db.SIDs, db.SIDsNoColor = append(db.SIDs, d), append(db.SIDsNoColor, dnc)
+	}
 	for _, star := range sectorFile.STARs {
 		db.STARs = append(db.STARs, staticColoredLines(star.Name, star.Segs, "STAR"))
 		db.STARsNoColor = append(db.STARsNoColor, staticLines(star.Name, star.Segs))
 	}
+	for _, star := range eseSTARs {
+		d, dnc := staticColoredLines(star.Name, star.Segs, "STAR"), staticLines(star.Name, star.Segs)
+		d.airport, dnc.airport = star.airport, star.airport
+		db.STARs, db.STARsNoColor = append(db.STARs, d), append(db.STARsNoColor, dnc)
+	}
 	for _, geo := range sectorFile.Geo {
 		db.geos = append(db.geos, staticColoredLines(geo.Name, geo.Segments, "Geo"))
 		db.geosNoColor = append(db.geosNoColor, staticLines(geo.Name, geo.Segments))
@@ -666,11 +752,103 @@ func (db *StaticDatabase) LoadSectorFile(filename string) error {
 	return nil
 }
 
+// eseSidStar is a SID or STAR from an .ese file and the airport it is
+// for.
+type eseSidStar struct {
+	sct2.SidStar
+	airport string
+}
+
+// loadESE loads the EuroScope .ese file with the same base name as the
+// given sector file, if there is one, and returns its SIDs and STARs.
+func (db *StaticDatabase) loadESE(sectorFilename string) (sids, stars []eseSidStar) {
+	base := strings.TrimSuffix(sectorFilename, filepath.Ext(sectorFilename))
+	var contents []byte
+	var err error
+	for _, ext := range []string{".ese", ".ESE"} {
+		if contents, err = os.ReadFile(base + ext); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	lg.Printf("%s: loading ESE file", base)
+	ese := ParseESE(contents, db.Locate, func(err string) {
+		lg.Errorf("%s.ese: %s", base, err)
+	})
+	db.freeText = ese.FreeText
+	db.eseSectors = ese.Sectors
+
+	// Convert the SIDs and STARs to lines. SIDs start
+	// from the end of the departure runway and STARs go to the threshold
+	// of the arrival runway, when they're known.
+	runwayPoint := func(airport, number string, threshold bool) (Point2LL, bool) {
+		for _, rwy := range db.runways[airport] {
+			if rwy.Number == number {
+				return Select(threshold, rwy.Threshold, rwy.End), true
+			}
+		}
+		return Point2LL{}, false
+	}
+	for _, proc := range ese.Procedures {
+		var pts []Point2LL
+		for _, fix := range proc.Fixes {
+			if p, ok := db.Locate(fix); ok {
+				pts = append(pts, p)
+			}
+		}
+		if p, ok := runwayPoint(proc.Airport, proc.Runway, !proc.SID); ok {
+			if proc.SID {
+				pts = append([]Point2LL{p}, pts...)
+			} else {
+				pts = append(pts, p)
+			}
+		}
+
+		ss := sct2.SidStar{Name: proc.Name + " (" + proc.Runway + ")"}
+		for i := 1; i < len(pts); i++ {
+			seg := sct2.Segment{P: [2]sct2.LatLong{
+				{Latitude: float64(pts[i-1][1]), Longitude: float64(pts[i-1][0])},
+				{Latitude: float64(pts[i][1]), Longitude: float64(pts[i][0])}}}
+			ss.Segs = append(ss.Segs, sct2.ColoredSegment{Segment: seg})
+		}
+
+		if proc.SID {
+			sids = append(sids, eseSidStar{SidStar: ss, airport: proc.Airport})
+		} else {
+			stars = append(stars, eseSidStar{SidStar: ss, airport: proc.Airport})
+		}
+	}
+	return
+}
+
+// normalizeEuroScopeSectorFile rewrites the parts of EuroScope-flavored
+// sector files that sct2 doesn't handle: a leading byte order mark,
+// #defines after the start of the first section, and the REGIONNAME
+// lines that precede regions. VRC sector files are unchanged.
+func normalizeEuroScopeSectorFile(contents []byte) []byte {
+	contents = bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf"))
+
+	var defines, rest [][]byte
+	for _, line := range bytes.SplitAfter(contents, []byte("\n")) {
+		t := bytes.TrimSpace(line)
+		if bytes.HasPrefix(t, []byte("#define")) {
+			defines = append(defines, append(bytes.TrimRight(line, "\r\n"), '\n'))
+		} else if !bytes.HasPrefix(t, []byte("REGIONNAME")) {
+			rest = append(rest, line)
+		}
+	}
+	return bytes.Join(append(defines, rest...), nil)
+}
+
 func parseSectorFile(sectorFilename string) (*sct2.SectorFile, error) {
 	contents, err := os.ReadFile(sectorFilename)
 	if err != nil {
 		return nil, err
 	}
+	contents = normalizeEuroScopeSectorFile(contents)
 
 	type SctResult struct {
 		sf  *sct2.SectorFile
@@ -785,14 +963,28 @@ func getAirwayCommandBuffers(airways []sct2.Airway) (CommandBuffer, []Label) {
 func (db *StaticDatabase) LoadPositionFile(filename string) error {
 	lg.Printf("%s: loading position file", filename)
 
-	db.positions, db.positionFileLoadError = parsePositionFile(filename)
+	db.positions, db.positionFileLoadError = parsePositionFile(filename, db.Locate)
 
 	lg.Printf("%s: finished loading position file", filename)
 
 	return db.positionFileLoadError
 }
 
-func parsePositionFile(filename string) (map[string][]Position, error) {
+// parsePositionFile parses either a VRC position file or the
+// [POSITIONS] section of a EuroScope .ese file; locate is used to find the
+// locations of fixes that .ese files refer to.
+func parsePositionFile(filename string, locate func(string) (Point2LL, bool)) (map[string][]Position, error) {
+	if strings.ToLower(filepath.Ext(filename)) == ".ese" {
+		contents, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		ese := ParseESE(contents, locate, func(err string) {
+			lg.Printf("%s: %s", filename, err)
+		})
+		return ese.Positions, nil
+	}
+
 	m := make(map[string][]Position)
 
 	f, err := os.Open(filename)
@@ -814,38 +1006,12 @@ func parsePositionFile(filename string) (map[string][]Position, error) {
 			continue
 		}
 
-		var frequency float64
-		frequency, err := strconv.ParseFloat(fields[2], 32)
+		p, err := parsePositionFields(fields)
 		if err != nil {
-			lg.Printf("%s: error parsing frequency: [%+v]", err, fields)
+			lg.Printf("%s: error parsing position: %v [%+v]", filename, err, fields)
 			continue
 		}
-		// Note: parse as octal!
-		var lowSquawk, highSquawk int64
-		lowSquawk, err = strconv.ParseInt(fields[9], 8, 32)
-		if err != nil {
-			// This happens for e.g. entries for neighboring ARTCCs
-			lowSquawk = -1
-		}
-		highSquawk, err = strconv.ParseInt(fields[10], 8, 32)
-		if err != nil {
-			// This happens for e.g. entries for neighboring ARTCCs
-			highSquawk = -1
-		}
-
-		id := fields[5] + "_" + fields[6]
-		p := Position{
-			Name:      fields[0],
-			Callsign:  fields[1],
-			Frequency: NewFrequency(float32(frequency)),
-			SectorId:  fields[3],
-			Scope:     fields[4],
-			Id:        id,
-			// ignore fields 7/8
-			LowSquawk:  Squawk(lowSquawk),
-			HighSquawk: Squawk(highSquawk)}
-
-		m[id] = append(m[id], p)
+		m[p.Id] = append(m[p.Id], p)
 	}
 	return m, nil
 }
@@ -854,7 +1020,16 @@ func parsePositionFile(filename string) (map[string][]Position, error) {
 // Utility methods
 
 // Locate returns the location of a (static) named thing, if we've heard of it.
+// If there are multiple navaids or fixes with the name, the one closest to
+// the sector file's center is returned.
 func (db *StaticDatabase) Locate(name string) (Point2LL, bool) {
+	return db.LocateNear(name, db.defaultCenter)
+}
+
+// LocateNear is like Locate, but it resolves ambiguous names using the one
+// closest to the given point. (It is not used for sector file items,
+// which are assumed to be local.)
+func (db *StaticDatabase) LocateNear(name string, p Point2LL) (Point2LL, bool) {
 	name = strings.ToUpper(name)
 	// We'll start with the sector file and then move on to the FAA
 	// database if we don't find it.
@@ -866,13 +1041,34 @@ func (db *StaticDatabase) Locate(name string) (Point2LL, bool) {
 		return pos, ok
 	} else if pos, ok := db.airports[name]; ok {
 		return pos.Location, ok
-	} else if n, ok := db.FAA.navaids[name]; ok {
-		return n.Location, ok
-	} else if f, ok := db.FAA.fixes[name]; ok {
-		return f.Location, ok
-	} else {
+	}
+
+	var candidates []Point2LL
+	if n, ok := db.FAA.navaids[name]; ok {
+		candidates = append(candidates, n.Location)
+	}
+	if f, ok := db.FAA.fixes[name]; ok {
+		candidates = append(candidates, f.Location)
+	}
+	for _, n := range db.world.navaids[name] {
+		candidates = append(candidates, n.Location)
+	}
+	for _, f := range db.world.fixes[name] {
+		candidates = append(candidates, f.Location)
+	}
+	if len(candidates) == 0 {
 		return Point2LL{}, false
 	}
+
+	closest := candidates[0]
+	if !p.IsZero() {
+		for _, c := range candidates[1:] {
+			if nmdistance2ll(p, c) < nmdistance2ll(p, closest) {
+				closest = c
+			}
+		}
+	}
+	return closest, true
 }
 
 func (db *StaticDatabase) LookupPosition(callsign string, frequency Frequency) *Position {
@@ -1012,10 +1208,17 @@ type StaticDrawConfig struct {
 	ARTCCLowDrawSet  map[string]interface{}
 	ARTCCHighDrawSet map[string]interface{}
 
+	// Groups of free text labels from the EuroScope .ese file to draw.
+	FreeTextDrawSet map[string]interface{}
+
+	// User-supplied GeoJSON and KML layers
+	Overlays []*MapOverlay
+
 	// Various persistent state used in the ui but not maintained across
 	// sessions.
 	vorsComboState, ndbsComboState      *ComboBoxState
 	fixesComboState, airportsComboState *ComboBoxState
+	overlayFileDialog                   *FileSelectDialogBox
 }
 
 func NewStaticDrawConfig() *StaticDrawConfig {
@@ -1034,6 +1237,7 @@ func NewStaticDrawConfig() *StaticDrawConfig {
 	s.ARTCCDrawSet = make(map[string]interface{})
 	s.ARTCCLowDrawSet = make(map[string]interface{})
 	s.ARTCCHighDrawSet = make(map[string]interface{})
+	s.FreeTextDrawSet = make(map[string]interface{})
 
 	s.vorsComboState = NewComboBoxState(1)
 	s.ndbsComboState = NewComboBoxState(1)
@@ -1059,11 +1263,14 @@ func (s *StaticDrawConfig) Duplicate() *StaticDrawConfig {
 	dupe.ARTCCDrawSet = DuplicateMap(s.ARTCCDrawSet)
 	dupe.ARTCCLowDrawSet = DuplicateMap(s.ARTCCLowDrawSet)
 	dupe.ARTCCHighDrawSet = DuplicateMap(s.ARTCCHighDrawSet)
+	dupe.FreeTextDrawSet = DuplicateMap(s.FreeTextDrawSet)
+	dupe.Overlays = MapSlice(s.Overlays, func(o *MapOverlay) *MapOverlay { return o.Duplicate() })
 
 	dupe.vorsComboState = NewComboBoxState(1)
 	dupe.ndbsComboState = NewComboBoxState(1)
 	dupe.fixesComboState = NewComboBoxState(1)
 	dupe.airportsComboState = NewComboBoxState(1)
+	dupe.overlayFileDialog = nil
 
 	return dupe
 }
@@ -1087,6 +1294,9 @@ func (s *StaticDrawConfig) Activate() {
 	if s.AirportsToDraw == nil {
 		s.AirportsToDraw = make(map[string]interface{})
 	}
+	if s.FreeTextDrawSet == nil {
+		s.FreeTextDrawSet = make(map[string]interface{})
+	}
 	if s.vorsComboState == nil {
 		s.vorsComboState = NewComboBoxState(1)
 	}
@@ -1099,6 +1309,9 @@ func (s *StaticDrawConfig) Activate() {
 	if s.airportsComboState == nil {
 		s.airportsComboState = NewComboBoxState(1)
 	}
+	for _, o := range s.Overlays {
+		o.Activate()
+	}
 }
 
 func (s *StaticDrawConfig) Deactivate() {
@@ -1251,12 +1464,17 @@ func (s *StaticDrawConfig) DrawUI() {
 	// SIDs and STARS are presented hierarchically, where names that start
 	// with "===" are interpreted as separators that in turn are rendered
 	// using tree nodes so that the user can expand them individually.
+	// Those from .ese files are similarly grouped by airport.
 	sidStarHierarchy := func(title string, sidstar []StaticDrawable, drawSet map[string]interface{}) {
 		if imgui.TreeNode(title) {
 			depth := 1
 			active := true
+			airport := ""
 			for _, ss := range sidstar {
-				if strings.HasPrefix(ss.name, "===") {
+				separator := strings.HasPrefix(ss.name, "===")
+				newAirport := ss.airport != "" && ss.airport != airport
+				airport = ss.airport
+				if separator || newAirport {
 					if active && depth > 1 {
 						// We've gone into a subtree for another item, so
 						// end that one before we start one for the next
@@ -1267,6 +1485,9 @@ func (s *StaticDrawConfig) DrawUI() {
 					// Chop off the equals signs for the UI
 					n := strings.TrimLeft(ss.name, "= ")
 					n = strings.TrimRight(n, "= ")
+					if !separator {
+						n = ss.airport
+					}
 
 					// And start a new subtree; increment the current depth
 					// if the user has expanded it.
@@ -1274,7 +1495,8 @@ func (s *StaticDrawConfig) DrawUI() {
 					if active {
 						depth++
 					}
-				} else if active {
+				}
+				if !separator && active {
 					// It's a regular entry; draw the checkbox for it.
 					_, draw := drawSet[ss.name]
 					imgui.Checkbox(ss.name, &draw)
@@ -1314,6 +1536,48 @@ func (s *StaticDrawConfig) DrawUI() {
 	artccCheckboxes("ARTCC Low", database.ARTCCLow, s.ARTCCLowDrawSet)
 	artccCheckboxes("ARTCC High", database.ARTCCHigh, s.ARTCCHighDrawSet)
 
+	if len(database.freeText) > 0 && imgui.TreeNode("Free text") {
+		groups := make(map[string]interface{})
+		for _, ft := range database.freeText {
+			groups[ft.Group] = nil
+		}
+		for _, g := range SortedMapKeys(groups) {
+			_, draw := s.FreeTextDrawSet[g]
+			imgui.Checkbox(g, &draw)
+			if draw {
+				s.FreeTextDrawSet[g] = nil
+			} else {
+				delete(s.FreeTextDrawSet, g)
+			}
+		}
+		imgui.TreePop()
+	}
+
+	if imgui.TreeNode("Overlays") {
+		remove := -1
+		for i, o := range s.Overlays {
+			if o.DrawUI() {
+				remove = i
+			}
+		}
+		if remove != -1 {
+			s.Overlays = DeleteSliceElement(s.Overlays, remove)
+		}
+
+		if imgui.Button("Add GeoJSON/KML overlay...") {
+			if s.overlayFileDialog == nil {
+				s.overlayFileDialog = NewFileSelectDialogBox("Select overlay file...",
+					[]string{".geojson", ".json", ".kml"}, "",
+					func(fn string) { s.Overlays = append(s.Overlays, NewMapOverlay(fn)) })
+			}
+			s.overlayFileDialog.Activate()
+		}
+		if s.overlayFileDialog != nil {
+			s.overlayFileDialog.Draw()
+		}
+		imgui.TreePop()
+	}
+
 	imgui.PopID()
 }
 
@@ -1436,6 +1700,11 @@ func (s *StaticDrawConfig) Draw(ctx *PaneContext, labelFont *Font, color *RGB,
 		}
 	}
 
+	// User-supplied overlays
+	for _, o := range s.Overlays {
+		o.drawGeometry(color, viewBounds, cb)
+	}
+
 	// Airways. For now just draw the lines, if requested. Labels will come
 	// shortly.
 	if s.DrawEverything || s.DrawLowAirways {
@@ -1516,12 +1785,16 @@ func (s *StaticDrawConfig) Draw(ctx *PaneContext, labelFont *Font, color *RGB,
 		}
 	}
 
-	ld.GenerateCommands(cb)
-	ld.Reset() // after GenerateCommands...
-
 	td := GetTextDrawBuilder()
 	defer ReturnTextDrawBuilder(td)
 
+	for _, o := range s.Overlays {
+		o.drawPoints(color, viewBounds, transforms, labelFont, ctx.cs.Background, ld, td)
+	}
+
+	ld.GenerateCommands(cb)
+	ld.Reset() // after GenerateCommands...
+
 	// Helper function to draw airway labels.
 	drawAirwayLabels := func(labels []Label, color RGB) {
 		for _, label := range labels {
@@ -1562,6 +1835,18 @@ func (s *StaticDrawConfig) Draw(ctx *PaneContext, labelFont *Font, color *RGB,
 		}
 	}
 
+	// And free text from the .ese file.
+	for _, ft := range database.freeText {
+		if _, draw := s.FreeTextDrawSet[ft.Group]; (draw || s.DrawEverything) && viewBounds.Inside(ft.P) {
+			style := TextStyle{
+				Font:            labelFont,
+				Color:           filterColor(ctx.cs.Text),
+				DropShadow:      true,
+				DropShadowColor: ctx.cs.Background}
+			td.AddTextCentered(ft.Text, transforms.WindowFromLatLongP(ft.P), style)
+		}
+	}
+
 	// Helper function for drawing text for VORs, NDBs, fixes, and
 	// airports. Takes a latlong point at which to draw the label as well
 	// as an enum that indicates to which side of the point the label
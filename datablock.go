@@ -0,0 +1,299 @@
+// datablock.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// Datablock templates are user-defined datablock formats. A template is
+// text with field tokens in braces, like "{callsign}" or "{alt}"; each
+// line of the template is a line of the datablock. Sections in square
+// brackets are optional: they may have multiple alternatives separated by
+// "|", and the first alternative where all of the fields are non-empty is
+// used. (If none qualify, the section is omitted.) Lines that end up
+// empty are dropped. A line consisting of "---" separates pages that are
+// alternately flashed.
+//
+// For example:
+//
+//	{callsign}{voice}
+//	{alt}{altarrow}[{code}|{scratchpad}|{tempalt}T]
+//	{type} {gs10}[{vfr}]
+//	---
+//	{callsign}{voice}
+//	{alt}{altarrow}[{dest}|????]
+//	{type} {gs10}[{vfr}]
+
+// datablockContext holds the per-aircraft information used when
+// expanding templates.
+type datablockContext struct {
+	ac              *Aircraft
+	duplicateSquawk bool
+}
+
+var datablockFields = map[string]func(c *datablockContext) string{
+	"callsign": func(c *datablockContext) string { return c.ac.Callsign },
+	"voice": func(c *datablockContext) string {
+		switch c.ac.VoiceCapability {
+		case VoiceReceive:
+			return "/r"
+		case VoiceText:
+			return "/t"
+		default:
+			return ""
+		}
+	},
+	"type":     datablockFlightPlanField(func(fp *FlightPlan) string { return fp.TypeWithoutSuffix() }),
+	"basetype": datablockFlightPlanField(func(fp *FlightPlan) string { return fp.BaseType() }),
+	"dep":      datablockFlightPlanField(func(fp *FlightPlan) string { return fp.DepartureAirport }),
+	"dest":     datablockFlightPlanField(func(fp *FlightPlan) string { return fp.ArrivalAirport }),
+	"cruise": datablockFlightPlanField(func(fp *FlightPlan) string {
+		if fp.Altitude == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%03d", fp.Altitude/100)
+	}),
+	"vfr": datablockFlightPlanField(func(fp *FlightPlan) string { return Select(fp.Rules == VFR, "V", "") }),
+	"alt": func(c *datablockContext) string { return fmt.Sprintf("%03d", (c.ac.Altitude()+50)/100) },
+	"altarrow": func(c *datablockContext) string {
+		ac := c.ac
+		dalt := ac.AltitudeChange()
+		if ac.TempAltitude != 0 && abs(ac.Altitude()-ac.TempAltitude) < 300 {
+			return "T"
+		} else if ac.FlightPlan != nil && ac.FlightPlan.Altitude != 0 &&
+			abs(ac.Altitude()-ac.FlightPlan.Altitude) < 300 {
+			return "C"
+		} else if dalt > 250 {
+			return FontAwesomeIconArrowUp
+		} else if dalt < -250 {
+			return FontAwesomeIconArrowDown
+		}
+		return ""
+	},
+	"tempalt": func(c *datablockContext) string {
+		if c.ac.TempAltitude == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%03d", c.ac.TempAltitude/100)
+	},
+	"gs":         func(c *datablockContext) string { return fmt.Sprintf("%d", c.ac.Groundspeed()) },
+	"gs10":       func(c *datablockContext) string { return fmt.Sprintf("%02d", (c.ac.Groundspeed()+5)/10) },
+	"scratchpad": func(c *datablockContext) string { return c.ac.Scratchpad },
+	"squawk":     func(c *datablockContext) string { return c.ac.Squawk.String() },
+	// code is non-empty only if there's a problem with the aircraft's squawk
+	"code": func(c *datablockContext) string {
+		ac := c.ac
+		if ac.Mode == Standby || ac.Squawk == 0 {
+			return ""
+		} else if c.duplicateSquawk && ac.Squawk != Squawk(0o1200) {
+			return "CODE"
+		} else if !c.duplicateSquawk && ac.Squawk != ac.AssignedSquawk {
+			return ac.Squawk.String()
+		}
+		return ""
+	},
+	"hours": func(c *datablockContext) string {
+		if h := c.ac.HoursOnNetwork(false); h < 100 && h != 0 {
+			return FontAwesomeIconBaby
+		} else if h > 500 {
+			return FontAwesomeIconGlobeAmericas
+		}
+		return ""
+	},
+	"ident": func(c *datablockContext) string { return Select(c.ac.Mode == Ident, "ID", "") },
+}
+
+func datablockFlightPlanField(f func(fp *FlightPlan) string) func(c *datablockContext) string {
+	return func(c *datablockContext) string {
+		if c.ac.FlightPlan == nil {
+			return ""
+		}
+		return f(c.ac.FlightPlan)
+	}
+}
+
+// expandDatablockTemplate expands a single line of a template. It returns
+// the expanded text and whether all of the fields in it were non-empty.
+// If c is nil, the template is only checked for errors and no fields are
+// evaluated.
+func expandDatablockTemplate(t string, c *datablockContext) (string, bool, error) {
+	var sb strings.Builder
+	allPresent := true
+
+	for len(t) > 0 {
+		switch t[0] {
+		case '{':
+			end := strings.IndexByte(t, '}')
+			if end == -1 {
+				return "", false, fmt.Errorf("missing }")
+			}
+			field, ok := datablockFields[strings.ToLower(t[1:end])]
+			if !ok {
+				return "", false, fmt.Errorf("%s: unknown field", t[1:end])
+			}
+			if c != nil {
+				v := field(c)
+				allPresent = allPresent && v != ""
+				sb.WriteString(v)
+			}
+			t = t[end+1:]
+
+		case '[':
+			// Find the matching ] and split the alternatives at the top
+			// level of the section.
+			depth, start := 0, 1
+			var alternatives []string
+			end := -1
+			for i := 1; i < len(t) && end == -1; i++ {
+				switch t[i] {
+				case '[':
+					depth++
+				case ']':
+					if depth == 0 {
+						alternatives = append(alternatives, t[start:i])
+						end = i
+					}
+					depth--
+				case '|':
+					if depth == 0 {
+						alternatives = append(alternatives, t[start:i])
+						start = i + 1
+					}
+				}
+			}
+			if end == -1 {
+				return "", false, fmt.Errorf("missing ]")
+			}
+
+			for _, alt := range alternatives {
+				s, ok, err := expandDatablockTemplate(alt, c)
+				if err != nil {
+					return "", false, err
+				}
+				if ok && c != nil {
+					sb.WriteString(s)
+					break
+				}
+			}
+			t = t[end+1:]
+
+		case '}', ']', '|':
+			return "", false, fmt.Errorf("unexpected %c", t[0])
+
+		default:
+			n := strings.IndexAny(t, "{}[]|")
+			if n == -1 {
+				n = len(t)
+			}
+			sb.WriteString(t[:n])
+			t = t[n:]
+		}
+	}
+	return sb.String(), allPresent, nil
+}
+
+// FormatDatablockTemplate returns the text of each of the template's
+// pages for the given aircraft.
+func FormatDatablockTemplate(template string, ac *Aircraft, duplicateSquawk bool) ([]string, error) {
+	c := &datablockContext{ac: ac, duplicateSquawk: duplicateSquawk}
+
+	var pages []string
+	var lines []string
+	for _, line := range strings.Split(template, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "---" {
+			pages = append(pages, strings.Join(lines, "\n"))
+			lines = nil
+			continue
+		}
+		s, _, err := expandDatablockTemplate(line, c)
+		if err != nil {
+			return nil, err
+		}
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return append(pages, strings.Join(lines, "\n")), nil
+}
+
+// CheckDatablockTemplate returns an error if the template has syntax
+// errors or unknown fields. It doesn't evaluate any of the fields, so it
+// is cheap enough to call every frame.
+func CheckDatablockTemplate(template string) error {
+	for _, line := range strings.Split(template, "\n") {
+		if _, _, err := expandDatablockTemplate(strings.TrimRight(line, " \r"), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Template UI
+
+// drawDatablockTemplateSelector draws a combo box for selecting one of the
+// position config's datablock templates as well as an editor for them. It
+// returns true if the selection or the selected template changed.
+func drawDatablockTemplateSelector(name *string) bool {
+	changed := false
+	templates := positionConfig.DatablockTemplates
+
+	if imgui.BeginCombo("Datablock template", *name) {
+		for _, n := range SortedMapKeys(templates) {
+			if imgui.SelectableV(n, n == *name, 0, imgui.Vec2{}) {
+				*name = n
+				changed = true
+			}
+		}
+		imgui.EndCombo()
+	}
+
+	if t, ok := templates[*name]; ok {
+		if imgui.InputTextMultilineV("##template", &t, imgui.Vec2{X: 400, Y: 120}, 0, nil) {
+			templates[*name] = t
+			changed = true
+		}
+		if err := CheckDatablockTemplate(t); err != nil {
+			imgui.Text("Error: " + err.Error())
+		}
+		if imgui.Button("Delete template") {
+			delete(templates, *name)
+			*name = ""
+			changed = true
+		}
+		imgui.SameLine()
+	}
+
+	if imgui.Button("New template...") {
+		ui.newTemplateName = ""
+		imgui.OpenPopup("New datablock template")
+	}
+	if imgui.BeginPopup("New datablock template") {
+		imgui.InputTextV("Name", &ui.newTemplateName, imgui.InputTextFlagsCharsNoBlank, nil)
+		_, exists := templates[ui.newTemplateName]
+		disable := ui.newTemplateName == "" || exists
+		uiStartDisable(disable)
+		if imgui.Button("Create") {
+			if positionConfig.DatablockTemplates == nil {
+				positionConfig.DatablockTemplates = make(map[string]string)
+			}
+			// Start with something reasonable.
+			positionConfig.DatablockTemplates[ui.newTemplateName] =
+				"{callsign}{voice}\n{alt}{altarrow} [{code}|{scratchpad}|{dest}]\n{type} {gs10}[{vfr}]"
+			*name = ui.newTemplateName
+			changed = true
+			imgui.CloseCurrentPopup()
+		}
+		uiEndDisable(disable)
+		imgui.EndPopup()
+	}
+
+	return changed
+}
@@ -0,0 +1,27 @@
+// datablock_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckDatablockTemplate(t *testing.T) {
+	for _, test := range []struct {
+		template string
+		ok       bool
+	}{
+		// {hours} would make a network request if it were evaluated.
+		{"{callsign}{voice}\n{alt}{altarrow}[{code}|{scratchpad}|{hours}]\n---\n{dest}", true},
+		{"{callsign}\n{nonesuch}", false},
+		{"{callsign", false},
+		{"[{code}|{bogus}]", false},
+		{"{alt}]", false},
+	} {
+		if err := CheckDatablockTemplate(test.template); (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, expected ok %v", test.template, err, test.ok)
+		}
+	}
+}
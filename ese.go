@@ -0,0 +1,319 @@
+// ese.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file handles EuroScope sector extension (.ese) files, which
+// accompany EuroScope sector files and describe controller positions,
+// sector ownership, SIDs and STARs, and free text labels.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ESEFile struct {
+	Positions   map[string][]Position // map key is e.g. EGLL_TWR
+	Procedures  []ESEProcedure
+	FreeText    []ESEFreeText
+	SectorLines map[string][]Point2LL
+	Sectors     []ESESector
+}
+
+// ESEProcedure is a SID or STAR from the [SIDSSTARS] section.
+type ESEProcedure struct {
+	SID     bool
+	Airport string
+	Runway  string
+	Name    string
+	Fixes   []string
+}
+
+type ESEFreeText struct {
+	Group string
+	Text  string
+	P     Point2LL
+}
+
+// ESESector is a sector from the [AIRSPACE] section.
+type ESESector struct {
+	Name           string
+	Floor, Ceiling int
+	// Position identifiers (Position.SectorId) of the controllers who
+	// own the sector, in priority order.
+	Owners []string
+	// Names of the sector lines that make up the sector's border.
+	Border []string
+	// The sector's boundary, assembled from the border lines; it is nil
+	// if they don't form a closed loop.
+	Boundary []Point2LL
+}
+
+// Owner returns the identifier of the highest-priority owner of the
+// sector that is online, or the empty string if none is.
+func (s *ESESector) Owner(online func(id string) bool) string {
+	for _, id := range s.Owners {
+		if online(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// parseESELatLong parses a latitude and longitude in EuroScope's format,
+// e.g. "N051.28.39.000" and "W000.27.41.000".
+func parseESELatLong(lat, long string) (Point2LL, error) {
+	return ParseLatLong(strings.TrimSpace(lat) + "," + strings.TrimSpace(long))
+}
+
+// parsePositionFields parses a position from the colon-separated fields
+// of a VRC position file line or the [POSITIONS] section of an ESE file;
+// the first 11 fields are the same in both.
+func parsePositionFields(fields []string) (Position, error) {
+	if len(fields) < 11 {
+		return Position{}, fmt.Errorf("expected at least 11 fields, got %d", len(fields))
+	}
+
+	frequency, err := strconv.ParseFloat(fields[2], 32)
+	if err != nil {
+		return Position{}, err
+	}
+	// Note: parse as octal!
+	lowSquawk, err := strconv.ParseInt(fields[9], 8, 32)
+	if err != nil {
+		// This happens for e.g. entries for neighboring ARTCCs
+		lowSquawk = -1
+	}
+	highSquawk, err := strconv.ParseInt(fields[10], 8, 32)
+	if err != nil {
+		highSquawk = -1
+	}
+
+	return Position{
+		Name:      fields[0],
+		Callsign:  fields[1],
+		Frequency: NewFrequency(float32(frequency)),
+		SectorId:  fields[3],
+		Scope:     fields[4],
+		Id:        fields[5] + "_" + fields[6],
+		// ignore fields 7/8
+		LowSquawk:  Squawk(lowSquawk),
+		HighSquawk: Squawk(highSquawk)}, nil
+}
+
+// ParseESE parses the contents of an ESE file. Errors in individual lines
+// are reported via the errorCallback and the line is skipped. locate is
+// used to find the locations of named fixes in CIRCLE_SECTORLINE
+// definitions.
+func ParseESE(contents []byte, locate func(string) (Point2LL, bool), errorCallback func(string)) *ESEFile {
+	ese := &ESEFile{
+		Positions:   make(map[string][]Position),
+		SectorLines: make(map[string][]Point2LL),
+	}
+
+	contents = bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf"))
+	section := ""
+	var sectorLine string
+	var sector *ESESector
+	for i, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || line[0] == ';' {
+			continue
+		}
+		lineError := func(f string, args ...interface{}) {
+			errorCallback(fmt.Sprintf("%d: %s: %s", i+1, fmt.Sprintf(f, args...), line))
+		}
+
+		if line[0] == '[' {
+			section = strings.ToUpper(strings.TrimSpace(line))
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		switch section {
+		case "[POSITIONS]":
+			if p, err := parsePositionFields(fields); err != nil {
+				lineError("%v", err)
+			} else {
+				ese.Positions[p.Id] = append(ese.Positions[p.Id], p)
+			}
+
+		case "[SIDSSTARS]":
+			if len(fields) < 5 || (fields[0] != "SID" && fields[0] != "STAR") {
+				lineError("malformed SID/STAR")
+				continue
+			}
+			ese.Procedures = append(ese.Procedures, ESEProcedure{
+				SID:     fields[0] == "SID",
+				Airport: fields[1],
+				Runway:  fields[2],
+				Name:    fields[3],
+				Fixes:   strings.Fields(fields[4]),
+			})
+
+		case "[FREETEXT]":
+			if len(fields) < 4 {
+				lineError("expected 4 fields")
+				continue
+			}
+			if p, err := parseESELatLong(fields[0], fields[1]); err != nil {
+				lineError("%v", err)
+			} else {
+				// The text may itself include colons.
+				ese.FreeText = append(ese.FreeText,
+					ESEFreeText{Group: fields[2], Text: strings.Join(fields[3:], ":"), P: p})
+			}
+
+		case "[AIRSPACE]":
+			switch fields[0] {
+			case "SECTORLINE":
+				if len(fields) < 2 {
+					lineError("missing sector line name")
+					continue
+				}
+				sectorLine = fields[1]
+				ese.SectorLines[sectorLine] = nil
+
+			case "CIRCLE_SECTORLINE":
+				// CIRCLE_SECTORLINE:name:lat:long:radius or
+				// CIRCLE_SECTORLINE:name:fix:radius
+				var center Point2LL
+				var radius float64
+				var err error
+				if len(fields) == 5 {
+					center, err = parseESELatLong(fields[2], fields[3])
+				} else if len(fields) == 4 {
+					var ok bool
+					if center, ok = locate(fields[2]); !ok {
+						err = fmt.Errorf("%s: unknown fix", fields[2])
+					}
+				} else {
+					err = fmt.Errorf("malformed circle sector line")
+				}
+				if err == nil {
+					radius, err = strconv.ParseFloat(fields[len(fields)-1], 32)
+				}
+				if err != nil {
+					lineError("%v", err)
+					continue
+				}
+				sectorLine = ""
+				ese.SectorLines[fields[1]] = circleLatLong(center, float32(radius), 64)
+
+			case "COORD":
+				if sectorLine == "" || len(fields) < 3 {
+					lineError("unexpected COORD")
+					continue
+				}
+				if p, err := parseESELatLong(fields[1], fields[2]); err != nil {
+					lineError("%v", err)
+				} else {
+					ese.SectorLines[sectorLine] = append(ese.SectorLines[sectorLine], p)
+				}
+
+			case "SECTOR":
+				if len(fields) < 4 {
+					lineError("expected 4 fields")
+					continue
+				}
+				floor, ferr := strconv.Atoi(fields[2])
+				ceiling, cerr := strconv.Atoi(fields[3])
+				if ferr != nil || cerr != nil {
+					lineError("invalid altitudes")
+					continue
+				}
+				ese.Sectors = append(ese.Sectors, ESESector{Name: fields[1], Floor: floor, Ceiling: ceiling})
+				sector = &ese.Sectors[len(ese.Sectors)-1]
+				sectorLine = ""
+
+			case "OWNER", "BORDER":
+				if sector == nil {
+					lineError("%s outside of a SECTOR", fields[0])
+				} else if fields[0] == "OWNER" {
+					sector.Owners = append(sector.Owners, fields[1:]...)
+				} else {
+					sector.Border = append(sector.Border, fields[1:]...)
+				}
+
+			default:
+				// DISPLAY, ALTOWNER, ACTIVE, DEPAPT, ARRAPT, GUEST, etc.,
+				// aren't used.
+			}
+		}
+	}
+
+	for i := range ese.Sectors {
+		s := &ese.Sectors[i]
+		var lines [][]Point2LL
+		for _, name := range s.Border {
+			if l, ok := ese.SectorLines[name]; ok {
+				lines = append(lines, l)
+			} else {
+				errorCallback(fmt.Sprintf("%s: unknown sector line in border of %s", name, s.Name))
+			}
+		}
+		s.Boundary = chainPolylines(lines)
+	}
+
+	return ese
+}
+
+// circleLatLong returns n points around a circle of the given radius in
+// nautical miles.
+func circleLatLong(center Point2LL, radius float32, n int) []Point2LL {
+	nmPerLongitude := 60 * cos(radians(center[1]))
+	var pts []Point2LL
+	for i := 0; i <= n; i++ {
+		theta := radians(360 * float32(i) / float32(n))
+		pts = append(pts, Point2LL{center[0] + radius*sin(theta)/nmPerLongitude,
+			center[1] + radius*cos(theta)/60})
+	}
+	return pts
+}
+
+// chainPolylines joins polylines that share endpoints, reversing them as
+// needed, into a single closed loop. It returns nil if they don't form
+// one.
+func chainPolylines(lines [][]Point2LL) []Point2LL {
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		return nil
+	}
+
+	const eps = 1e-5
+	near := func(a, b Point2LL) bool { return abs(a[0]-b[0]) < eps && abs(a[1]-b[1]) < eps }
+
+	loop := DuplicateSlice(lines[0])
+	used := make([]bool, len(lines))
+	used[0] = true
+	for n := 1; n < len(lines); n++ {
+		end := loop[len(loop)-1]
+		found := false
+		for i, l := range lines {
+			if used[i] || len(l) == 0 {
+				continue
+			}
+			if near(l[0], end) {
+				loop = append(loop, l[1:]...)
+			} else if near(l[len(l)-1], end) {
+				for j := len(l) - 2; j >= 0; j-- {
+					loop = append(loop, l[j])
+				}
+			} else {
+				continue
+			}
+			used[i], found = true, true
+			break
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	if !near(loop[0], loop[len(loop)-1]) {
+		return nil
+	}
+	return loop
+}
@@ -0,0 +1,118 @@
+// ese_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testESE = "\xef\xbb\xbf;comment\r\n" + `[POSITIONS]
+London Control:London Control:127.100:L:L:EGTT:CTR:-:-:0401:0467:N051.00.00.000:W000.30.00.000
+Heathrow Tower:Heathrow Tower:118.500:LL:T:EGLL:TWR:-:-:0001:0007
+bad line
+
+[SIDSSTARS]
+SID:EGLL:27R:BPK7F:BPK
+STAR:EGLL:27L:BNN1B:BNN OCK
+
+[FREETEXT]
+N051.28.39.000:W000.27.41.000:EGLL Labels:Stand 1: west
+
+[AIRSPACE]
+SECTORLINE:A
+COORD:N051.00.00.000:W001.00.00.000
+COORD:N051.00.00.000:E001.00.00.000
+SECTORLINE:B
+COORD:N052.00.00.000:E001.00.00.000
+COORD:N051.00.00.000:E001.00.00.000
+SECTORLINE:C
+COORD:N052.00.00.000:E001.00.00.000
+COORD:N051.00.00.000:W001.00.00.000
+SECTOR:LON:0:24500
+OWNER:L:LL
+BORDER:A:B:C
+`
+
+func TestParseESE(t *testing.T) {
+	var errs []string
+	ese := ParseESE([]byte(testESE), func(string) (Point2LL, bool) { return Point2LL{}, false },
+		func(e string) { errs = append(errs, e) })
+
+	if len(errs) != 1 || !strings.Contains(errs[0], "bad line") {
+		t.Errorf("expected a single error for \"bad line\", got %+v", errs)
+	}
+
+	if p, ok := ese.Positions["EGTT_CTR"]; !ok || len(p) != 1 {
+		t.Errorf("EGTT_CTR position missing: %+v", ese.Positions)
+	} else if p[0].SectorId != "L" || p[0].LowSquawk != 0401 || p[0].HighSquawk != 0467 ||
+		p[0].Frequency != NewFrequency(127.1) {
+		t.Errorf("EGTT_CTR: mismatch %+v", p[0])
+	}
+	if _, ok := ese.Positions["EGLL_TWR"]; !ok {
+		t.Errorf("EGLL_TWR position missing: %+v", ese.Positions)
+	}
+
+	if len(ese.Procedures) != 2 {
+		t.Errorf("expected 2 procedures, got %+v", ese.Procedures)
+	} else {
+		sid, star := ese.Procedures[0], ese.Procedures[1]
+		if !sid.SID || sid.Airport != "EGLL" || sid.Runway != "27R" || sid.Name != "BPK7F" ||
+			len(sid.Fixes) != 1 {
+			t.Errorf("SID mismatch: %+v", sid)
+		}
+		if star.SID || star.Name != "BNN1B" || len(star.Fixes) != 2 || star.Fixes[1] != "OCK" {
+			t.Errorf("STAR mismatch: %+v", star)
+		}
+	}
+
+	if len(ese.FreeText) != 1 {
+		t.Errorf("expected 1 free text, got %+v", ese.FreeText)
+	} else if ft := ese.FreeText[0]; ft.Group != "EGLL Labels" || ft.Text != "Stand 1: west" ||
+		abs(ft.P[1]-51.4775) > 1e-4 || abs(ft.P[0]+0.461389) > 1e-4 {
+		t.Errorf("free text mismatch: %+v", ft)
+	}
+
+	if len(ese.Sectors) != 1 {
+		t.Errorf("expected 1 sector, got %+v", ese.Sectors)
+	} else {
+		s := ese.Sectors[0]
+		if s.Name != "LON" || s.Floor != 0 || s.Ceiling != 24500 {
+			t.Errorf("sector mismatch: %+v", s)
+		}
+		if len(s.Boundary) != 4 {
+			t.Errorf("expected closed boundary with 4 points, got %+v", s.Boundary)
+		}
+		if o := s.Owner(func(id string) bool { return id == "LL" }); o != "LL" {
+			t.Errorf("expected owner LL, got %q", o)
+		}
+		if o := s.Owner(func(id string) bool { return true }); o != "L" {
+			t.Errorf("expected owner L, got %q", o)
+		}
+		if o := s.Owner(func(id string) bool { return false }); o != "" {
+			t.Errorf("expected no owner, got %q", o)
+		}
+	}
+}
+
+func TestChainPolylines(t *testing.T) {
+	a := []Point2LL{{0, 0}, {1, 0}}
+	b := []Point2LL{{1, 1}, {1, 0}}
+	c := []Point2LL{{1, 1}, {0, 0}}
+	if loop := chainPolylines([][]Point2LL{a, b, c}); len(loop) != 4 || loop[2] != (Point2LL{1, 1}) {
+		t.Errorf("unexpected loop %+v", loop)
+	}
+	if loop := chainPolylines([][]Point2LL{a, b}); loop != nil {
+		t.Errorf("expected nil for open chain, got %+v", loop)
+	}
+}
+
+func TestNormalizeEuroScopeSectorFile(t *testing.T) {
+	in := "\xef\xbb\xbf#define a 1\r\n[INFO]\r\nfoo\r\n#define b 2\r\n[REGIONS]\r\nREGIONNAME x\r\nbar\r\n"
+	expected := "#define a 1\n#define b 2\n[INFO]\r\nfoo\r\n[REGIONS]\r\nbar\r\n"
+	if out := string(normalizeEuroScopeSectorFile([]byte(in))); out != expected {
+		t.Errorf("got %q, expected %q", out, expected)
+	}
+}
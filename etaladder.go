@@ -0,0 +1,204 @@
+// etaladder.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// ETALadderPane
+
+// ETALadderPane shows arrivals to a set of airports on a vertical
+// timeline, ordered by their estimated time of arrival at the airport or
+// at a meter fix.
+type ETALadderPane struct {
+	Airports map[string]interface{}
+	// If non-empty, ETAs are computed to this fix rather than to the
+	// arrival airport.
+	MeterFix string
+	// Number of minutes of arrivals shown on the ladder.
+	Minutes int32
+
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	cb CommandBuffer
+}
+
+type ladderEntry struct {
+	ac    *Aircraft
+	eta   time.Time
+	label string
+	y     float32 // where the label is drawn, after de-overlapping
+}
+
+func NewETALadderPane() *ETALadderPane {
+	return &ETALadderPane{
+		Airports: make(map[string]interface{}),
+		Minutes:  30,
+	}
+}
+
+func (el *ETALadderPane) Duplicate(nameAsCopy bool) Pane {
+	return &ETALadderPane{
+		Airports:       DuplicateMap(el.Airports),
+		MeterFix:       el.MeterFix,
+		Minutes:        el.Minutes,
+		FontIdentifier: el.FontIdentifier,
+		font:           el.font,
+	}
+}
+
+func (el *ETALadderPane) Activate() {
+	if el.font = GetFont(el.FontIdentifier); el.font == nil {
+		el.font = GetDefaultFont()
+		el.FontIdentifier = el.font.id
+	}
+	if el.Airports == nil {
+		el.Airports = make(map[string]interface{})
+	}
+	if el.Minutes <= 0 {
+		el.Minutes = 30
+	}
+}
+
+func (el *ETALadderPane) Deactivate()                {}
+func (el *ETALadderPane) CanTakeKeyboardFocus() bool { return false }
+
+func (el *ETALadderPane) Name() string {
+	n := "Arrival Ladder"
+	if len(el.Airports) > 0 {
+		n += ": " + strings.Join(SortedMapKeys(el.Airports), ",")
+	}
+	if el.MeterFix != "" {
+		n += " via " + el.MeterFix
+	}
+	return n
+}
+
+func (el *ETALadderPane) DrawUI() {
+	el.Airports, _ = drawAirportSelector(el.Airports, "Airports")
+	imgui.InputTextV("Meter fix", &el.MeterFix, imgui.InputTextFlagsCharsUppercase, nil)
+	if el.MeterFix != "" {
+		if _, ok := database.Locate(el.MeterFix); !ok {
+			imgui.Text(el.MeterFix + ": unknown fix")
+		}
+	}
+	imgui.SliderIntV("Minutes shown", &el.Minutes, 5, 120, "%d", 0)
+	if newFont, changed := DrawFontPicker(&el.FontIdentifier, "Font"); changed {
+		el.font = newFont
+	}
+}
+
+func (el *ETALadderPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	now := server.CurrentTime()
+	window := time.Duration(el.Minutes) * time.Minute
+
+	var entries []ladderEntry
+	for _, arr := range getDistanceSortedArrivals(el.Airports) {
+		ac := arr.aircraft
+		fix := el.MeterFix
+		if fix == "" {
+			fix = ac.FlightPlan.ArrivalAirport
+		}
+		if eta, ok := ac.ETA(fix, now); ok && eta.Sub(now) < window {
+			label := fmt.Sprintf("%s %s %03d %s", eta.UTC().Format("1504"), ac.Callsign,
+				(ac.Altitude()+50)/100, ac.FlightPlan.AircraftType)
+			entries = append(entries, ladderEntry{ac: ac, eta: eta, label: label})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].eta.Before(entries[j].eta) })
+
+	el.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&el.cb)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+
+	cs := ctx.cs
+	style := TextStyle{Font: el.font, Color: cs.Text}
+	lineHeight := float32(el.font.size)
+	sz2 := lineHeight / 2
+	height := ctx.paneExtent.Height()
+
+	// The ladder runs from now at the bottom to now+Minutes at the top.
+	tickWidth, _ := el.font.BoundText("00:00", 0)
+	x := float32(tickWidth) + 2*lineHeight
+	y0, y1 := lineHeight, height-lineHeight
+	if y1 <= y0 {
+		return
+	}
+	timeToY := func(t time.Time) float32 {
+		return y0 + (y1-y0)*float32(t.Sub(now).Seconds()/window.Seconds())
+	}
+
+	ld.AddLine([2]float32{x, y0}, [2]float32{x, y1}, cs.UIControl)
+	tickInterval := 5 * time.Minute
+	if el.Minutes > 60 {
+		tickInterval = 15 * time.Minute
+	}
+	for t := now.Truncate(tickInterval).Add(tickInterval); t.Sub(now) <= window; t = t.Add(tickInterval) {
+		y := timeToY(t)
+		ld.AddLine([2]float32{x - sz2, y}, [2]float32{x, y}, cs.UIControl)
+		td.AddText(t.UTC().Format("15:04"), [2]float32{sz2, y + sz2}, style)
+	}
+
+	// Place labels at their ETAs, pushing them up as needed so that they
+	// don't overlap.
+	labelX := x + 2*lineHeight
+	prevY := float32(-1e10)
+	for i := range entries {
+		y := max(timeToY(entries[i].eta), prevY+lineHeight)
+		entries[i].y = y
+		prevY = y
+	}
+
+	var clicked *ladderEntry
+	for i, e := range entries {
+		tickY := timeToY(e.eta)
+		ld.AddLine([2]float32{x, tickY}, [2]float32{x + lineHeight, tickY}, cs.Text)
+		ld.AddLine([2]float32{x + lineHeight, tickY}, [2]float32{labelX - sz2/2, e.y}, cs.Text)
+
+		s := style
+		if positionConfig.IsFlagged(e.ac.Callsign) {
+			s.Color = cs.TextHighlight
+		}
+		if e.ac == positionConfig.selectedAircraft {
+			s.DrawBackground = true
+			s.BackgroundColor = cs.AltBackground
+		}
+		// AddText takes the upper-left corner.
+		td.AddText(e.label, [2]float32{labelX, e.y + sz2}, s)
+
+		if ctx.mouse != nil {
+			bx, _ := el.font.BoundText(e.label, 0)
+			p := ctx.mouse.Pos
+			if p[0] >= labelX && p[0] < labelX+float32(bx) && p[1] >= e.y-sz2 && p[1] < e.y+sz2 {
+				clicked = &entries[i]
+			}
+		}
+	}
+
+	if clicked != nil {
+		if ctx.mouse.Clicked[MouseButtonPrimary] {
+			eventStream.Post(&SelectedAircraftEvent{ac: clicked.ac})
+		}
+		if ctx.mouse.Clicked[MouseButtonSecondary] {
+			positionConfig.ToggleFlagged(clicked.ac.Callsign)
+		}
+	}
+
+	ld.GenerateCommands(&el.cb)
+	td.GenerateCommands(&el.cb)
+	cb.Call(el.cb)
+}
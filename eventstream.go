@@ -335,3 +335,50 @@ type ClosedServerConnectionEvent struct{}
 func (e *ClosedServerConnectionEvent) String() string {
 	return "ClosedServerConnectionEvent"
 }
+
+type DepartedEvent struct {
+	ac      *Aircraft
+	airport string
+	runway  string
+	time    time.Time
+}
+
+func (e *DepartedEvent) String() string {
+	return "DepartedEvent: " + e.ac.Callsign + " " + e.airport + " " + e.runway
+}
+
+type LandedEvent struct {
+	ac      *Aircraft
+	airport string
+	runway  string
+	time    time.Time
+}
+
+func (e *LandedEvent) String() string {
+	return "LandedEvent: " + e.ac.Callsign + " " + e.airport + " " + e.runway
+}
+
+type GoAroundEvent struct {
+	ac      *Aircraft
+	airport string
+	runway  string
+	time    time.Time
+}
+
+func (e *GoAroundEvent) String() string {
+	return "GoAroundEvent: " + e.ac.Callsign + " " + e.airport + " " + e.runway
+}
+
+// HoldingEvent is posted when an aircraft is seen to be flying in a
+// holding pattern; runway is generally unset and airport is empty if it
+// isn't holding near the airports in its flight plan.
+type HoldingEvent struct {
+	ac      *Aircraft
+	airport string
+	runway  string
+	time    time.Time
+}
+
+func (e *HoldingEvent) String() string {
+	return "HoldingEvent: " + e.ac.Callsign + " " + e.airport
+}
@@ -0,0 +1,492 @@
+// filter.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// Aircraft filter expressions are made of comparisons like "arr=KJFK",
+// "alt<10000", or "type~B7*" (where ~ does glob matching), combined with
+// & (and), | (or), ! (not), and parentheses.  Boolean fields like
+// "ground" may be used on their own and saved filters can be referenced
+// by name with a leading @.  CompileAircraftFilter turns an expression
+// into a function that can be passed to ATCServer.GetFilteredAircraft.
+
+type filterTokenType int
+
+const (
+	filterTokenEOF = iota
+	filterTokenWord
+	filterTokenOp // comparison operator
+	filterTokenAnd
+	filterTokenOr
+	filterTokenNot
+	filterTokenLParen
+	filterTokenRParen
+)
+
+type filterToken struct {
+	t    filterTokenType
+	text string
+}
+
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '&':
+			tokens = append(tokens, filterToken{t: filterTokenAnd, text: "&"})
+			i++
+		case ch == '|':
+			tokens = append(tokens, filterToken{t: filterTokenOr, text: "|"})
+			i++
+		case ch == '(':
+			tokens = append(tokens, filterToken{t: filterTokenLParen, text: "("})
+			i++
+		case ch == ')':
+			tokens = append(tokens, filterToken{t: filterTokenRParen, text: ")"})
+			i++
+		case ch == '!' || ch == '<' || ch == '>' || ch == '=' || ch == '~':
+			if i+1 < len(expr) && (expr[i+1] == '=' || (ch == '!' && expr[i+1] == '~')) {
+				tokens = append(tokens, filterToken{t: filterTokenOp, text: expr[i : i+2]})
+				i += 2
+			} else if ch == '!' {
+				tokens = append(tokens, filterToken{t: filterTokenNot, text: "!"})
+				i++
+			} else {
+				tokens = append(tokens, filterToken{t: filterTokenOp, text: expr[i : i+1]})
+				i++
+			}
+		case ch == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, filterToken{t: filterTokenWord, text: expr[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t&|()!<>=~\"", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{t: filterTokenWord, text: expr[start:i]})
+		}
+	}
+	return append(tokens, filterToken{t: filterTokenEOF}), nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	// Names of the saved filters currently being expanded, to catch
+	// recursive definitions.
+	expanding map[string]interface{}
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.t != filterTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() (func(*Aircraft) bool, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().t == filterTokenOr {
+		p.next()
+		g, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		a := f
+		f = func(ac *Aircraft) bool { return a(ac) || g(ac) }
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseAnd() (func(*Aircraft) bool, error) {
+	f, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().t == filterTokenAnd {
+		p.next()
+		g, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		a := f
+		f = func(ac *Aircraft) bool { return a(ac) && g(ac) }
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseUnary() (func(*Aircraft) bool, error) {
+	switch t := p.next(); t.t {
+	case filterTokenNot:
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(ac *Aircraft) bool { return !f(ac) }, nil
+
+	case filterTokenLParen:
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().t != filterTokenRParen {
+			return nil, fmt.Errorf("missing )")
+		}
+		return f, nil
+
+	case filterTokenWord:
+		if strings.HasPrefix(t.text, "@") {
+			return p.parseSavedFilter(t.text[1:])
+		}
+		return p.parseTerm(strings.ToLower(t.text))
+
+	case filterTokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("%s: unexpected", t.text)
+	}
+}
+
+func (p *filterParser) parseSavedFilter(name string) (func(*Aircraft) bool, error) {
+	expr, ok := globalConfig.AircraftFilters[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no saved filter with that name", name)
+	}
+	if _, ok := p.expanding[name]; ok {
+		return nil, fmt.Errorf("%s: saved filter refers to itself", name)
+	}
+
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	sub := &filterParser{tokens: tokens, expanding: DuplicateMap(p.expanding)}
+	sub.expanding[name] = nil
+	f, err := sub.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return f, nil
+}
+
+// filterStringFields are the fields that can be compared to strings.
+// Fields that come from the flight plan give an empty string for
+// aircraft without one.
+var filterStringFields = map[string]func(*Aircraft) string{
+	"callsign":   func(ac *Aircraft) string { return ac.Callsign },
+	"squawk":     func(ac *Aircraft) string { return ac.Squawk.String() },
+	"scratchpad": func(ac *Aircraft) string { return ac.Scratchpad },
+	"tracking":   func(ac *Aircraft) string { return ac.TrackingController },
+	"type":       flightPlanString(func(fp *FlightPlan) string { return fp.BaseType() }),
+	"rules":      flightPlanString(func(fp *FlightPlan) string { return fp.Rules.String() }),
+	"dep":        flightPlanString(func(fp *FlightPlan) string { return fp.DepartureAirport }),
+	"arr":        flightPlanString(func(fp *FlightPlan) string { return fp.ArrivalAirport }),
+	"alternate":  flightPlanString(func(fp *FlightPlan) string { return fp.AlternateAirport }),
+	"route":      flightPlanString(func(fp *FlightPlan) string { return fp.Route }),
+	"remarks":    flightPlanString(func(fp *FlightPlan) string { return fp.Remarks }),
+}
+
+func flightPlanString(f func(fp *FlightPlan) string) func(*Aircraft) string {
+	return func(ac *Aircraft) string {
+		if ac.FlightPlan == nil {
+			return ""
+		}
+		return f(ac.FlightPlan)
+	}
+}
+
+// filterNumericFields are the fields that are compared numerically.
+var filterNumericFields = map[string]func(*Aircraft) float32{
+	"alt":     func(ac *Aircraft) float32 { return float32(ac.Altitude()) },
+	"gs":      func(ac *Aircraft) float32 { return float32(ac.Groundspeed()) },
+	"tempalt": func(ac *Aircraft) float32 { return float32(ac.TempAltitude) },
+	"cruise": func(ac *Aircraft) float32 {
+		if ac.FlightPlan == nil {
+			return 0
+		}
+		return float32(ac.FlightPlan.Altitude)
+	},
+}
+
+var filterBoolFields = map[string]func(*Aircraft) bool{
+	"ground":     func(ac *Aircraft) bool { return ac.OnGround() },
+	"tracked":    func(ac *Aircraft) bool { return ac.TrackingController != "" },
+	"flagged":    func(ac *Aircraft) bool { return positionConfig.IsFlagged(ac.Callsign) },
+	"associated": func(ac *Aircraft) bool { return ac.IsAssociated() },
+	"flightplan": func(ac *Aircraft) bool { return ac.FlightPlan != nil },
+	"wrongfreq":  func(ac *Aircraft) bool { return ac.FrequencyCheck().Problem != "" },
+}
+
+func (p *filterParser) parseTerm(field string) (func(*Aircraft) bool, error) {
+	if b, ok := filterBoolFields[field]; ok {
+		return b, nil
+	}
+
+	// dist(FIX) gives the distance in nm from the specified location.
+	var distFrom Point2LL
+	if field == "dist" {
+		if p.next().t != filterTokenLParen {
+			return nil, fmt.Errorf("dist: expected (")
+		}
+		loc := p.next()
+		if loc.t != filterTokenWord {
+			return nil, fmt.Errorf("dist: expected a fix or airport")
+		}
+		if p.next().t != filterTokenRParen {
+			return nil, fmt.Errorf("dist: missing )")
+		}
+		var ok bool
+		if distFrom, ok = database.Locate(strings.ToUpper(loc.text)); !ok {
+			return nil, fmt.Errorf("%s: unknown location", loc.text)
+		}
+	}
+
+	op := p.next()
+	if op.t != filterTokenOp {
+		return nil, fmt.Errorf("%s: expected comparison operator", field)
+	}
+	value := p.next()
+	if value.t != filterTokenWord {
+		return nil, fmt.Errorf("%s%s: expected value", field, op.text)
+	}
+
+	if s, ok := filterStringFields[field]; ok {
+		v := strings.ToUpper(value.text)
+		if _, err := path.Match(v, ""); err != nil {
+			return nil, fmt.Errorf("%s: %v", value.text, err)
+		}
+		switch op.text {
+		case "=":
+			return func(ac *Aircraft) bool { return strings.ToUpper(s(ac)) == v }, nil
+		case "!=":
+			return func(ac *Aircraft) bool { return strings.ToUpper(s(ac)) != v }, nil
+		case "~":
+			return func(ac *Aircraft) bool { m, _ := path.Match(v, strings.ToUpper(s(ac))); return m }, nil
+		case "!~":
+			return func(ac *Aircraft) bool { m, _ := path.Match(v, strings.ToUpper(s(ac))); return !m }, nil
+		default:
+			return nil, fmt.Errorf("%s: %s can't be used with strings", field, op.text)
+		}
+	}
+
+	if field == "freq" {
+		fv, err := strconv.ParseFloat(value.text, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid frequency", value.text)
+		}
+		freq := NewFrequency(float32(fv))
+		tuned := func(ac *Aircraft) bool { return Find(ac.TunedFrequencies, freq) != -1 }
+		switch op.text {
+		case "=":
+			return tuned, nil
+		case "!=":
+			return func(ac *Aircraft) bool { return !tuned(ac) }, nil
+		default:
+			return nil, fmt.Errorf("freq: only = and != may be used")
+		}
+	}
+
+	var get func(*Aircraft) float32
+	var v float32
+	if field == "dist" {
+		get = func(ac *Aircraft) float32 { return nmdistance2ll(ac.Position(), distFrom) }
+		fv, err := strconv.ParseFloat(value.text, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid distance", value.text)
+		}
+		v = float32(fv)
+	} else if n, ok := filterNumericFields[field]; ok {
+		get = n
+		if field == "gs" {
+			gs, err := strconv.Atoi(value.text)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid groundspeed", value.text)
+			}
+			v = float32(gs)
+		} else {
+			alt, err := ParseAltitude(strings.ToUpper(value.text))
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid altitude", value.text)
+			}
+			v = float32(alt)
+		}
+	} else {
+		return nil, fmt.Errorf("%s: unknown field", field)
+	}
+
+	switch op.text {
+	case "=":
+		return func(ac *Aircraft) bool { return get(ac) == v }, nil
+	case "!=":
+		return func(ac *Aircraft) bool { return get(ac) != v }, nil
+	case "<":
+		return func(ac *Aircraft) bool { return get(ac) < v }, nil
+	case "<=":
+		return func(ac *Aircraft) bool { return get(ac) <= v }, nil
+	case ">":
+		return func(ac *Aircraft) bool { return get(ac) > v }, nil
+	case ">=":
+		return func(ac *Aircraft) bool { return get(ac) >= v }, nil
+	default:
+		return nil, fmt.Errorf("%s: %s can't be used with numbers", field, op.text)
+	}
+}
+
+func (p *filterParser) parse() (func(*Aircraft) bool, error) {
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.t != filterTokenEOF {
+		return nil, fmt.Errorf("%s: unexpected", t.text)
+	}
+	return f, nil
+}
+
+// CompileAircraftFilter parses the given filter expression and returns a
+// function that returns true for aircraft that match it.  An empty
+// expression matches all aircraft.
+func CompileAircraftFilter(expr string) (func(*Aircraft) bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return func(*Aircraft) bool { return true }, nil
+	}
+
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens, expanding: make(map[string]interface{})}
+	return p.parse()
+}
+
+///////////////////////////////////////////////////////////////////////////
+// AircraftFilter
+
+// aircraftFiltersGeneration is incremented whenever the saved filters
+// change so that AircraftFilters that refer to them are recompiled.
+var aircraftFiltersGeneration int
+
+// AircraftFilter is a filter expression that may be used by panes; it is
+// compiled on demand and recompiled when it or the saved filters change.
+type AircraftFilter struct {
+	Expression string
+
+	compiled     func(*Aircraft) bool
+	compiledExpr string
+	generation   int
+	err          error
+}
+
+func (f *AircraftFilter) compile() {
+	if f.compiled != nil && f.compiledExpr == f.Expression && f.generation == aircraftFiltersGeneration {
+		return
+	}
+	f.compiledExpr, f.generation = f.Expression, aircraftFiltersGeneration
+	if f.compiled, f.err = CompileAircraftFilter(f.Expression); f.err != nil {
+		// Don't hide everything if the expression is invalid; DrawUI
+		// reports the error.
+		f.compiled = func(*Aircraft) bool { return true }
+	}
+}
+
+// Match returns true if the aircraft passes the filter.
+func (f *AircraftFilter) Match(ac *Aircraft) bool {
+	f.compile()
+	return f.compiled(ac)
+}
+
+// DrawUI draws the imgui widgets for editing the filter and selecting a
+// saved filter; it returns true if the filter was changed.
+func (f *AircraftFilter) DrawUI(label string) bool {
+	changed := imgui.InputText(label, &f.Expression)
+	if len(globalConfig.AircraftFilters) > 0 {
+		imgui.SameLine()
+		if imgui.BeginComboV(fmt.Sprintf("##saved%p", f), "Saved", imgui.ComboFlagsNoPreview) {
+			for _, name := range SortedMapKeys(globalConfig.AircraftFilters) {
+				if imgui.Selectable(name) {
+					f.Expression = "@" + name
+					changed = true
+				}
+			}
+			imgui.EndCombo()
+		}
+	}
+	f.compile()
+	if f.err != nil {
+		imgui.Text("Error: " + f.err.Error())
+	}
+	return changed
+}
+
+// DrawAircraftFiltersUI draws the editor for the saved aircraft filters.
+func (gc *GlobalConfig) DrawAircraftFiltersUI() {
+	flags := imgui.TableFlagsBordersV | imgui.TableFlagsBordersOuterH | imgui.TableFlagsRowBg
+	if imgui.BeginTableV("filters", 3, flags, imgui.Vec2{}, 0) {
+		imgui.TableSetupColumn("Name")
+		imgui.TableSetupColumn("Expression")
+		imgui.TableSetupColumn("")
+		imgui.TableHeadersRow()
+
+		for _, name := range SortedMapKeys(gc.AircraftFilters) {
+			imgui.PushID(name)
+			imgui.TableNextRow()
+			imgui.TableNextColumn()
+			imgui.Text(name)
+			imgui.TableNextColumn()
+			expr := gc.AircraftFilters[name]
+			if imgui.InputText("##expr", &expr) {
+				gc.AircraftFilters[name] = expr
+				aircraftFiltersGeneration++
+			}
+			if _, err := CompileAircraftFilter(expr); err != nil {
+				imgui.Text("Error: " + err.Error())
+			}
+			imgui.TableNextColumn()
+			if imgui.Button(FontAwesomeIconTrash) {
+				delete(gc.AircraftFilters, name)
+				aircraftFiltersGeneration++
+			}
+			imgui.PopID()
+		}
+		imgui.EndTable()
+	}
+
+	imgui.InputTextV("New filter name", &ui.newFilterName, imgui.InputTextFlagsCharsNoBlank, nil)
+	imgui.SameLine()
+	_, exists := gc.AircraftFilters[ui.newFilterName]
+	disable := ui.newFilterName == "" || exists
+	uiStartDisable(disable)
+	if imgui.Button("Add") {
+		if gc.AircraftFilters == nil {
+			gc.AircraftFilters = make(map[string]string)
+		}
+		gc.AircraftFilters[ui.newFilterName] = ""
+		ui.newFilterName = ""
+		aircraftFiltersGeneration++
+	}
+	uiEndDisable(disable)
+}
@@ -0,0 +1,59 @@
+// filter_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestAircraftFilter(t *testing.T) {
+	ac := &Aircraft{
+		Callsign: "AAL123",
+		FlightPlan: &FlightPlan{
+			Rules:            IFR,
+			AircraftType:     "B738/L",
+			DepartureAirport: "KBOS",
+			ArrivalAirport:   "KJFK",
+		},
+		TunedFrequencies: []Frequency{NewFrequency(125.7)},
+	}
+	ac.Tracks[0] = RadarTrack{Altitude: 8000, Groundspeed: 250}
+
+	for _, test := range []struct {
+		expr  string
+		match bool
+	}{
+		{"", true},
+		{"arr=KJFK", true},
+		{"arr=kjfk", true},
+		{"ARR=KJFK & alt<10000 & rules=IFR & type~B7*", true},
+		{"arr=KJFK & alt<5000", false},
+		{"arr=KLGA | dep=KBOS", true},
+		{"!(arr=KLGA | dep=KBOS)", false},
+		{"type=B738", true},
+		{"type!~A3*", true},
+		{"callsign~AAL???", true},
+		{"alt>=FL080", true},
+		{"alt>FL080", false},
+		{"gs>200 & !tracked", true},
+		{"freq=125.7", true},
+		{"freq!=125.7", false},
+		{"tracking=\"\"", true},
+	} {
+		f, err := CompileAircraftFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+		} else if f(ac) != test.match {
+			t.Errorf("%s: got %v, expected %v", test.expr, f(ac), test.match)
+		}
+	}
+
+	for _, expr := range []string{"arr", "arr=", "alt<abc", "arr<KJFK", "(arr=KJFK", "arr=KJFK)",
+		"arr=KJFK &", "speed>100", "freq<120.0", "callsign=\"AAL"} {
+		if _, err := CompileAircraftFilter(expr); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+// flightstrips.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// FlightStripPane
+
+// FlightStripPane displays flight strips organized into bays. Strips can
+// be dragged between and within bays and each one has a 3x3 grid of
+// editable annotations. The bays and annotations are saved with the
+// pane's configuration so that they persist across sessions.
+type FlightStripPane struct {
+	Airports          map[string]interface{}
+	AutoAddDepartures bool
+	// Arrivals are added to the bay named "Arrivals" if there is one and
+	// to the first bay otherwise.
+	AutoAddArrivals bool
+	// If set, departures are moved to the bay named "Departed" (if there
+	// is one) when they take off.
+	MoveDeparted bool
+
+	Bays []FlightStripBay
+	// callsign -> annotations; this is the persistent copy of the
+	// annotations in the server's FlightStrip.
+	Annotations map[string][9]string
+
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	// Aircraft for which strips have been automatically added; this
+	// ensures that strips that the user deletes don't come back.
+	addedAircraft map[string]interface{}
+
+	selectedStrip       string
+	selectedAnnotation  int
+	annotationCursorPos int
+	dragStrip           string
+
+	addCallsign string
+
+	eventsId EventSubscriberId
+	sb       *ScrollBar
+	cb       CommandBuffer
+}
+
+type FlightStripBay struct {
+	Name      string
+	Callsigns []string
+}
+
+// Layout of a strip, in characters
+const (
+	flightStripTextWidth       = 26
+	flightStripAnnotationWidth = 6
+	flightStripLines           = 3
+)
+
+func NewFlightStripPane() *FlightStripPane {
+	return &FlightStripPane{
+		Airports:          make(map[string]interface{}),
+		AutoAddDepartures: true,
+		AutoAddArrivals:   true,
+		MoveDeparted:      true,
+		Bays: []FlightStripBay{
+			FlightStripBay{Name: "Pending"},
+			FlightStripBay{Name: "Cleared"},
+			FlightStripBay{Name: "Taxi"},
+			FlightStripBay{Name: "Departed"},
+			FlightStripBay{Name: "Arrivals"},
+		},
+		Annotations:        make(map[string][9]string),
+		selectedAnnotation: -1,
+	}
+}
+
+func (fsp *FlightStripPane) Duplicate(nameAsCopy bool) Pane {
+	dupe := &FlightStripPane{
+		Airports:           DuplicateMap(fsp.Airports),
+		AutoAddDepartures:  fsp.AutoAddDepartures,
+		AutoAddArrivals:    fsp.AutoAddArrivals,
+		MoveDeparted:       fsp.MoveDeparted,
+		Annotations:        DuplicateMap(fsp.Annotations),
+		FontIdentifier:     fsp.FontIdentifier,
+		font:               fsp.font,
+		addedAircraft:      DuplicateMap(fsp.addedAircraft),
+		selectedAnnotation: -1,
+		eventsId:           eventStream.Subscribe(),
+		sb:                 NewScrollBar(4, false),
+	}
+	for _, bay := range fsp.Bays {
+		dupe.Bays = append(dupe.Bays, FlightStripBay{Name: bay.Name, Callsigns: DuplicateSlice(bay.Callsigns)})
+	}
+	return dupe
+}
+
+func (fsp *FlightStripPane) Activate() {
+	if fsp.font = GetFont(fsp.FontIdentifier); fsp.font == nil {
+		fsp.font = GetDefaultFont()
+		fsp.FontIdentifier = fsp.font.id
+	}
+	if fsp.Airports == nil {
+		fsp.Airports = make(map[string]interface{})
+	}
+	if fsp.Annotations == nil {
+		fsp.Annotations = make(map[string][9]string)
+	}
+	if len(fsp.Bays) == 0 {
+		fsp.Bays = []FlightStripBay{FlightStripBay{Name: "Pending"}}
+	}
+	if fsp.addedAircraft == nil {
+		fsp.addedAircraft = make(map[string]interface{})
+		// Don't automatically re-add strips that were saved with the pane.
+		for _, bay := range fsp.Bays {
+			for _, callsign := range bay.Callsigns {
+				fsp.addedAircraft[callsign] = nil
+			}
+		}
+	}
+	if fsp.sb == nil {
+		fsp.sb = NewScrollBar(4, false)
+	}
+	fsp.selectedAnnotation = -1
+	fsp.eventsId = eventStream.Subscribe()
+}
+
+func (fsp *FlightStripPane) Deactivate() {
+	eventStream.Unsubscribe(fsp.eventsId)
+	fsp.eventsId = InvalidEventSubscriberId
+}
+
+func (fsp *FlightStripPane) CanTakeKeyboardFocus() bool { return true }
+
+func (fsp *FlightStripPane) Name() string {
+	n := "Flight Strips"
+	if len(fsp.Airports) > 0 {
+		n += ": " + strings.Join(SortedMapKeys(fsp.Airports), ",")
+	}
+	return n
+}
+
+func (fsp *FlightStripPane) DrawUI() {
+	fsp.Airports, _ = drawAirportSelector(fsp.Airports, "Airports")
+	imgui.Checkbox("Automatically add departures", &fsp.AutoAddDepartures)
+	imgui.Checkbox("Automatically add arrivals", &fsp.AutoAddArrivals)
+	imgui.Checkbox("Move departures to \"Departed\" bay after takeoff", &fsp.MoveDeparted)
+	if newFont, changed := DrawFontPicker(&fsp.FontIdentifier, "Font"); changed {
+		fsp.font = newFont
+	}
+
+	imgui.Separator()
+	imgui.Text("Bays")
+	for i := range fsp.Bays {
+		imgui.PushID(fmt.Sprintf("bay%d", i))
+		imgui.InputText("##name", &fsp.Bays[i].Name)
+		imgui.SameLine()
+		uiStartDisable(i == 0)
+		if imgui.Button(FontAwesomeIconArrowUp) {
+			fsp.Bays[i], fsp.Bays[i-1] = fsp.Bays[i-1], fsp.Bays[i]
+		}
+		uiEndDisable(i == 0)
+		imgui.SameLine()
+		// Only allow removing empty bays so that strips don't silently
+		// disappear.
+		disable := len(fsp.Bays) == 1 || len(fsp.Bays[i].Callsigns) > 0
+		uiStartDisable(disable)
+		remove := imgui.Button(FontAwesomeIconTrash)
+		uiEndDisable(disable)
+		imgui.PopID()
+		if remove {
+			fsp.Bays = DeleteSliceElement(fsp.Bays, i)
+			break
+		}
+	}
+	if imgui.Button("Add bay") {
+		fsp.Bays = append(fsp.Bays, FlightStripBay{Name: "New bay"})
+	}
+
+	imgui.Separator()
+	imgui.InputTextV("Callsign", &fsp.addCallsign, imgui.InputTextFlagsCharsUppercase, nil)
+	imgui.SameLine()
+	if imgui.Button("Add strip") && fsp.addCallsign != "" {
+		fsp.addStrip(fsp.addCallsign, 0)
+		fsp.addCallsign = ""
+	}
+	imgui.Text("Drag strips to move them; right-click a strip to remove it.")
+}
+
+// findStrip returns the bay and index within it of the given aircraft's
+// strip, or -1s if there isn't one.
+func (fsp *FlightStripPane) findStrip(callsign string) (bay int, index int) {
+	for i, b := range fsp.Bays {
+		if idx := Find(b.Callsigns, callsign); idx != -1 {
+			return i, idx
+		}
+	}
+	return -1, -1
+}
+
+func (fsp *FlightStripPane) addStrip(callsign string, bay int) {
+	fsp.addedAircraft[callsign] = nil
+	if b, _ := fsp.findStrip(callsign); b == -1 {
+		fsp.Bays[bay].Callsigns = append(fsp.Bays[bay].Callsigns, callsign)
+	}
+}
+
+func (fsp *FlightStripPane) removeStrip(callsign string) {
+	if b, idx := fsp.findStrip(callsign); b != -1 {
+		fsp.Bays[b].Callsigns = DeleteSliceElement(fsp.Bays[b].Callsigns, idx)
+	}
+	delete(fsp.Annotations, callsign)
+	if fsp.selectedStrip == callsign {
+		fsp.selectedStrip = ""
+		fsp.selectedAnnotation = -1
+	}
+}
+
+// moveStrip moves the strip to the given position in the given bay.
+func (fsp *FlightStripPane) moveStrip(callsign string, bay int, index int) {
+	if b, idx := fsp.findStrip(callsign); b != -1 {
+		fsp.Bays[b].Callsigns = DeleteSliceElement(fsp.Bays[b].Callsigns, idx)
+		if b == bay && idx < index {
+			index--
+		}
+	}
+	index = clamp(index, 0, len(fsp.Bays[bay].Callsigns))
+	fsp.Bays[bay].Callsigns = InsertSliceElement(fsp.Bays[bay].Callsigns, index, callsign)
+}
+
+func (fsp *FlightStripPane) processEvents(es *EventStream) {
+	arrivalBay := FindIf(fsp.Bays, func(b FlightStripBay) bool { return strings.EqualFold(b.Name, "Arrivals") })
+	if arrivalBay == -1 {
+		arrivalBay = 0
+	}
+	departedBay := FindIf(fsp.Bays, func(b FlightStripBay) bool { return strings.EqualFold(b.Name, "Departed") })
+
+	for _, event := range es.Get(fsp.eventsId) {
+		switch v := event.(type) {
+		case *AddedAircraftEvent:
+			fsp.checkAutoAdd(v.ac, arrivalBay)
+
+		case *ModifiedAircraftEvent:
+			fsp.checkAutoAdd(v.ac, arrivalBay)
+
+		case *PushedFlightStripEvent:
+			fsp.addStrip(v.callsign, 0)
+
+		case *DepartedEvent:
+			if fsp.MoveDeparted && departedBay != -1 {
+				if b, _ := fsp.findStrip(v.ac.Callsign); b != -1 && b != departedBay {
+					fsp.moveStrip(v.ac.Callsign, departedBay, len(fsp.Bays[departedBay].Callsigns))
+				}
+			}
+		}
+	}
+}
+
+// checkAutoAdd adds a strip for the aircraft if it is departing from or
+// arriving at one of the pane's airports and doesn't already have one.
+func (fsp *FlightStripPane) checkAutoAdd(ac *Aircraft, arrivalBay int) {
+	if _, ok := fsp.addedAircraft[ac.Callsign]; ok || ac.FlightPlan == nil {
+		return
+	}
+	if _, ok := fsp.Airports[ac.FlightPlan.DepartureAirport]; ok && fsp.AutoAddDepartures && ac.OnGround() {
+		fsp.addStrip(ac.Callsign, 0)
+	} else if _, ok := fsp.Airports[ac.FlightPlan.ArrivalAirport]; ok && fsp.AutoAddArrivals {
+		fsp.addStrip(ac.Callsign, arrivalBay)
+	}
+}
+
+func (fsp *FlightStripPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	fsp.processEvents(ctx.events)
+
+	if !ctx.haveFocus {
+		fsp.selectedAnnotation = -1
+	}
+
+	cs := ctx.cs
+	style := TextStyle{Font: fsp.font, Color: cs.Text}
+	headerStyle := TextStyle{Font: fsp.font, Color: cs.TextHighlight}
+	cursorStyle := TextStyle{Font: fsp.font, Color: cs.Background,
+		DrawBackground: true, BackgroundColor: cs.Text}
+
+	lineHeight := float32(fsp.font.size)
+	charWidth, _ := fsp.font.BoundText("X", 0)
+	cw := float32(charWidth)
+	sz2 := lineHeight / 2
+	width, height := ctx.paneExtent.Width(), ctx.paneExtent.Height()
+
+	// Lay out the strips by line: each bay has a one-line header and then
+	// each strip takes flightStripLines lines.
+	type stripRow struct {
+		bay, index int // index is -1 for bay headers
+		line       int // first line
+	}
+	var rows []stripRow
+	nLines := 0
+	for b, bay := range fsp.Bays {
+		rows = append(rows, stripRow{bay: b, index: -1, line: nLines})
+		nLines++
+		for i := range bay.Callsigns {
+			rows = append(rows, stripRow{bay: b, index: i, line: nLines})
+			nLines += flightStripLines
+		}
+	}
+
+	nVisibleLines := int(height / lineHeight)
+	fsp.sb.Update(nLines, nVisibleLines, ctx)
+	offset := fsp.sb.Offset()
+	lineY := func(line int) float32 { return height - float32(line-offset)*lineHeight }
+
+	// Returns the row under the given y coordinate, or nil if none.
+	rowAt := func(y float32) *stripRow {
+		line := int((height-y)/lineHeight) + offset
+		for i := range rows {
+			n := Select(rows[i].index == -1, 1, flightStripLines)
+			if line >= rows[i].line && line < rows[i].line+n {
+				return &rows[i]
+			}
+		}
+		return nil
+	}
+
+	fsp.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&fsp.cb)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+
+	annotationX := sz2 + flightStripTextWidth*cw
+	stripRight := annotationX + 3*flightStripAnnotationWidth*cw
+	stripRight = min(stripRight, width-float32(fsp.sb.Width()))
+
+	// annotationAt returns the index of the annotation at the given point
+	// in a strip starting at y, or -1 if it's not over an annotation.
+	annotationAt := func(p [2]float32, y float32) int {
+		if p[0] < annotationX || p[0] >= annotationX+3*flightStripAnnotationWidth*cw {
+			return -1
+		}
+		col := int((p[0] - annotationX) / (flightStripAnnotationWidth * cw))
+		row := int((y - p[1]) / lineHeight)
+		if row < 0 || row > 2 {
+			return -1
+		}
+		return 3*row + col
+	}
+
+	var editPos [2]float32
+	removeCallsign := ""
+	for _, row := range rows {
+		y := lineY(row.line)
+		if y < 0 || y-lineHeight > height {
+			continue
+		}
+
+		bay := fsp.Bays[row.bay]
+		if row.index == -1 {
+			td.AddText(fmt.Sprintf("%s (%d)", bay.Name, len(bay.Callsigns)), [2]float32{sz2, y}, headerStyle)
+			ld.AddLine([2]float32{sz2, y - lineHeight}, [2]float32{stripRight, y - lineHeight}, cs.UIControl)
+			continue
+		}
+
+		callsign := bay.Callsigns[row.index]
+		s := style
+		if ac := server.GetAircraft(callsign); ac != nil && ac == positionConfig.selectedAircraft {
+			s.DrawBackground = true
+			s.BackgroundColor = cs.AltBackground
+		}
+		td.AddText(fsp.stripText(callsign), [2]float32{sz2, y}, s)
+
+		// Strip outline and annotation grid
+		y1 := y - flightStripLines*lineHeight
+		ld.AddLine([2]float32{sz2, y1}, [2]float32{stripRight, y1}, cs.UIControl)
+		for i := 0; i <= 3; i++ {
+			x := annotationX + float32(i*flightStripAnnotationWidth)*cw
+			ld.AddLine([2]float32{x, y}, [2]float32{x, y1}, cs.UIControl)
+		}
+		for i := 1; i < 3; i++ {
+			ay := y - float32(i)*lineHeight
+			ld.AddLine([2]float32{annotationX, ay}, [2]float32{stripRight, ay}, cs.UIControl)
+		}
+
+		annotations := fsp.Annotations[callsign]
+		for i, a := range annotations {
+			p := [2]float32{annotationX + float32((i%3)*flightStripAnnotationWidth)*cw + cw/2,
+				y - float32(i/3)*lineHeight}
+			if callsign == fsp.selectedStrip && i == fsp.selectedAnnotation {
+				editPos = p
+			} else if a != "" {
+				td.AddText(a, p, style)
+			}
+		}
+
+		// Mouse handling for this strip
+		if ctx.mouse != nil && ctx.mouse.Pos[1] <= y && ctx.mouse.Pos[1] > y1 &&
+			ctx.mouse.Pos[0] >= sz2 && ctx.mouse.Pos[0] < stripRight {
+			if ctx.mouse.Clicked[MouseButtonPrimary] {
+				if idx := annotationAt(ctx.mouse.Pos, y); idx != -1 {
+					fsp.selectedStrip = callsign
+					fsp.selectedAnnotation = idx
+					fsp.annotationCursorPos = len(annotations[idx])
+					wmTakeKeyboardFocus(fsp, true)
+				} else {
+					fsp.dragStrip = callsign
+					if ac := server.GetAircraft(callsign); ac != nil {
+						eventStream.Post(&SelectedAircraftEvent{ac: ac})
+					}
+				}
+			}
+			if ctx.mouse.Clicked[MouseButtonSecondary] {
+				removeCallsign = callsign
+			}
+		}
+	}
+
+	if removeCallsign != "" {
+		fsp.removeStrip(removeCallsign)
+	}
+
+	// Annotation editing
+	if fsp.selectedAnnotation != -1 && ctx.haveFocus {
+		annotations := fsp.Annotations[fsp.selectedStrip]
+		exit, _ := uiDrawTextEdit(&annotations[fsp.selectedAnnotation], &fsp.annotationCursorPos,
+			ctx.keyboard, editPos, style, cursorStyle, &fsp.cb)
+		// Annotations are short; keep them from overflowing their box.
+		if len(annotations[fsp.selectedAnnotation]) > flightStripAnnotationWidth-1 {
+			annotations[fsp.selectedAnnotation] = annotations[fsp.selectedAnnotation][:flightStripAnnotationWidth-1]
+		}
+		fsp.Annotations[fsp.selectedStrip] = annotations
+
+		switch exit {
+		case TextEditReturnEnter:
+			fsp.selectedAnnotation = -1
+		case TextEditReturnNext:
+			fsp.selectedAnnotation = (fsp.selectedAnnotation + 1) % 9
+			fsp.annotationCursorPos = len(annotations[fsp.selectedAnnotation])
+		case TextEditReturnPrev:
+			fsp.selectedAnnotation = (fsp.selectedAnnotation + 8) % 9
+			fsp.annotationCursorPos = len(annotations[fsp.selectedAnnotation])
+		}
+	}
+
+	// Keep the server's flight strips in sync with the saved annotations.
+	for callsign, annotations := range fsp.Annotations {
+		if strip := server.GetFlightStrip(callsign); strip != nil {
+			strip.annotations = annotations
+		}
+	}
+
+	// Drag and drop
+	if fsp.dragStrip != "" && ctx.mouse != nil {
+		// Figure out where the strip would be dropped: before the strip
+		// under the mouse if it's in its upper half, otherwise after it.
+		dropBay, dropIndex := len(fsp.Bays)-1, len(fsp.Bays[len(fsp.Bays)-1].Callsigns)
+		dropY := lineY(nLines)
+		if row := rowAt(ctx.mouse.Pos[1]); row != nil {
+			dropBay = row.bay
+			if row.index == -1 {
+				dropIndex, dropY = 0, lineY(row.line+1)
+			} else if ctx.mouse.Pos[1] > lineY(row.line)-flightStripLines*lineHeight/2 {
+				dropIndex, dropY = row.index, lineY(row.line)
+			} else {
+				dropIndex, dropY = row.index+1, lineY(row.line+flightStripLines)
+			}
+		}
+
+		if ctx.mouse.Dragging[MouseButtonPrimary] {
+			ld.AddLine([2]float32{sz2, dropY}, [2]float32{stripRight, dropY}, cs.TextHighlight)
+		} else if ctx.mouse.Released[MouseButtonPrimary] {
+			if b, _ := fsp.findStrip(fsp.dragStrip); b != -1 {
+				fsp.moveStrip(fsp.dragStrip, dropBay, dropIndex)
+			}
+			fsp.dragStrip = ""
+		}
+	}
+
+	ld.GenerateCommands(&fsp.cb)
+	td.GenerateCommands(&fsp.cb)
+	fsp.sb.Draw(ctx, &fsp.cb)
+
+	cb.Call(fsp.cb)
+}
+
+// stripText returns the three lines of text for the given aircraft's
+// flight strip.
+func (fsp *FlightStripPane) stripText(callsign string) string {
+	ac := server.GetAircraft(callsign)
+	if ac == nil || ac.FlightPlan == nil {
+		return callsign + "\n\n"
+	}
+
+	fp := ac.FlightPlan
+	route := fp.Route
+	if len(route) > flightStripTextWidth-1 {
+		route = route[:flightStripTextWidth-3] + ".."
+	}
+	return fmt.Sprintf("%-8s %-4s %s\n", callsign, fp.Rules, fp.AircraftType) +
+		fmt.Sprintf("%s %-6s %s-%s\n", ac.AssignedSquawk, formatAltitude(fp.Altitude),
+			fp.DepartureAirport, fp.ArrivalAirport) +
+		route
+}
@@ -44,6 +44,7 @@ var (
 	FontAwesomeIconGlobeAmericas       = faUsedIcons["GlobeAmericas"]
 	FontAwesomeIconHome                = faUsedIcons["Home"]
 	FontAwesomeIconHandPointLeft       = faUsedIcons["HandPointLeft"]
+	FontAwesomeIconHeadphones          = faUsedIcons["Headphones"]
 	FontAwesomeIconLevelUpAlt          = faUsedIcons["LevelUpAlt"]
 	FontAwesomeIconLock                = faUsedIcons["Lock"]
 	FontAwesomeIconSquare              = faUsedIcons["Square"]
@@ -74,6 +75,7 @@ var (
 		"GlobeAmericas":       FontAwesomeString("GlobeAmericas"),
 		"Home":                FontAwesomeString("Home"),
 		"HandPointLeft":       FontAwesomeString("HandPointLeft"),
+		"Headphones":          FontAwesomeString("Headphones"),
 		"LevelUpAlt":          FontAwesomeString("LevelUpAlt"),
 		"Lock":                FontAwesomeString("Lock"),
 		"Square":              FontAwesomeString("Square"),
@@ -0,0 +1,198 @@
+// geodesy.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file has functions for distances, bearings, and destination points
+// on the Earth, both on a sphere (great circle) and on the WGS84
+// ellipsoid. The flat-earth approximations elsewhere (e.g., ll2nm) are
+// only accurate over short distances near the latitude their scale was
+// computed for; nmPerLongitudeAt gives the scale to use with them.
+
+package main
+
+import (
+	"math"
+)
+
+const (
+	// Mean radius of the Earth; it matches the one used by nmdistance2ll.
+	earthRadiusNM = 6371000 / 1852.
+
+	nmPerLatitude = 60
+
+	// Flat-earth bearings are used for points that are at most this many
+	// degrees apart in both latitude and longitude, where they differ
+	// from great-circle bearings by a small fraction of a degree.
+	flatEarthMaxDegrees = 0.5
+
+	// WGS84 ellipsoid
+	wgs84A = 6378137.
+	wgs84F = 1 / 298.257223563
+	wgs84B = (1 - wgs84F) * wgs84A
+)
+
+// nmPerLongitudeAt returns the number of nautical miles per degree of
+// longitude at the given latitude.
+func nmPerLongitudeAt(latitude float32) float32 {
+	return nmPerLatitude * cos(radians(latitude))
+}
+
+// flatEarthValid returns true if a flat-earth approximation using the
+// local nautical miles per degree of longitude is accurate for the two
+// points.
+func flatEarthValid(a, b Point2LL) bool {
+	return abs(a[0]-b[0]) <= flatEarthMaxDegrees && abs(a[1]-b[1]) <= flatEarthMaxDegrees &&
+		abs(a[1]) < 80 && abs(b[1]) < 80
+}
+
+// normalizeBearing returns the given bearing in degrees in the range
+// [0,360).
+func normalizeBearing(b float64) float32 {
+	b = math.Mod(b, 360)
+	if b < 0 {
+		b += 360
+	}
+	return float32(b)
+}
+
+// greatCircleBearing returns the initial true bearing in degrees of the
+// great circle path from |from| to |to|.
+func greatCircleBearing(from, to Point2LL) float32 {
+	// https://www.movable-type.co.uk/scripts/latlong.html
+	rad := func(d float32) float64 { return float64(d) / 180 * math.Pi }
+	lat1, lat2 := rad(from[1]), rad(to[1])
+	dlon := rad(to[0] - from[0])
+
+	y := math.Sin(dlon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dlon)
+	return normalizeBearing(math.Atan2(y, x) * 180 / math.Pi)
+}
+
+// greatCircleDestination returns the point reached by following the
+// great circle from p with the given initial true bearing (degrees) for
+// the given distance in nautical miles.
+func greatCircleDestination(p Point2LL, bearing float32, nm float32) Point2LL {
+	rad := func(d float32) float64 { return float64(d) / 180 * math.Pi }
+	lat1, lon1, theta := rad(p[1]), rad(p[0]), rad(bearing)
+	delta := float64(nm) / earthRadiusNM
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1),
+		math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return Point2LL{float32(normalizeLongitude(lon2 * 180 / math.Pi)), float32(lat2 * 180 / math.Pi)}
+}
+
+// normalizeLongitude returns the given longitude in degrees in the range
+// [-180,180).
+func normalizeLongitude(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// ellipsoidalInverse returns the distance in nautical miles and the
+// initial true bearing in degrees of the shortest path between two points
+// on the WGS84 ellipsoid, computed using Vincenty's formulae. For nearly
+// antipodal points where the iteration doesn't converge, the great circle
+// distance and bearing are returned.
+func ellipsoidalInverse(from, to Point2LL) (nm float32, bearing float32) {
+	// https://www.movable-type.co.uk/scripts/latlong-vincenty.html
+	rad := func(d float32) float64 { return float64(d) / 180 * math.Pi }
+	L := rad(to[0] - from[0])
+	U1 := math.Atan((1 - wgs84F) * math.Tan(rad(from[1])))
+	U2 := math.Atan((1 - wgs84F) * math.Tan(rad(to[1])))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	lambda := L
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	converged := false
+	for i := 0; i < 100; i++ {
+		sinLambda, cosLambda = math.Sincos(lambda)
+		sinSigma = math.Sqrt(sqr(cosU2*sinLambda) + sqr(cosU1*sinU2-sinU1*cosU2*cosLambda))
+		if sinSigma == 0 {
+			// Coincident points
+			return 0, 0
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // on the equator
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = L + (1-C)*wgs84F*sinAlpha*
+			(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return nmdistance2ll(from, to), greatCircleBearing(from, to)
+	}
+
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	s := wgs84B * A * (sigma - deltaSigma) // metres
+
+	alpha1 := math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+	return float32(s / 1852), normalizeBearing(alpha1 * 180 / math.Pi)
+}
+
+// ellipsoidalDestination returns the point reached by following the
+// geodesic on the WGS84 ellipsoid from p with the given initial true
+// bearing (degrees) for the given distance in nautical miles.
+func ellipsoidalDestination(p Point2LL, bearing float32, nm float32) Point2LL {
+	rad := func(d float32) float64 { return float64(d) / 180 * math.Pi }
+	sinAlpha1, cosAlpha1 := math.Sincos(rad(bearing))
+	s := float64(nm) * 1852
+
+	tanU1 := (1 - wgs84F) * math.Tan(rad(p[1]))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+	sigma1 := math.Atan2(tanU1, cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+
+	sigma := s / (wgs84B * A)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for i := 0; i < 100; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sincos(sigma)
+		deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		prev := sigma
+		sigma = s/(wgs84B*A) + deltaSigma
+		if math.Abs(sigma-prev) < 1e-12 {
+			break
+		}
+	}
+	sinSigma, cosSigma = math.Sincos(sigma)
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+
+	x := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat2 := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-wgs84F)*math.Sqrt(sinAlpha*sinAlpha+x*x))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+	L := lambda - (1-C)*wgs84F*sinAlpha*
+		(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+	lon2 := float64(p[0]) + L*180/math.Pi
+	return Point2LL{float32(normalizeLongitude(lon2)), float32(lat2 * 180 / math.Pi)}
+}
+
+// ellipsoidalHeading returns the distance in nautical miles and the
+// initial magnetic heading from |from| to |to| along the geodesic.
+func ellipsoidalHeading(from, to Point2LL) (nm float32, heading float32) {
+	nm, bearing := ellipsoidalInverse(from, to)
+	return nm, normalizeBearing(float64(bearing + database.MagneticCorrection(from)))
+}
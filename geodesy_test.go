@@ -0,0 +1,79 @@
+// geodesy_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestEllipsoidal(t *testing.T) {
+	// Vincenty's example: Flinders Peak to Buninyong.
+	flinders := Point2LL{144 + 25./60 + 29.5244/3600, -(37 + 57./60 + 3.7203/3600)}
+	buninyong := Point2LL{143 + 55./60 + 35.3839/3600, -(37 + 39./60 + 10.1561/3600)}
+
+	// Tolerances account for Point2LL's float32 precision, which is
+	// about a metre at these longitudes.
+	nm, bearing := ellipsoidalInverse(flinders, buninyong)
+	if abs(nm-54972.271/1852) > 0.002 || abs(bearing-306.868158) > 0.002 {
+		t.Errorf("got %f nm bearing %f, expected %f nm bearing %f", nm, bearing, 54972.271/1852, 306.868158)
+	}
+
+	p := ellipsoidalDestination(flinders, bearing, nm)
+	if abs(p[0]-buninyong[0]) > 1e-5 || abs(p[1]-buninyong[1]) > 1e-5 {
+		t.Errorf("got destination %v, expected %v", p, buninyong)
+	}
+
+	if nm, _ := ellipsoidalInverse(flinders, flinders); nm != 0 {
+		t.Errorf("got %f nm for coincident points", nm)
+	}
+}
+
+func TestGreatCircle(t *testing.T) {
+	jfk, lhr := Point2LL{-73.7789, 40.6398}, Point2LL{-0.4614, 51.47}
+
+	bearing := greatCircleBearing(jfk, lhr)
+	if bearing < 50 || bearing > 52 {
+		t.Errorf("JFK-LHR bearing %f, expected about 51", bearing)
+	}
+	p := greatCircleDestination(jfk, bearing, nmdistance2ll(jfk, lhr))
+	if abs(p[0]-lhr[0]) > 1e-3 || abs(p[1]-lhr[1]) > 1e-3 {
+		t.Errorf("got destination %v, expected %v", p, lhr)
+	}
+
+	// Across the antimeridian
+	if p := greatCircleDestination(Point2LL{179.9, 0}, 90, 12); abs(p[0]+179.9) > 1e-3 {
+		t.Errorf("got destination %v, expected longitude -179.9", p)
+	}
+
+	// The spherical and ellipsoidal distances should agree to within
+	// 0.5%.
+	if nm, _ := ellipsoidalInverse(jfk, lhr); abs(nm-nmdistance2ll(jfk, lhr)) > 0.005*nm {
+		t.Errorf("ellipsoidal %f nm, great circle %f nm", nm, nmdistance2ll(jfk, lhr))
+	}
+
+	// For nearby points, headingp2ll uses the flat-earth approximation,
+	// which should be close to the great circle bearing.
+	a, b := Point2LL{-73.7789, 40.6398}, Point2LL{-73.4, 40.9}
+	if h := headingp2ll(a, b, 0); headingDifference(h, greatCircleBearing(a, b)) > 0.25 {
+		t.Errorf("flat earth heading %f, great circle %f", h, greatCircleBearing(a, b))
+	}
+}
+
+func TestLocalNmScale(t *testing.T) {
+	// Near Anchorage, where the scale differs greatly from that at lower
+	// latitudes.
+	a, b := Point2LL{-150, 61.2}, Point2LL{-149.8, 61.25}
+	nmPerLongitude := nmPerLongitudeAt(a[1])
+	d := distance2f(ll2nm(a, nmPerLongitude), ll2nm(b, nmPerLongitude))
+	if abs(d-nmdistance2ll(a, b)) > 0.02 {
+		t.Errorf("got %f nm, expected %f", d, nmdistance2ll(a, b))
+	}
+	if l := nmlength2ll(sub2ll(b, a), nmPerLongitude); abs(l-d) > 1e-3 {
+		t.Errorf("got length %f nm, expected %f", l, d)
+	}
+	if p := nm2ll(ll2nm(b, nmPerLongitude), nmPerLongitude); abs(p[0]-b[0]) > 1e-4 || abs(p[1]-b[1]) > 1e-4 {
+		t.Errorf("got %v, expected %v", p, b)
+	}
+}
@@ -5,10 +5,13 @@ go 1.18
 require (
 	github.com/go-gl/gl v0.0.0-20211210172815-726fda9656d6
 	github.com/go-gl/glfw v0.0.0-20191125211704-12ad95a8df72
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b
 	github.com/klauspost/compress v1.15.9
 	github.com/mmp/IconFontCppHeaders v0.0.0-20220907145128-86cc7607b455
 	github.com/mmp/earcut-go v0.0.0-20220810122213-9ac08159e8b5
 	github.com/mmp/imgui-go/v4 v4.0.0-20220911181801-968a517f674f
+	github.com/mmp/sct2 v0.0.0-20230307173011-c164475d0814
+	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
 	github.com/veandco/go-sdl2 v0.5.0-alpha.3.0.20220913133553-3c4862273074
 	golang.org/x/exp v0.0.0-20221208152030-732eee02a75a
@@ -16,10 +19,7 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b // indirect
 	github.com/inkyblackness/imgui-go/v4 v4.5.0 // indirect
-	github.com/mmp/sct2 v0.0.0-20230307173011-c164475d0814 // indirect
-	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
 	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
 	golang.org/x/sys v0.1.0 // indirect
 )
@@ -37,6 +37,9 @@ var (
 	database       *StaticDatabase
 	server         ATCServer
 	eventStream    *EventStream
+	movements      *MovementDetector
+	runwayTracker  *RunwayTracker
+	trafficStats   *TrafficStatistics
 	lg             *Logger
 
 	//go:embed resources/version.txt
@@ -140,6 +143,9 @@ func main() {
 	fontsInit(renderer)
 
 	database = <-dbChan
+	movements = NewMovementDetector()
+	runwayTracker = NewRunwayTracker()
+	trafficStats = NewTrafficStatistics()
 
 	wmInit()
 
@@ -174,6 +180,9 @@ func main() {
 		positionConfig.SendUpdates()
 		server.GetUpdates()
 		positionConfig.Update()
+		movements.Update(eventStream)
+		runwayTracker.Update(eventStream)
+		trafficStats.Update(eventStream)
 		audioProcessEvents(eventStream)
 
 		platform.NewFrame()
@@ -0,0 +1,398 @@
+// messages.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// MessagesPane
+
+// MessagesPane shows text messages grouped into conversations: private
+// messages with each other party, inter-ATC messages, and messages on
+// each set of frequencies. Replies can be sent to the selected
+// conversation and the message history can be searched.
+type MessagesPane struct {
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	// If set, all messages are also written to a log file for the
+	// session.
+	LogMessages bool
+
+	conversations map[string]*messageConversation
+	selected      string
+
+	search       string
+	searchCursor int
+	reply        string
+	replyCursor  int
+	// Which of the text fields has keyboard focus
+	activeField int
+	status      string
+
+	logFile *os.File
+
+	eventsId EventSubscriberId
+	sb       *ScrollBar
+	cb       CommandBuffer
+}
+
+const (
+	messagesFieldNone = iota
+	messagesFieldSearch
+	messagesFieldReply
+)
+
+type messageConversation struct {
+	name string
+	// Template for replies; nil if replies aren't possible.
+	reply        *TextMessage
+	messages     []loggedMessage
+	unread       int
+	lastActivity time.Time
+}
+
+type loggedMessage struct {
+	time     time.Time
+	sender   string
+	contents string
+}
+
+// conversationFor returns the name of the conversation that the message
+// belongs to and a template message for replies to it. For messages that
+// we sent, sent should be true.
+func conversationFor(m *TextMessage, sent bool) (string, *TextMessage) {
+	switch m.messageType {
+	case TextPrivate:
+		other := Select(sent, m.recipient, m.sender)
+		return other, &TextMessage{messageType: TextPrivate, recipient: other}
+
+	case TextATC:
+		return "ATC", &TextMessage{messageType: TextATC}
+
+	case TextFrequency:
+		freqs := MapSlice(m.frequencies, func(f Frequency) string { return f.String() })
+		return strings.Join(freqs, ","), &TextMessage{messageType: TextFrequency,
+			frequencies: DuplicateSlice(m.frequencies)}
+
+	default:
+		// Broadcasts and wallops can't be replied to directly.
+		return m.messageType.String(), nil
+	}
+}
+
+func NewMessagesPane() *MessagesPane {
+	return &MessagesPane{LogMessages: true}
+}
+
+func (mp *MessagesPane) Duplicate(nameAsCopy bool) Pane {
+	return &MessagesPane{
+		FontIdentifier: mp.FontIdentifier,
+		font:           mp.font,
+		LogMessages:    mp.LogMessages,
+		conversations:  make(map[string]*messageConversation),
+		eventsId:       eventStream.Subscribe(),
+		sb:             NewScrollBar(4, true),
+	}
+}
+
+func (mp *MessagesPane) Activate() {
+	if mp.font = GetFont(mp.FontIdentifier); mp.font == nil {
+		mp.font = GetDefaultFont()
+		mp.FontIdentifier = mp.font.id
+	}
+	if mp.conversations == nil {
+		mp.conversations = make(map[string]*messageConversation)
+	}
+	if mp.sb == nil {
+		mp.sb = NewScrollBar(4, true)
+	}
+	mp.eventsId = eventStream.Subscribe()
+}
+
+func (mp *MessagesPane) Deactivate() {
+	eventStream.Unsubscribe(mp.eventsId)
+	mp.eventsId = InvalidEventSubscriberId
+	if mp.logFile != nil {
+		mp.logFile.Close()
+		mp.logFile = nil
+	}
+}
+
+func (mp *MessagesPane) CanTakeKeyboardFocus() bool { return true }
+
+func (mp *MessagesPane) Name() string {
+	n := "Messages"
+	unread := 0
+	for _, c := range mp.conversations {
+		unread += c.unread
+	}
+	if unread > 0 {
+		n += fmt.Sprintf(" (%d)", unread)
+	}
+	return n
+}
+
+func (mp *MessagesPane) DrawUI() {
+	if newFont, changed := DrawFontPicker(&mp.FontIdentifier, "Font"); changed {
+		mp.font = newFont
+	}
+	if imgui.Checkbox("Log messages to file", &mp.LogMessages) && !mp.LogMessages && mp.logFile != nil {
+		mp.logFile.Close()
+		mp.logFile = nil
+	}
+	if mp.logFile != nil {
+		imgui.Text("Log file: " + mp.logFile.Name())
+	}
+}
+
+// addMessage records a message in the appropriate conversation, creating
+// the conversation if needed.
+func (mp *MessagesPane) addMessage(m *TextMessage, sent bool) {
+	name, reply := conversationFor(m, sent)
+	c, ok := mp.conversations[name]
+	if !ok {
+		c = &messageConversation{name: name, reply: reply}
+		mp.conversations[name] = c
+	}
+
+	now := server.CurrentTime()
+	c.messages = append(c.messages, loggedMessage{time: now, sender: m.sender, contents: m.contents})
+	c.lastActivity = now
+	if !sent && name != mp.selected {
+		c.unread++
+	}
+	if mp.selected == "" {
+		mp.selected = name
+	}
+
+	mp.logMessage(name, m.sender, m.contents, now)
+}
+
+func (mp *MessagesPane) logMessage(conversation string, sender string, contents string, t time.Time) {
+	if !mp.LogMessages {
+		return
+	}
+
+	if mp.logFile == nil {
+		dir := path.Join(path.Dir(configFilePath()), "messages")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			lg.Errorf("%s: unable to create directory: %v", dir, err)
+			mp.LogMessages = false
+			return
+		}
+		fn := path.Join(dir, time.Now().Format("2006-01-02-150405")+".txt")
+		f, err := os.OpenFile(fn, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			lg.Errorf("%s: unable to create message log: %v", fn, err)
+			mp.LogMessages = false
+			return
+		}
+		mp.logFile = f
+	}
+
+	fmt.Fprintf(mp.logFile, "%s [%s] %s: %s\n", t.UTC().Format(time.RFC3339), conversation, sender, contents)
+}
+
+func (mp *MessagesPane) processEvents(es *EventStream) {
+	for _, event := range es.Get(mp.eventsId) {
+		if tm, ok := event.(*TextMessageEvent); ok {
+			mp.addMessage(tm.message, false)
+			globalConfig.AudioSettings.HandleEvent(AudioEventReceivedMessage)
+		}
+	}
+}
+
+func (mp *MessagesPane) sendReply() {
+	c, ok := mp.conversations[mp.selected]
+	if !ok || c.reply == nil {
+		mp.status = "Can't reply to this conversation"
+		return
+	}
+	if strings.TrimSpace(mp.reply) == "" {
+		return
+	}
+
+	tm := *c.reply
+	tm.sender = server.Callsign()
+	tm.contents = mp.reply
+	if err := server.SendTextMessage(tm); err != nil {
+		mp.status = err.Error()
+		return
+	}
+
+	mp.addMessage(&tm, true)
+	mp.reply, mp.replyCursor, mp.status = "", 0, ""
+}
+
+func (mp *MessagesPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	mp.processEvents(ctx.events)
+
+	if !ctx.haveFocus {
+		mp.activeField = messagesFieldNone
+	}
+
+	mp.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&mp.cb)
+
+	cs := ctx.cs
+	style := TextStyle{Font: mp.font, Color: cs.Text}
+	emphasizedStyle := TextStyle{Font: mp.font, Color: cs.TextHighlight}
+	disabledStyle := TextStyle{Font: mp.font, Color: cs.TextDisabled}
+	errorStyle := TextStyle{Font: mp.font, Color: cs.TextError}
+	cursorStyle := TextStyle{Font: mp.font, Color: cs.Background,
+		DrawBackground: true, BackgroundColor: cs.Text}
+
+	lineHeight := float32(mp.font.size)
+	sz2 := lineHeight / 2
+	width, height := ctx.paneExtent.Width(), ctx.paneExtent.Height()
+	charWidth, _ := mp.font.BoundText("X", 0)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+
+	// Text fields: search at the top, reply at the bottom.
+	searchY, replyY := height-sz2, sz2+lineHeight
+	drawField := func(label string, s *string, cursor *int, field int, y float32, placeholder string) int {
+		p := td.AddText(label, [2]float32{sz2, y}, emphasizedStyle)
+		if mp.activeField == field {
+			exit, _ := uiDrawTextEdit(s, cursor, ctx.keyboard, p, style, cursorStyle, &mp.cb)
+			return exit
+		} else if *s == "" {
+			td.AddText(placeholder, p, disabledStyle)
+		} else {
+			td.AddText(*s, p, style)
+		}
+		return TextEditReturnNone
+	}
+	drawField("Search: ", &mp.search, &mp.searchCursor, messagesFieldSearch, searchY, "(click to search)")
+
+	replyPlaceholder := "(click to reply)"
+	if c, ok := mp.conversations[mp.selected]; !ok || c.reply == nil {
+		replyPlaceholder = ""
+	}
+	if drawField("Reply: ", &mp.reply, &mp.replyCursor, messagesFieldReply, replyY,
+		replyPlaceholder) == TextEditReturnEnter {
+		mp.sendReply()
+	}
+	if mp.status != "" {
+		td.AddText(mp.status, [2]float32{sz2, replyY + lineHeight}, errorStyle)
+	}
+
+	top, bottom := searchY-1.5*lineHeight, replyY+Select(mp.status != "", 2*lineHeight, lineHeight)
+	ld.AddLine([2]float32{0, top + sz2}, [2]float32{width, top + sz2}, cs.UIControl)
+	ld.AddLine([2]float32{0, bottom}, [2]float32{width, bottom}, cs.UIControl)
+
+	// Conversation list, most recently active first.
+	listWidth := float32(18 * charWidth)
+	ld.AddLine([2]float32{listWidth, top + sz2}, [2]float32{listWidth, bottom}, cs.UIControl)
+
+	_, convs := FlattenMap(mp.conversations)
+	sort.Slice(convs, func(i, j int) bool { return convs[i].lastActivity.After(convs[j].lastActivity) })
+	for i, c := range convs {
+		y := top - float32(i)*lineHeight
+		if y-lineHeight < bottom {
+			break
+		}
+		label := c.name
+		if c.unread > 0 {
+			label += fmt.Sprintf(" (%d)", c.unread)
+		}
+		s := Select(c.unread > 0, emphasizedStyle, style)
+		if c.name == mp.selected {
+			s.DrawBackground = true
+			s.BackgroundColor = cs.AltBackground
+		}
+		td.AddText(label, [2]float32{sz2, y}, s)
+	}
+
+	// Messages: either the selected conversation or, when searching, all
+	// matching messages.
+	type messageLine struct {
+		text  string
+		style TextStyle
+	}
+	var lines []messageLine
+	ncols := int((width-listWidth-float32(mp.sb.Width())-lineHeight)/float32(charWidth)) - 1
+	addMessage := func(prefix string, m loggedMessage) {
+		header := "[" + m.time.UTC().Format("15:04Z") + "] " + prefix + m.sender + ": "
+		text, _ := wrapText(header+m.contents, max(ncols, 10), 4, true)
+		for i, line := range strings.Split(text, "\n") {
+			lines = append(lines, messageLine{text: line,
+				style: Select(i == 0 && m.sender == server.Callsign(), emphasizedStyle, style)})
+		}
+	}
+	if mp.search != "" {
+		query := strings.ToLower(mp.search)
+		type match struct {
+			conversation string
+			message      loggedMessage
+		}
+		var matches []match
+		for _, c := range convs {
+			for _, m := range c.messages {
+				if strings.Contains(strings.ToLower(m.contents), query) ||
+					strings.Contains(strings.ToLower(m.sender), query) {
+					matches = append(matches, match{conversation: c.name, message: m})
+				}
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].message.time.Before(matches[j].message.time)
+		})
+		for _, m := range matches {
+			addMessage(m.conversation+" "+FontAwesomeIconArrowRight+" ", m.message)
+		}
+	} else if c, ok := mp.conversations[mp.selected]; ok {
+		c.unread = 0
+		for _, m := range c.messages {
+			addMessage("", m)
+		}
+	}
+
+	// Draw from the bottom up so that the most recent messages are
+	// visible.
+	nVisible := int((top - bottom) / lineHeight)
+	mp.sb.Update(len(lines), nVisible, ctx)
+	offset := mp.sb.Offset()
+	for i := 0; i < nVisible && len(lines)-1-offset-i >= 0; i++ {
+		line := lines[len(lines)-1-offset-i]
+		td.AddText(line.text, [2]float32{listWidth + sz2, bottom + float32(i+1)*lineHeight}, line.style)
+	}
+
+	// Mouse handling
+	if ctx.mouse != nil && ctx.mouse.Clicked[MouseButtonPrimary] {
+		p := ctx.mouse.Pos
+		if p[1] > top+sz2 {
+			mp.activeField = messagesFieldSearch
+			wmTakeKeyboardFocus(mp, true)
+		} else if p[1] < bottom {
+			mp.activeField = messagesFieldReply
+			wmTakeKeyboardFocus(mp, true)
+		} else if p[0] < listWidth {
+			if i := int((top - p[1]) / lineHeight); i >= 0 && i < len(convs) {
+				mp.selected = convs[i].name
+				mp.search = ""
+				mp.status = ""
+			}
+		}
+	}
+
+	ld.GenerateCommands(&mp.cb)
+	td.GenerateCommands(&mp.cb)
+	mp.sb.Draw(ctx, &mp.cb)
+	cb.Call(mp.cb)
+}
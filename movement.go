@@ -0,0 +1,253 @@
+// movement.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// MovementDetector
+
+// MovementDetector watches aircraft updates and posts events to the event
+// stream when aircraft take off, land, go around, or enter a hold.
+// Departures and arrivals are detected at the airports in an aircraft's
+// flight plan using its height above the airport, its climb or descent
+// rate, and its position with respect to the airport's runways.
+type MovementDetector struct {
+	aircraft map[string]*aircraftMovementState // callsign -> state
+	eventsId EventSubscriberId
+}
+
+type aircraftMovementState struct {
+	// Airport from the flight plan that the aircraft is near and whether
+	// it was on the ground there at the last update.
+	airport  string
+	onGround bool
+
+	// Runway that the aircraft was last seen lined up on for departure.
+	departureRunway string
+
+	// Runway that the aircraft is on final for, if any, and the lowest
+	// height above the airport it has been seen at on final.
+	finalRunway  string
+	finalMinimum int
+
+	lastAltitude int
+	lastHeading  float32
+	lastTime     time.Time
+
+	// Recent heading changes, used to detect holding.
+	turns   []headingChange
+	holding bool
+}
+
+type headingChange struct {
+	time  time.Time
+	delta float32
+}
+
+const (
+	// Aircraft within this distance (nm) of an airport are considered for
+	// departures and arrivals there.
+	movementAirportRadius = 10
+	// Holding is detected when an aircraft turns through at least
+	// holdingTurn degrees in the same direction over holdingWindow: about
+	// two circuits of a standard holding pattern, so that a single 360
+	// for spacing isn't taken to be a hold.
+	holdingTurn   = 690
+	holdingWindow = 12 * time.Minute
+	// Holds are reported at the flight plan's departure or arrival
+	// airport if the aircraft is within this distance (nm) of it.
+	holdingAirportRadius = 40
+)
+
+func NewMovementDetector() *MovementDetector {
+	return &MovementDetector{
+		aircraft: make(map[string]*aircraftMovementState),
+		eventsId: eventStream.Subscribe(),
+	}
+}
+
+// Update should be called once per frame, after the server has provided
+// its updates; any movements detected are posted to the event stream.
+func (md *MovementDetector) Update(es *EventStream) {
+	for _, event := range es.Get(md.eventsId) {
+		switch v := event.(type) {
+		case *AddedAircraftEvent:
+			md.update(v.ac)
+
+		case *ModifiedAircraftEvent:
+			md.update(v.ac)
+
+		case *RemovedAircraftEvent:
+			delete(md.aircraft, v.ac.Callsign)
+
+		case *ClosedServerConnectionEvent:
+			md.aircraft = make(map[string]*aircraftMovementState)
+		}
+	}
+}
+
+// nearbyAirport returns the airport from the aircraft's flight plan that
+// it is close to, if any.
+func nearbyAirport(ac *Aircraft) (Airport, bool) {
+	if fp := ac.FlightPlan; fp != nil {
+		for _, icao := range [2]string{fp.DepartureAirport, fp.ArrivalAirport} {
+			if ap, ok := database.airports[icao]; ok &&
+				nmdistance2ll(ap.Location, ac.Position()) < movementAirportRadius {
+				return ap, true
+			}
+		}
+	}
+	return Airport{}, false
+}
+
+func (md *MovementDetector) update(ac *Aircraft) {
+	if !ac.HaveTrack() {
+		return
+	}
+
+	now := server.CurrentTime()
+	ap, nearAirport := nearbyAirport(ac)
+	onGround := nearAirport && (ac.Groundspeed() < 40 || abs(ac.Altitude()-ap.Elevation) < 100)
+
+	s, ok := md.aircraft[ac.Callsign]
+	if !ok {
+		// First time we've seen it; just record its current state.
+		md.aircraft[ac.Callsign] = &aircraftMovementState{
+			airport:      ap.Id,
+			onGround:     onGround,
+			lastAltitude: ac.Altitude(),
+			lastHeading:  ac.Heading(),
+			lastTime:     now,
+		}
+		return
+	}
+
+	// Climb rate in feet per minute since the last update. (We don't use
+	// Aircraft AltitudeChange() since interpolated tracks may be in the
+	// mix.)
+	climbRate := 0
+	if dt := now.Sub(s.lastTime).Minutes(); dt > 0 {
+		climbRate = int(float64(ac.Altitude()-s.lastAltitude) / dt)
+	}
+
+	if !nearAirport {
+		s.airport, s.onGround = "", false
+		s.departureRunway, s.finalRunway = "", ""
+	} else if ap.Id != s.airport {
+		// Newly arrived in the vicinity of an airport.
+		s.airport, s.onGround = ap.Id, onGround
+		s.departureRunway, s.finalRunway = "", ""
+	} else {
+		agl := ac.Altitude() - ap.Elevation
+
+		if onGround {
+			if ac.Groundspeed() >= 40 {
+				// Rolling on a runway; remember which one in case we only
+				// see it airborne once it is past the departure end.
+				if rwy := aircraftRunway(ac, ap.Id, true); rwy != nil {
+					s.departureRunway = rwy.Number
+				}
+			}
+
+			if !s.onGround {
+				// Touchdown.
+				rwy := s.finalRunway
+				if rwy == "" {
+					if r := aircraftRunway(ac, ap.Id, false); r != nil {
+						rwy = r.Number
+					}
+				}
+				eventStream.Post(&LandedEvent{ac: ac, airport: ap.Id, runway: rwy, time: now})
+				s.finalRunway = ""
+			}
+		} else {
+			if s.onGround {
+				// Liftoff.
+				rwy := s.departureRunway
+				if r := aircraftRunway(ac, ap.Id, true); r != nil {
+					rwy = r.Number
+				}
+				eventStream.Post(&DepartedEvent{ac: ac, airport: ap.Id, runway: rwy, time: now})
+				s.departureRunway = ""
+			} else if agl < 2000 {
+				if rwy := aircraftRunway(ac, ap.Id, false); rwy != nil && climbRate <= 0 {
+					if s.finalRunway != rwy.Number {
+						s.finalRunway, s.finalMinimum = rwy.Number, agl
+					}
+					s.finalMinimum = min(s.finalMinimum, agl)
+				} else if s.finalRunway != "" && climbRate > 300 && s.finalMinimum < 1000 &&
+					agl > s.finalMinimum+200 {
+					// Established on final, low, and now climbing away.
+					eventStream.Post(&GoAroundEvent{ac: ac, airport: ap.Id, runway: s.finalRunway, time: now})
+					s.finalRunway = ""
+				}
+			} else {
+				s.finalRunway = ""
+			}
+		}
+		s.onGround = onGround
+	}
+
+	md.checkHolding(ac, s, onGround, now)
+
+	s.lastAltitude = ac.Altitude()
+	s.lastHeading = ac.Heading()
+	s.lastTime = now
+}
+
+// checkHolding accumulates the aircraft's recent heading changes and posts
+// a HoldingEvent when it has turned through two full circles.
+func (md *MovementDetector) checkHolding(ac *Aircraft, s *aircraftMovementState, onGround bool, now time.Time) {
+	if onGround || ac.Groundspeed() < 60 {
+		s.turns = nil
+		s.holding = false
+		return
+	}
+
+	// Signed heading change in [-180,180]
+	delta := ac.Heading() - s.lastHeading
+	if delta > 180 {
+		delta -= 360
+	} else if delta < -180 {
+		delta += 360
+	}
+	s.turns = append(s.turns, headingChange{time: now, delta: delta})
+	s.turns = FilterSlice(s.turns, func(hc headingChange) bool { return now.Sub(hc.time) < holdingWindow })
+
+	var total float32
+	for _, hc := range s.turns {
+		total += hc.delta
+	}
+
+	if abs(total) >= holdingTurn {
+		if !s.holding {
+			s.holding = true
+			eventStream.Post(&HoldingEvent{ac: ac, airport: holdingAirport(ac), time: now})
+		}
+	} else if abs(total) < 90 {
+		s.holding = false
+	}
+}
+
+// holdingAirport returns the closer of the departure and arrival airports
+// in the aircraft's flight plan if it is within holdingAirportRadius of
+// it; otherwise, the aircraft is presumably holding en route and an empty
+// string is returned.
+func holdingAirport(ac *Aircraft) string {
+	airport, dist := "", float32(holdingAirportRadius)
+	if fp := ac.FlightPlan; fp != nil {
+		for _, icao := range [2]string{fp.DepartureAirport, fp.ArrivalAirport} {
+			if ap, ok := database.airports[icao]; ok {
+				if d := nmdistance2ll(ap.Location, ac.Position()); d < dist {
+					airport, dist = icao, d
+				}
+			}
+		}
+	}
+	return airport
+}
@@ -0,0 +1,227 @@
+// msaw.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file implements minimum safe altitude warnings (MSAW): IFR
+// aircraft that are below, or are predicted to descend below, the minimum
+// vectoring altitude (MVA) are flagged.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// How far ahead the aircraft's path is checked.
+	msawLookaheadMinutes = 1
+	// Aircraft within this many nm of their departure or arrival airport
+	// aren't checked, since they are expected to be below the MVA there.
+	msawAirportExclusionRadius = 5
+)
+
+// MVASector is a single minimum vectoring altitude sector.
+type MVASector struct {
+	Altitude int
+	// The outer boundary followed by any holes.
+	Polygon [][]Point2LL
+
+	bounds Extent2D
+}
+
+func NewMVASector(altitude int, polygon [][]Point2LL) MVASector {
+	var pts [][2]float32
+	for _, p := range polygon[0] {
+		pts = append(pts, p)
+	}
+	return MVASector{Altitude: altitude, Polygon: polygon, bounds: Extent2DFromPoints(pts)}
+}
+
+// Inside returns true if the point is inside the sector's outer boundary
+// and not inside any of its holes.
+func (s *MVASector) Inside(p Point2LL) bool {
+	if !s.bounds.Inside(p) || !PointInPolygon(p, s.Polygon[0]) {
+		return false
+	}
+	for _, hole := range s.Polygon[1:] {
+		if PointInPolygon(p, hole) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadMVAFile loads MVA sectors from either an FAA MVA AIXM file or a
+// GeoJSON file.
+func LoadMVAFile(filename string) ([]MVASector, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(contents), []byte("<")) {
+		return ParseAIXMMVA(bytes.NewReader(contents))
+	}
+	return ParseGeoJSONMVA(contents)
+}
+
+// LoadMVAFiles replaces the database's MVA sectors with the ones in the
+// given files, reporting errors via the log.
+func (db *StaticDatabase) LoadMVAFiles(filenames []string) {
+	db.mva = nil
+	for _, fn := range filenames {
+		lg.Printf("%s: loading MVA file", fn)
+		sectors, err := LoadMVAFile(fn)
+		if err != nil {
+			lg.Errorf("%s: %v", fn, err)
+		}
+		db.mva = append(db.mva, sectors...)
+	}
+}
+
+// ParseAIXMMVA parses the airspace volumes of an FAA MVA AIXM file. The
+// MVA is given by each volume's minimumLimit or, if it doesn't have one,
+// its lowerLimit.
+func ParseAIXMMVA(r io.Reader) ([]MVASector, error) {
+	airspaces, err := parseAIXMAirspaces(r)
+
+	var sectors []MVASector
+	for _, a := range airspaces {
+		for _, v := range a.volumes {
+			alt := Select(v.minimum >= 0, v.minimum, v.floor)
+			sectors = append(sectors, NewMVASector(alt, append([][]Point2LL{v.exterior}, v.interiors...)))
+		}
+	}
+	return sectors, err
+}
+
+// ParseGeoJSONMVA parses a GeoJSON file where each feature is a polygon
+// with an altitude given by one of its "mva", "msa", "altitude",
+// "minalt", or "alt" properties (case-insensitive).
+func ParseGeoJSONMVA(b []byte) ([]MVASector, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	features := obj.Features
+	if obj.Type == "Feature" {
+		features = []geoJSONObject{obj}
+	} else if obj.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("%s: expected Feature or FeatureCollection", obj.Type)
+	}
+
+	var sectors []MVASector
+	var errs []string
+	for i, f := range features {
+		alt, ok := -1, false
+		for k, v := range f.Properties {
+			switch strings.ToLower(k) {
+			case "mva", "msa", "altitude", "minalt", "alt":
+				switch a := v.(type) {
+				case float64:
+					alt, ok = int(a), true
+				case string:
+					var err error
+					alt, err = parseSUAAltitude(a)
+					ok = err == nil
+				}
+			}
+		}
+		if !ok {
+			errs = append(errs, fmt.Sprintf("feature %d: no altitude property found", i))
+			continue
+		}
+		if f.Geometry == nil {
+			continue
+		}
+
+		g := &MapOverlayGeometry{}
+		if err := g.addGeoJSON(f.Geometry, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("feature %d: %v", i, err))
+			continue
+		}
+		for _, poly := range g.Polygons {
+			sectors = append(sectors, NewMVASector(alt, poly))
+		}
+	}
+
+	if len(errs) > 0 {
+		return sectors, errors.New(strings.Join(errs, "\n"))
+	}
+	return sectors, nil
+}
+
+// minimumAltitude returns the highest altitude of the sectors that
+// include p.
+func minimumAltitude(sectors []MVASector, p Point2LL) (int, bool) {
+	alt, found := 0, false
+	for i := range sectors {
+		if sectors[i].Inside(p) {
+			alt, found = max(alt, sectors[i].Altitude), true
+		}
+	}
+	return alt, found
+}
+
+// MinimumAltitude returns the minimum vectoring altitude at the given
+// point, if it is known.
+func (db *StaticDatabase) MinimumAltitude(p Point2LL) (int, bool) {
+	return minimumAltitude(db.mva, p)
+}
+
+// MSAWAlert describes an aircraft that is or will be too low.
+type MSAWAlert struct {
+	// The MVA where it is too low.
+	Minimum int
+	// Minutes until it is too low; 0 if it already is.
+	Minutes float32
+}
+
+// msawCheck returns an alert if an aircraft at p with the given altitude
+// will be below the MVA in the next msawLookaheadMinutes, assuming that
+// it continues with the per-minute velocity v and, if it's descending,
+// the given rate in feet per minute.
+func msawCheck(sectors []MVASector, p, v Point2LL, altitude, rate int) (MSAWAlert, bool) {
+	// Check every 15 seconds.
+	const steps = 4 * msawLookaheadMinutes
+	for i := 0; i <= steps; i++ {
+		t := float32(i) / 4
+		pt := add2ll(p, scale2ll(v, t))
+		alt := altitude + int(float32(min(0, rate))*t)
+		if mva, ok := minimumAltitude(sectors, pt); ok && alt < mva {
+			return MSAWAlert{Minimum: mva, Minutes: t}, true
+		}
+	}
+	return MSAWAlert{}, false
+}
+
+// MSAW checks the aircraft against the MVA sectors. Only airborne IFR
+// aircraft that are not near their departure or arrival airport are
+// checked.
+func (db *StaticDatabase) MSAW(ac *Aircraft) (MSAWAlert, bool) {
+	if len(db.mva) == 0 || !ac.HaveTrack() || ac.OnGround() || ac.FlightPlan == nil || ac.FlightPlan.Rules != IFR {
+		return MSAWAlert{}, false
+	}
+	for _, airport := range []string{ac.FlightPlan.DepartureAirport, ac.FlightPlan.ArrivalAirport} {
+		if ap, ok := db.airports[airport]; ok && nmdistance2ll(ap.Location, ac.Position()) < msawAirportExclusionRadius {
+			return MSAWAlert{}, false
+		}
+	}
+	return msawCheck(db.mva, ac.Position(), ac.HeadingVector(), ac.Altitude(), ac.AltitudeChange())
+}
+
+// MSAW returns the result of StaticDatabase.MSAW for the aircraft. It is
+// cached until the aircraft's position is updated.
+func (a *Aircraft) MSAW() (MSAWAlert, bool) {
+	if !a.msawTrackTime.Equal(a.Tracks[0].Time) || a.msawTrackTime.IsZero() {
+		a.msaw, a.msawActive = database.MSAW(a)
+		a.msawTrackTime = a.Tracks[0].Time
+	}
+	return a.msaw, a.msawActive
+}
@@ -0,0 +1,153 @@
+// msaw_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMVAGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": { "MVA": 3000 },
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[-75, 40], [-74, 40], [-74, 41], [-75, 41], [-75, 40]],
+          [[-74.6, 40.4], [-74.4, 40.4], [-74.4, 40.6], [-74.6, 40.6], [-74.6, 40.4]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": { "altitude": "6000" },
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [[[-74.6, 40.4], [-74.4, 40.4], [-74.4, 40.6], [-74.6, 40.6], [-74.6, 40.4]]]
+      }
+    }
+  ]
+}`
+
+func TestParseGeoJSONMVA(t *testing.T) {
+	sectors, err := ParseGeoJSONMVA([]byte(testMVAGeoJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sectors) != 2 || sectors[0].Altitude != 3000 || len(sectors[0].Polygon) != 2 || sectors[1].Altitude != 6000 {
+		t.Fatalf("unexpected sectors: %+v", sectors)
+	}
+
+	for _, test := range []struct {
+		p   Point2LL
+		alt int
+		ok  bool
+	}{
+		{Point2LL{-74.8, 40.2}, 3000, true},
+		{Point2LL{-74.5, 40.5}, 6000, true}, // in the hole
+		{Point2LL{-76, 40.5}, 0, false},
+	} {
+		if alt, ok := minimumAltitude(sectors, test.p); alt != test.alt || ok != test.ok {
+			t.Errorf("%v: got %d %v, expected %d %v", test.p, alt, ok, test.alt, test.ok)
+		}
+	}
+
+	if _, err := ParseGeoJSONMVA([]byte(`{"type": "Feature", "properties": {}, "geometry": null}`)); err == nil {
+		t.Errorf("expected error for feature without an altitude")
+	}
+}
+
+func TestParseAIXMMVA(t *testing.T) {
+	const aixm = `<aixm:AIXMBasicMessage xmlns:aixm="http://www.aixm.aero/schema/5.1" xmlns:gml="http://www.opengis.net/gml/3.2">
+  <aixm:Airspace>
+    <aixm:AirspaceVolume>
+      <aixm:upperLimit uom="FL">180</aixm:upperLimit>
+      <aixm:minimumLimit uom="FT">2500</aixm:minimumLimit>
+      <aixm:horizontalProjection>
+        <gml:PolygonPatch>
+          <gml:exterior><gml:LinearRing><gml:posList>40 -75 40 -74 41 -74 41 -75 40 -75</gml:posList></gml:LinearRing></gml:exterior>
+          <gml:interior><gml:LinearRing><gml:posList>40.4 -74.6 40.4 -74.4 40.6 -74.4 40.6 -74.6 40.4 -74.6</gml:posList></gml:LinearRing></gml:interior>
+        </gml:PolygonPatch>
+      </aixm:horizontalProjection>
+    </aixm:AirspaceVolume>
+  </aixm:Airspace>
+</aixm:AIXMBasicMessage>`
+
+	sectors, err := ParseAIXMMVA(strings.NewReader(aixm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sectors) != 1 || sectors[0].Altitude != 2500 || len(sectors[0].Polygon) != 2 ||
+		len(sectors[0].Polygon[0]) != 5 || sectors[0].Polygon[0][1] != (Point2LL{-74, 40}) {
+		t.Fatalf("unexpected sectors: %+v", sectors)
+	}
+	if !sectors[0].Inside(Point2LL{-74.8, 40.2}) || sectors[0].Inside(Point2LL{-74.5, 40.5}) {
+		t.Errorf("hole not handled")
+	}
+}
+
+func TestParseAIXMMVAArc(t *testing.T) {
+	const aixm = `<aixm:AIXMBasicMessage xmlns:aixm="http://www.aixm.aero/schema/5.1" xmlns:gml="http://www.opengis.net/gml/3.2">
+  <aixm:Airspace>
+    <aixm:AirspaceVolume>
+      <aixm:minimumLimit uom="FT">3000</aixm:minimumLimit>
+      <aixm:horizontalProjection><gml:PolygonPatch><gml:exterior>` + aixmArcRing + `</gml:exterior></gml:PolygonPatch></aixm:horizontalProjection>
+    </aixm:AirspaceVolume>
+  </aixm:Airspace>
+</aixm:AIXMBasicMessage>`
+
+	sectors, err := ParseAIXMMVA(strings.NewReader(aixm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sectors) != 1 || sectors[0].Altitude != 3000 {
+		t.Fatalf("unexpected sectors: %+v", sectors)
+	}
+	if Find(sectors[0].Polygon[0], Point2LL{-74, 40.5}) != -1 {
+		t.Errorf("arc center included in the boundary")
+	}
+	// Inside the arc but outside of the straight line between its
+	// endpoints, and outside the arc.
+	if alt, ok := minimumAltitude(sectors, Point2LL{-73.6, 40.5}); !ok || alt != 3000 {
+		t.Errorf("expected MVA 3000 inside the arc, got %d %v", alt, ok)
+	}
+	if _, ok := minimumAltitude(sectors, Point2LL{-73.7, 40.02}); ok {
+		t.Errorf("unexpected MVA outside the arc")
+	}
+}
+
+func TestMSAWCheck(t *testing.T) {
+	sectors, err := ParseGeoJSONMVA([]byte(testMVAGeoJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Heading east at about 6nm/minute.
+	v := Point2LL{0.13, 0}
+	for i, test := range []struct {
+		p         Point2LL
+		alt, rate int
+		alert     bool
+		minimum   int
+		minutes   float32
+	}{
+		{Point2LL{-74.9, 40.2}, 4000, 0, false, 0, 0},
+		{Point2LL{-74.9, 40.2}, 2500, 0, true, 3000, 0},
+		// Descending through 3000 in 30 seconds
+		{Point2LL{-74.9, 40.2}, 3500, -2000, true, 3000, 0.5},
+		// Level at 4000, but about to fly into the 6000 sector.
+		{Point2LL{-74.65, 40.5}, 4000, 0, true, 6000, 0.5},
+		// Outside of all of the sectors
+		{Point2LL{-80, 40.2}, 1000, 0, false, 0, 0},
+	} {
+		alert, ok := msawCheck(sectors, test.p, v, test.alt, test.rate)
+		if ok != test.alert || alert.Minimum != test.minimum || alert.Minutes != test.minutes {
+			t.Errorf("%d: got %+v %v, expected %d %f %v", i, alert, ok, test.minimum, test.minutes, test.alert)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+// nasr.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file handles loading the FAA's NASR 28-day subscription data at
+// runtime so that the navaids, fixes, airports, preferred routes, and
+// airways needn't be limited to the cycle that was embedded in the
+// binary. See
+// https://www.faa.gov/air_traffic/flight_info/aeronav/aero_data/NASR_Subscription/.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// NASRData stores the parsed contents of a NASR subscription.
+type NASRData struct {
+	// The zip file or directory the data came from and its modification
+	// time, so that we can tell if the cache is stale.
+	Source        string
+	SourceModTime time.Time
+	Effective     time.Time
+
+	Navaids  map[string]Navaid
+	Fixes    map[string]Fix
+	Airports map[string]Airport
+	PRD      []PRDEntry
+	Airways  map[string][]string
+}
+
+// Increment this when NASRData changes so that old caches are ignored.
+const nasrCacheVersion = 1
+
+// AIRACCycle returns the identifier (e.g., "2310") of the AIRAC cycle that
+// includes the given time as well as the times at which the cycle starts
+// and ends.
+func AIRACCycle(t time.Time) (id string, start, end time.Time) {
+	// Cycle 2301 started on January 26, 2023 and each one is 28 days long.
+	ref := time.Date(2023, time.January, 26, 0, 0, 0, 0, time.UTC)
+	const cycle = 28 * 24 * time.Hour
+	n := t.Sub(ref) / cycle
+	if t.Before(ref) && t.Sub(ref)%cycle != 0 {
+		n--
+	}
+	start = ref.Add(n * cycle)
+	// The first cycle of each year starts in its first 28 days.
+	id = fmt.Sprintf("%02d%02d", start.Year()%100, (start.YearDay()-1)/28+1)
+	return id, start, start.Add(cycle)
+}
+
+// nasrEffectiveDate returns the date given in the EFF_DATE field of the
+// first record of the NASR CSV file.
+func nasrEffectiveDate(raw string) time.Time {
+	cr := csv.NewReader(strings.NewReader(raw))
+	header, err := cr.Read()
+	if err != nil {
+		return time.Time{}
+	}
+	record, err := cr.Read()
+	if err != nil {
+		return time.Time{}
+	}
+	for i, h := range header {
+		if strings.TrimSpace(h) == "EFF_DATE" && i < len(record) {
+			t, _ := time.Parse("2006/01/02", record[i])
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// openNASR returns a file system for the CSV files of a NASR subscription,
+// which may be given by either a directory or a zip file. The top-level
+// subscription zip file stores the CSV files in a second zip file, which
+// is also handled. The returned function should be called when the
+// caller is done with the file system.
+func openNASR(filename string) (fs.FS, func(), error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, nil, err
+	} else if fi.IsDir() {
+		return os.DirFS(filename), func() {}, nil
+	}
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	closer := func() { zr.Close() }
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "_CSV.zip") {
+			r, err := f.Open()
+			if err != nil {
+				closer()
+				return nil, nil, err
+			}
+			b, err := io.ReadAll(r)
+			r.Close()
+			closer()
+			if err != nil {
+				return nil, nil, err
+			}
+			inner, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+			return inner, func() {}, err
+		}
+	}
+	return zr, closer, nil
+}
+
+// readNASRFile returns the contents of the named CSV file (e.g.,
+// "NAV_BASE.csv"), wherever it is in the file system.
+func readNASRFile(fsys fs.FS, name string) (string, error) {
+	var contents []byte
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && path.Base(p) == name && contents == nil {
+			contents, err = fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	} else if contents == nil {
+		return "", fmt.Errorf("%s: not found", name)
+	}
+	return string(contents), nil
+}
+
+// nasrTableFields gives the columns that are used from each of the NASR
+// tables.
+var nasrTableFields = map[string][]string{
+	"NAV_BASE.csv": navaidFields,
+	"FIX_BASE.csv": fixFields,
+	"APT_BASE.csv": {"ARPT_ID", "ICAO_ID", "ARPT_NAME", "LAT_DECIMAL", "LONG_DECIMAL", "ELEV"},
+	"PFR_BASE.csv": {"ORIGIN_ID", "DSTN_ID", "PFR_TYPE_CODE", "ROUTE_NO", "SPECIAL_AREA_DESCRIP",
+		"ALT_DESCRIP", "AIRCRAFT", "HOURS", "ROUTE_DIR_DESCRIP", "ROUTE_STRING"},
+	"AWY_BASE.csv": {"AWY_LOCATION", "AWY_ID", "AIRWAY_STRING"},
+}
+
+// checkNASRHeader returns an error if the header of the given CSV file
+// doesn't include all of the given columns. (mungeCSV only logs missing
+// columns, which would lead to out of bounds accesses in its callbacks.)
+func checkNASRHeader(name, raw string, fields []string) error {
+	header, err := csv.NewReader(strings.NewReader(raw)).Read()
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	header = MapSlice(header, strings.TrimSpace)
+
+	var missing []string
+	for _, f := range fields {
+		if Find(header, f) == -1 {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: missing columns %s", name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ParseNASR parses the NAV, FIX, APT, PFR, and AWY tables of a NASR
+// subscription. An error is returned if any of them is missing or doesn't
+// have the expected columns.
+func ParseNASR(fsys fs.FS) (*NASRData, error) {
+	files := make(map[string]string)
+	for _, name := range SortedMapKeys(nasrTableFields) {
+		var err error
+		if files[name], err = readNASRFile(fsys, name); err != nil {
+			return nil, err
+		}
+		if err := checkNASRHeader(name, files[name], nasrTableFields[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	nd := &NASRData{
+		Effective: nasrEffectiveDate(files["NAV_BASE.csv"]),
+		Navaids:   parseNavaids(files["NAV_BASE.csv"]),
+		Fixes:     parseFixes(files["FIX_BASE.csv"]),
+		Airports:  make(map[string]Airport),
+		Airways:   make(map[string][]string),
+	}
+
+	mungeCSV("APT_BASE.csv", files["APT_BASE.csv"], nasrTableFields["APT_BASE.csv"],
+		func(s []string) {
+			ap := Airport{Id: s[1], Name: s[2], Location: Point2LL{float32(atof(s[4])), float32(atof(s[3]))}}
+			if ap.Id == "" {
+				ap.Id = s[0]
+			}
+			if s[5] != "" {
+				ap.Elevation = int(atof(s[5]))
+			}
+			nd.Airports[ap.Id] = ap
+		})
+
+	mungeCSV("PFR_BASE.csv", files["PFR_BASE.csv"], nasrTableFields["PFR_BASE.csv"],
+		func(s []string) {
+			nd.PRD = append(nd.PRD, PRDEntry{
+				Depart:    s[0],
+				Arrive:    s[1],
+				Type:      s[2],
+				Seq:       s[3],
+				Area:      s[4],
+				Altitude:  s[5],
+				Aircraft:  s[6],
+				Hours:     [3]string{s[7]},
+				Direction: s[8],
+				Route:     s[9]})
+		})
+
+	mungeCSV("AWY_BASE.csv", files["AWY_BASE.csv"], nasrTableFields["AWY_BASE.csv"],
+		func(s []string) {
+			// Alaska and Hawaii have some airways with the same names as
+			// ones in the contiguous US; prefer the latter.
+			if _, ok := nd.Airways[s[1]]; !ok || s[0] == "C" {
+				nd.Airways[s[1]] = strings.Fields(s[2])
+			}
+		})
+
+	return nd, nil
+}
+
+func nasrCachePath() string {
+	return path.Join(path.Dir(configFilePath()), "nasr-cache.gob")
+}
+
+// readNASRCache returns the cached NASR data if it was parsed from the
+// given source and the source hasn't changed since then.
+func readNASRCache(source string, modTime time.Time) (*NASRData, error) {
+	f, err := os.Open(nasrCachePath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	var version int
+	if err := dec.Decode(&version); err != nil {
+		return nil, err
+	} else if version != nasrCacheVersion {
+		return nil, errors.New("cache version mismatch")
+	}
+	var nd NASRData
+	if err := dec.Decode(&nd); err != nil {
+		return nil, err
+	}
+	if nd.Source != source || !nd.SourceModTime.Equal(modTime) {
+		return nil, errors.New("cache is stale")
+	}
+	return &nd, nil
+}
+
+func writeNASRCache(nd *NASRData) error {
+	f, err := os.Create(nasrCachePath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	if err := enc.Encode(nasrCacheVersion); err != nil {
+		return err
+	}
+	return enc.Encode(nd)
+}
+
+// LoadNASR replaces the built-in FAA data with the NASR subscription
+// from the given zip file or directory, using the cached parsed data if
+// possible. If there is an error, the current data is left as is.
+func (db *StaticDatabase) LoadNASR(filename string) error {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	nd, err := readNASRCache(filename, fi.ModTime())
+	if err != nil {
+		lg.Printf("%s: parsing NASR data (%v)", filename, err)
+		start := time.Now()
+		fsys, closer, err := openNASR(filename)
+		if err != nil {
+			return err
+		}
+		nd, err = ParseNASR(fsys)
+		closer()
+		if err != nil {
+			return err
+		}
+		nd.Source, nd.SourceModTime = filename, fi.ModTime()
+		lg.Printf("%s: parsed NASR data in %v", filename, time.Since(start))
+
+		if err := writeNASRCache(nd); err != nil {
+			lg.Errorf("%s: unable to write NASR cache: %v", nasrCachePath(), err)
+		}
+	}
+
+	db.AddNASR(nd)
+	return nil
+}
+
+// AddNASR replaces the FAA navaids, fixes, preferred routes, and airways
+// with the ones in the given NASRData; its airports are merged into the
+// existing ones.
+func (db *StaticDatabase) AddNASR(nd *NASRData) {
+	db.FAA.navaids = nd.Navaids
+	db.FAA.fixes = nd.Fixes
+	db.FAA.airways = nd.Airways
+	db.FAA.prd = make(map[AirportPair][]PRDEntry)
+	for _, entry := range nd.PRD {
+		ap := AirportPair{entry.Depart, entry.Arrive}
+		db.FAA.prd[ap] = append(db.FAA.prd[ap], entry)
+	}
+	if db.airports == nil {
+		db.airports = make(map[string]Airport)
+	}
+	for id, ap := range nd.Airports {
+		db.airports[id] = ap
+	}
+	db.FAA.source = nd.Source
+	db.FAA.effective = nd.Effective
+	db.objectIndex = nil
+}
+
+// AirwayFixes returns the fixes along the given airway between from and
+// to, not including them. It returns nil if the airway isn't known or
+// doesn't include both fixes.
+func (db *StaticDatabase) AirwayFixes(airway, from, to string) []string {
+	fixes, ok := db.FAA.airways[airway]
+	if !ok {
+		return nil
+	}
+	i0, i1 := -1, -1
+	for i, f := range fixes {
+		if f == from {
+			i0 = i
+		} else if f == to {
+			i1 = i
+		}
+	}
+	if i0 == -1 || i1 == -1 {
+		return nil
+	}
+
+	if i0 < i1 {
+		return DuplicateSlice(fixes[i0+1 : i1])
+	}
+	var r []string
+	for i := i0 - 1; i > i1; i-- {
+		r = append(r, fixes[i])
+	}
+	return r
+}
+
+// FAACycleDescription returns a description of the source and the AIRAC
+// cycle of the FAA data and whether the cycle has expired.
+func (db *StaticDatabase) FAACycleDescription() (string, bool) {
+	if db.FAA.effective.IsZero() {
+		return db.FAA.source + ": unknown AIRAC cycle", false
+	}
+	id, _, end := AIRACCycle(db.FAA.effective)
+	expired := time.Now().After(end)
+	return fmt.Sprintf("AIRAC %s (%s), effective %s, expire%s %s", id, db.FAA.source,
+		db.FAA.effective.Format("2006-01-02"), Select(expired, "d", "s"), end.Format("2006-01-02")), expired
+}
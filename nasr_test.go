@@ -0,0 +1,174 @@
+// nasr_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAIRACCycle(t *testing.T) {
+	for _, test := range []struct {
+		date, id, start string
+	}{
+		{"2023-01-26", "2301", "2023-01-26"},
+		{"2023-02-22", "2301", "2023-01-26"},
+		{"2023-09-07", "2309", "2023-09-07"},
+		{"2023-12-31", "2313", "2023-12-28"},
+		{"2024-01-25", "2401", "2024-01-25"},
+		{"2022-12-30", "2213", "2022-12-29"},
+	} {
+		d, _ := time.Parse("2006-01-02", test.date)
+		id, start, end := AIRACCycle(d)
+		if id != test.id || start.Format("2006-01-02") != test.start || end.Sub(start) != 28*24*time.Hour {
+			t.Errorf("%s: got %s %s-%s, expected %s %s", test.date, id, start, end, test.id, test.start)
+		}
+	}
+}
+
+var testNASRFiles = map[string]string{
+	"NAV_BASE.csv": `"EFF_DATE","NAV_ID","NAV_TYPE","NAME","LAT_DECIMAL","LONG_DECIMAL"
+"2023/10/05","JFK","VOR/DME","KENNEDY",40.63,-73.77
+`,
+	"FIX_BASE.csv": `"EFF_DATE","FIX_ID","LAT_DECIMAL","LONG_DECIMAL"
+"2023/10/05","MERIT",41.38,-73.13
+"2023/10/05","HFD",41.64,-72.54
+`,
+	"APT_BASE.csv": `"EFF_DATE","ARPT_ID","ICAO_ID","ARPT_NAME","LAT_DECIMAL","LONG_DECIMAL","ELEV"
+"2023/10/05","JFK","KJFK","JOHN F KENNEDY INTL",40.64,-73.78,13
+"2023/10/05","N51","","SOLBERG-HUNTERDON",40.58,-74.74,
+`,
+	"PFR_BASE.csv": `"ORIGIN_ID","DSTN_ID","PFR_TYPE_CODE","ROUTE_NO","SPECIAL_AREA_DESCRIP","ALT_DESCRIP","AIRCRAFT","HOURS","ROUTE_DIR_DESCRIP","ROUTE_STRING"
+"JFK","BOS","TEC","1","","","JETS","","","JFK MERIT HFD BOS"
+"JFK","BOS","H","2","","","","","","JFK V229 BOS"
+`,
+	"AWY_BASE.csv": `"AWY_LOCATION","AWY_ID","AIRWAY_STRING"
+"A","J42","AAA BBB"
+"C","J42","JFK MERIT HFD PUT BOS"
+"H","J42","CCC DDD"
+`,
+}
+
+func TestParseNASR(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, contents := range testNASRFiles {
+		fsys["CSV_Data/"+name] = &fstest.MapFile{Data: []byte(contents)}
+	}
+
+	nd, err := ParseNASR(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nd.Effective.Format("2006-01-02") != "2023-10-05" {
+		t.Errorf("effective date mismatch: %v", nd.Effective)
+	}
+	if len(nd.Navaids) != 1 || nd.Navaids["JFK"].Name != "KENNEDY" {
+		t.Errorf("navaid mismatch: %+v", nd.Navaids)
+	}
+	if len(nd.Fixes) != 2 {
+		t.Errorf("fix mismatch: %+v", nd.Fixes)
+	}
+	if ap, ok := nd.Airports["KJFK"]; !ok || ap.Elevation != 13 || ap.Location != (Point2LL{-73.78, 40.64}) {
+		t.Errorf("KJFK mismatch: %+v", nd.Airports)
+	}
+	if _, ok := nd.Airports["N51"]; !ok {
+		t.Errorf("N51 missing: %+v", nd.Airports)
+	}
+	if len(nd.PRD) != 2 || nd.PRD[0].Route != "JFK MERIT HFD BOS" || nd.PRD[0].Aircraft != "JETS" {
+		t.Errorf("PRD mismatch: %+v", nd.PRD)
+	}
+
+	db := &StaticDatabase{}
+	db.AddNASR(nd)
+	if prd := db.FAA.prd[AirportPair{"JFK", "BOS"}]; len(prd) != 2 {
+		t.Errorf("expected 2 PRD entries, got %+v", prd)
+	}
+	for _, test := range []struct {
+		from, to string
+		expected []string
+	}{
+		{"JFK", "BOS", []string{"MERIT", "HFD", "PUT"}},
+		{"PUT", "MERIT", []string{"HFD"}},
+		{"MERIT", "HFD", nil},
+		{"JFK", "XXX", nil},
+	} {
+		fixes := db.AirwayFixes("J42", test.from, test.to)
+		if len(fixes) != len(test.expected) {
+			t.Errorf("J42 %s-%s: got %v, expected %v", test.from, test.to, fixes, test.expected)
+			continue
+		}
+		for i := range fixes {
+			if fixes[i] != test.expected[i] {
+				t.Errorf("J42 %s-%s: got %v, expected %v", test.from, test.to, fixes, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestParseNASRMissingColumns(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, contents := range testNASRFiles {
+		fsys["CSV_Data/"+name] = &fstest.MapFile{Data: []byte(contents)}
+	}
+	// An older layout without the ROUTE_STRING column
+	fsys["CSV_Data/PFR_BASE.csv"] = &fstest.MapFile{Data: []byte(`"ORIGIN_ID","DSTN_ID","PFR_TYPE_CODE"
+"JFK","BOS","TEC"
+`)}
+
+	if _, err := ParseNASR(fsys); err == nil || !strings.Contains(err.Error(), "ROUTE_STRING") {
+		t.Errorf("expected missing column error, got %v", err)
+	}
+}
+
+func TestOpenNASRNestedZip(t *testing.T) {
+	makeZip := func(files map[string][]byte) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, contents := range files {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(contents); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	csv := make(map[string][]byte)
+	for name, contents := range testNASRFiles {
+		csv[name] = []byte(contents)
+	}
+	outer := makeZip(map[string][]byte{
+		"README.txt":                   []byte("hello"),
+		"CSV_Data/05_Oct_2023_CSV.zip": makeZip(csv),
+	})
+	fn := filepath.Join(t.TempDir(), "28DaySubscription_Effective_2023-10-05.zip")
+	if err := os.WriteFile(fn, outer, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, closer, err := openNASR(fn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer closer()
+	if nd, err := ParseNASR(fsys); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(nd.Fixes) != 2 {
+		t.Errorf("fix mismatch: %+v", nd.Fixes)
+	}
+}
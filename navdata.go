@@ -0,0 +1,276 @@
+// navdata.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file handles user-supplied navigation databases that cover the
+// world beyond the built-in FAA data: OurAirports CSV files
+// (https://ourairports.com/data/) and ARINC 424 navdata files.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Navdata stores the airports, navaids, and fixes from a navigation
+// database. Idents aren't unique worldwide, so there may be multiple
+// navaids or fixes with the same one.
+type Navdata struct {
+	Airports []Airport
+	Navaids  []Navaid
+	Fixes    []Fix
+}
+
+// LoadNavdataFile loads the given OurAirports CSV file or ARINC 424 file,
+// determining which it is from its contents.
+func LoadNavdataFile(filename string, errorCallback func(string)) (*Navdata, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(contents, []byte("\"id\"")) || bytes.HasPrefix(contents, []byte("id,")) {
+		return ParseOurAirports(bytes.NewReader(contents))
+	}
+	return ParseARINC424(bytes.NewReader(contents), errorCallback), nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// OurAirports
+
+// ParseOurAirports parses either the airports.csv or navaids.csv file from
+// OurAirports.
+func ParseOurAirports(r io.Reader) (*Navdata, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	column := make(map[string]int)
+	for i, h := range header {
+		column[strings.TrimSpace(h)] = i
+	}
+	for _, f := range []string{"ident", "name", "type", "latitude_deg", "longitude_deg"} {
+		if _, ok := column[f]; !ok {
+			return nil, fmt.Errorf("%s: field not found in CSV header", f)
+		}
+	}
+	_, isNavaids := column["frequency_khz"]
+
+	nd := &Navdata{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nd, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		get := func(f string) string {
+			if i, ok := column[f]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		lat, laterr := strconv.ParseFloat(get("latitude_deg"), 32)
+		long, longerr := strconv.ParseFloat(get("longitude_deg"), 32)
+		if laterr != nil || longerr != nil || get("ident") == "" {
+			continue
+		}
+		loc := Point2LLFromLL64(lat, long)
+
+		if isNavaids {
+			nd.Navaids = append(nd.Navaids, Navaid{
+				Id:       strings.ToUpper(get("ident")),
+				Type:     get("type"),
+				Name:     strings.ToUpper(get("name")),
+				Location: loc,
+			})
+		} else {
+			typ := get("type")
+			if typ == "closed" || typ == "heliport" || typ == "balloonport" || typ == "seaplane_base" {
+				continue
+			}
+			// Prefer the ICAO code to OurAirports's ident, which is a
+			// local code for some airports.
+			id := get("gps_code")
+			if id == "" {
+				id = get("ident")
+			}
+			elevation, _ := strconv.Atoi(get("elevation_ft"))
+			nd.Airports = append(nd.Airports, Airport{
+				Id:        strings.ToUpper(id),
+				Name:      get("name"),
+				Elevation: elevation,
+				Location:  loc,
+			})
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////
+// ARINC 424
+
+// arincField returns the given columns of an ARINC 424 record; as in the
+// specification, columns are numbered starting from 1 and the range is
+// inclusive.
+func arincField(line string, start, end int) string {
+	if len(line) < end {
+		return ""
+	}
+	return strings.TrimSpace(line[start-1 : end])
+}
+
+// parseARINCLatLong parses a latitude and longitude in ARINC 424 format,
+// e.g., "N40380570" and "W073470870": degrees, minutes, seconds and
+// hundredths of seconds.
+func parseARINCLatLong(lat, long string) (Point2LL, error) {
+	parse := func(s string, degDigits int, pos, neg byte) (float32, error) {
+		if len(s) != 1+degDigits+6 || (s[0] != pos && s[0] != neg) {
+			return 0, fmt.Errorf("%s: malformed latitude/longitude", s)
+		}
+		deg, err := strconv.Atoi(s[1 : 1+degDigits])
+		if err != nil {
+			return 0, err
+		}
+		min, err := strconv.Atoi(s[1+degDigits : 3+degDigits])
+		if err != nil {
+			return 0, err
+		}
+		sec, err := strconv.Atoi(s[3+degDigits:])
+		if err != nil {
+			return 0, err
+		}
+		v := float32(deg) + float32(min)/60 + float32(sec)/(3600*100)
+		if s[0] == neg {
+			v = -v
+		}
+		return v, nil
+	}
+
+	var p Point2LL
+	var err error
+	if p[1], err = parse(lat, 2, 'N', 'S'); err != nil {
+		return Point2LL{}, err
+	}
+	if p[0], err = parse(long, 3, 'E', 'W'); err != nil {
+		return Point2LL{}, err
+	}
+	return p, nil
+}
+
+// ParseARINC424 parses the airports, VHF navaids, NDBs, and enroute and
+// terminal waypoints from an ARINC 424 file. Other records are ignored.
+func ParseARINC424(r io.Reader, errorCallback func(string)) *Navdata {
+	nd := &Navdata{}
+
+	scan := bufio.NewScanner(r)
+	lineno := 0
+	for scan.Scan() {
+		lineno++
+		line := scan.Text()
+		// Skip header records and continuation records.
+		if len(line) < 51 || (line[0] != 'S' && line[0] != 'T') ||
+			(line[21] != '0' && line[21] != '1') {
+			continue
+		}
+
+		location := func(latcol int) (Point2LL, bool) {
+			p, err := parseARINCLatLong(arincField(line, latcol, latcol+8), arincField(line, latcol+9, latcol+18))
+			if err != nil {
+				errorCallback(fmt.Sprintf("%d: %v", lineno, err))
+				return Point2LL{}, false
+			}
+			return p, true
+		}
+
+		section, subsection := line[4], line[5]
+		if section == 'P' {
+			// Airport records have the subsection in column 13.
+			subsection = line[12]
+		}
+
+		switch {
+		case section == 'D' && subsection == ' ':
+			// DME-only navaids give their location in the DME fields.
+			typ, latcol := "VOR", 33
+			if arincField(line, 33, 41) == "" {
+				typ, latcol = "DME", 56
+			}
+			if p, ok := location(latcol); ok {
+				nd.Navaids = append(nd.Navaids, Navaid{Id: arincField(line, 14, 17), Type: typ,
+					Name: arincField(line, 94, 123), Location: p})
+			}
+
+		case (section == 'D' && subsection == 'B') || (section == 'P' && subsection == 'N'):
+			if p, ok := location(33); ok {
+				nd.Navaids = append(nd.Navaids, Navaid{Id: arincField(line, 14, 17), Type: "NDB",
+					Name: arincField(line, 94, 123), Location: p})
+			}
+
+		case (section == 'E' && subsection == 'A') || (section == 'P' && subsection == 'C'):
+			if p, ok := location(33); ok {
+				nd.Fixes = append(nd.Fixes, Fix{Id: arincField(line, 14, 18), Location: p})
+			}
+
+		case section == 'P' && subsection == 'A':
+			if p, ok := location(33); ok {
+				elevation, _ := strconv.Atoi(arincField(line, 57, 61))
+				nd.Airports = append(nd.Airports, Airport{Id: arincField(line, 7, 10),
+					Name: arincField(line, 94, 123), Elevation: elevation, Location: p})
+			}
+		}
+	}
+	if err := scan.Err(); err != nil {
+		errorCallback(err.Error())
+	}
+
+	return nd
+}
+
+///////////////////////////////////////////////////////////////////////////
+// StaticDatabase integration
+
+// AddNavdata adds the contents of the Navdata to the database. Airports
+// that are already known (e.g., from the FAA database) are not replaced.
+func (db *StaticDatabase) AddNavdata(nd *Navdata) {
+	if db.world.navaids == nil {
+		db.world.navaids = make(map[string][]Navaid)
+		db.world.fixes = make(map[string][]Fix)
+	}
+
+	for _, ap := range nd.Airports {
+		if _, ok := db.airports[ap.Id]; !ok && ap.Id != "" {
+			db.airports[ap.Id] = ap
+		}
+	}
+	for _, n := range nd.Navaids {
+		db.world.navaids[n.Id] = append(db.world.navaids[n.Id], n)
+	}
+	for _, f := range nd.Fixes {
+		db.world.fixes[f.Id] = append(db.world.fixes[f.Id], f)
+	}
+	db.objectIndex = nil
+}
+
+// LoadNavdataFiles loads all of the given navdata files, reporting errors
+// via the log.
+func (db *StaticDatabase) LoadNavdataFiles(filenames []string) {
+	for _, fn := range filenames {
+		lg.Printf("%s: loading navdata file", fn)
+		nd, err := LoadNavdataFile(fn, func(err string) { lg.Errorf("%s: %s", fn, err) })
+		if err != nil {
+			lg.Errorf("%s: %v", fn, err)
+			continue
+		}
+		db.AddNavdata(nd)
+	}
+}
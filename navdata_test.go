@@ -0,0 +1,143 @@
+// navdata_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOurAirports(t *testing.T) {
+	airports := `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","gps_code"
+2434,"EGLL","large_airport","London Heathrow Airport",51.4706,-0.461941,83,"EGLL"
+1,"00A","heliport","Total Rf Heliport",40.070985,-74.933689,11,"00PA"
+3,"XX-0001","small_airport","Farm Strip",10.5,20.25,,""
+`
+	nd, err := ParseOurAirports(strings.NewReader(airports))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nd.Airports) != 2 || len(nd.Navaids) != 0 {
+		t.Fatalf("expected 2 airports, got %+v", nd)
+	}
+	if ap := nd.Airports[0]; ap.Id != "EGLL" || ap.Elevation != 83 || ap.Location[1] != 51.4706 {
+		t.Errorf("EGLL mismatch: %+v", ap)
+	}
+	if ap := nd.Airports[1]; ap.Id != "XX-0001" || ap.Location != (Point2LL{20.25, 10.5}) {
+		t.Errorf("XX-0001 mismatch: %+v", ap)
+	}
+
+	navaids := `"id","filename","ident","name","type","frequency_khz","latitude_deg","longitude_deg"
+1,"x","LON","London","VOR-DME",113600,51.4873,-0.466
+`
+	if nd, err = ParseOurAirports(strings.NewReader(navaids)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nd.Navaids) != 1 || nd.Navaids[0].Id != "LON" || nd.Navaids[0].Type != "VOR-DME" {
+		t.Errorf("navaid mismatch: %+v", nd)
+	}
+
+	if _, err = ParseOurAirports(strings.NewReader("a,b,c\n1,2,3\n")); err == nil {
+		t.Errorf("expected error for unknown CSV format")
+	}
+}
+
+// arincRecord returns a 132-column ARINC 424 record with the given fields
+// set; the map is from starting column (1-based) to value.
+func arincRecord(fields map[int]string) string {
+	b := []byte(strings.Repeat(" ", 132))
+	for col, v := range fields {
+		copy(b[col-1:], v)
+	}
+	return string(b)
+}
+
+func TestParseARINC424(t *testing.T) {
+	lines := []string{
+		"HDR01 header record",
+		// Airport
+		arincRecord(map[int]string{1: "SEURP", 7: "EGLL", 13: "A", 22: "0", 33: "N51284300",
+			42: "W000274800", 57: "00083", 94: "LONDON HEATHROW"}),
+		// Airport continuation record: ignored
+		arincRecord(map[int]string{1: "SEURP", 7: "EGLL", 13: "A", 22: "2", 33: "N00000000",
+			42: "E000000000"}),
+		// VOR
+		arincRecord(map[int]string{1: "SEURD ", 14: "BNN", 22: "1", 33: "N51432700",
+			42: "W000325900", 94: "BOVINGDON"}),
+		// DME-only
+		arincRecord(map[int]string{1: "SEURD ", 14: "LHR", 22: "1", 56: "N51284300",
+			65: "W000274800"}),
+		// NDB
+		arincRecord(map[int]string{1: "SEURDB", 14: "EPM", 22: "1", 33: "N51190900",
+			42: "W000222200", 94: "EPSOM"}),
+		// Enroute waypoint
+		arincRecord(map[int]string{1: "SEUREA", 14: "ABBOT", 22: "0", 33: "S12300000",
+			42: "E123450000"}),
+		// Terminal waypoint with a bad latitude
+		arincRecord(map[int]string{1: "SEURP", 7: "EGLL", 13: "C", 14: "LL001", 22: "0",
+			33: "X51000000", 42: "W000000000"}),
+	}
+
+	var errs []string
+	nd := ParseARINC424(strings.NewReader(strings.Join(lines, "\n")),
+		func(e string) { errs = append(errs, e) })
+
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error, got %+v", errs)
+	}
+	if len(nd.Airports) != 1 {
+		t.Errorf("expected 1 airport, got %+v", nd.Airports)
+	} else if ap := nd.Airports[0]; ap.Id != "EGLL" || ap.Name != "LONDON HEATHROW" || ap.Elevation != 83 ||
+		abs(ap.Location[1]-51.478611) > 1e-4 || abs(ap.Location[0]+0.463333) > 1e-4 {
+		t.Errorf("airport mismatch: %+v", ap)
+	}
+	if len(nd.Navaids) != 3 {
+		t.Errorf("expected 3 navaids, got %+v", nd.Navaids)
+	} else {
+		for i, expected := range []Navaid{{Id: "BNN", Type: "VOR", Name: "BOVINGDON"},
+			{Id: "LHR", Type: "DME"}, {Id: "EPM", Type: "NDB", Name: "EPSOM"}} {
+			n := nd.Navaids[i]
+			if n.Id != expected.Id || n.Type != expected.Type || n.Name != expected.Name || n.Location.IsZero() {
+				t.Errorf("navaid %d: got %+v, expected %+v", i, n, expected)
+			}
+		}
+	}
+	if len(nd.Fixes) != 1 || nd.Fixes[0].Id != "ABBOT" ||
+		nd.Fixes[0].Location != (Point2LL{123.75, -12.5}) {
+		t.Errorf("fix mismatch: %+v", nd.Fixes)
+	}
+}
+
+func TestLocateNear(t *testing.T) {
+	db := &StaticDatabase{airports: make(map[string]Airport)}
+	db.FAA.fixes = map[string]Fix{"DUP": {Id: "DUP", Location: Point2LL{-74, 40}}}
+	db.AddNavdata(&Navdata{
+		Airports: []Airport{{Id: "EGLL", Location: Point2LL{-0.46, 51.47}}},
+		Fixes:    []Fix{{Id: "DUP", Location: Point2LL{0, 51}}, {Id: "DUP", Location: Point2LL{139, 35}}},
+	})
+
+	if p, ok := db.Locate("egll"); !ok || p != (Point2LL{-0.46, 51.47}) {
+		t.Errorf("EGLL: got %v %v", p, ok)
+	}
+	for _, test := range []struct {
+		near, expected Point2LL
+	}{
+		{Point2LL{-73, 41}, Point2LL{-74, 40}},
+		{Point2LL{2, 49}, Point2LL{0, 51}},
+		{Point2LL{140, 36}, Point2LL{139, 35}},
+	} {
+		if p, ok := db.LocateNear("DUP", test.near); !ok || p != test.expected {
+			t.Errorf("near %v: got %v, expected %v", test.near, p, test.expected)
+		}
+	}
+
+	db.defaultCenter = Point2LL{138, 34}
+	if p, _ := db.Locate("DUP"); p != (Point2LL{139, 35}) {
+		t.Errorf("Locate didn't use the sector center: got %v", p)
+	}
+	if _, ok := db.Locate("NONE"); ok {
+		t.Errorf("found nonexistent fix")
+	}
+}
@@ -0,0 +1,293 @@
+// notes.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// FindTopic returns the node under n whose title best matches the given
+// topic along with the titles of the nodes along the path to it. Exact
+// (case-insensitive) matches are preferred to prefix matches, which are
+// in turn preferred to substring matches.
+func (n *NotesNode) FindTopic(topic string) (*NotesNode, []string) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	if topic == "" {
+		return nil, nil
+	}
+
+	var best *NotesNode
+	var bestPath []string
+	bestScore := 0
+	var visit func(node *NotesNode, path []string)
+	visit = func(node *NotesNode, path []string) {
+		for _, child := range node.children {
+			p := append(DuplicateSlice(path), child.title)
+			title := strings.ToLower(child.title)
+			score := 0
+			if title == topic {
+				score = 3
+			} else if strings.HasPrefix(title, topic) {
+				score = 2
+			} else if strings.Contains(title, topic) {
+				score = 1
+			}
+			if score > bestScore {
+				best, bestPath, bestScore = child, p, score
+			}
+			visit(child, p)
+		}
+	}
+	visit(n, nil)
+
+	return best, bestPath
+}
+
+// matches returns true if the node's title or text or any of its
+// descendants contain the given (lowercase) string.
+func (n *NotesNode) matches(s string) bool {
+	if strings.Contains(strings.ToLower(n.title), s) {
+		return true
+	}
+	for _, t := range n.text {
+		if strings.Contains(strings.ToLower(t), s) {
+			return true
+		}
+	}
+	for _, child := range n.children {
+		if child.matches(s) {
+			return true
+		}
+	}
+	return false
+}
+
+///////////////////////////////////////////////////////////////////////////
+// NotesPane
+
+// NotesPane shows the contents of the user's notes file as a collapsible
+// outline that can be searched.
+type NotesPane struct {
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	// Paths of expanded nodes; a path is the titles of the nodes from
+	// the root joined with "/".
+	expanded map[string]interface{}
+
+	search       string
+	searchCursor int
+	// When set, the outline is scrolled so that this node is at the top.
+	jumpPath string
+
+	sb *ScrollBar
+	cb CommandBuffer
+}
+
+type notesLine struct {
+	text      string
+	path      string // for headers; empty for text
+	highlight bool
+}
+
+func NewNotesPane() *NotesPane {
+	return &NotesPane{}
+}
+
+func (np *NotesPane) Duplicate(nameAsCopy bool) Pane {
+	return &NotesPane{
+		FontIdentifier: np.FontIdentifier,
+		font:           np.font,
+		expanded:       DuplicateMap(np.expanded),
+		sb:             NewScrollBar(4, false),
+	}
+}
+
+func (np *NotesPane) Activate() {
+	if np.font = GetFont(np.FontIdentifier); np.font == nil {
+		np.font = GetDefaultFont()
+		np.FontIdentifier = np.font.id
+	}
+	if np.expanded == nil {
+		np.expanded = make(map[string]interface{})
+	}
+	if np.sb == nil {
+		np.sb = NewScrollBar(4, false)
+	}
+}
+
+func (np *NotesPane) Deactivate()                {}
+func (np *NotesPane) CanTakeKeyboardFocus() bool { return true }
+
+func (np *NotesPane) Name() string { return "Notes" }
+
+func (np *NotesPane) DrawUI() {
+	if newFont, changed := DrawFontPicker(&np.FontIdentifier, "Font"); changed {
+		np.font = newFont
+	}
+	if imgui.Button("Expand all") {
+		np.setExpanded(globalConfig.notesRoot, "", true)
+	}
+	imgui.SameLine()
+	if imgui.Button("Collapse all") {
+		np.expanded = make(map[string]interface{})
+	}
+}
+
+func (np *NotesPane) setExpanded(n *NotesNode, path string, expand bool) {
+	if n == nil {
+		return
+	}
+	for _, child := range n.children {
+		p := path + "/" + child.title
+		if expand {
+			np.expanded[p] = nil
+		} else {
+			delete(np.expanded, p)
+		}
+		np.setExpanded(child, p, expand)
+	}
+}
+
+// JumpTo expands the outline to show the node with the given path and
+// scrolls so that it is at the top of the pane.
+func (np *NotesPane) JumpTo(path []string) {
+	p := ""
+	for _, title := range path {
+		p += "/" + title
+		np.expanded[p] = nil
+	}
+	np.jumpPath = p
+	np.search = ""
+	np.searchCursor = 0
+}
+
+// layout returns the lines of text to draw for the current expansion
+// state and search string.
+func (np *NotesPane) layout(root *NotesNode) []notesLine {
+	var lines []notesLine
+	query := strings.ToLower(np.search)
+	searching := query != ""
+
+	addText := func(text []string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, t := range text {
+			hl := searching && strings.Contains(strings.ToLower(t), query)
+			lines = append(lines, notesLine{text: indent + t, highlight: hl})
+		}
+	}
+
+	var visit func(n *NotesNode, path string, depth int)
+	visit = func(n *NotesNode, path string, depth int) {
+		for _, child := range n.children {
+			if searching && !child.matches(query) {
+				continue
+			}
+
+			p := path + "/" + child.title
+			_, expanded := np.expanded[p]
+			// Show everything that matches when searching.
+			expanded = expanded || searching
+
+			icon := " "
+			if len(child.text) > 0 || len(child.children) > 0 {
+				icon = Select(expanded, FontAwesomeIconCaretDown, FontAwesomeIconCaretRight)
+			}
+			hl := searching && strings.Contains(strings.ToLower(child.title), query)
+			lines = append(lines, notesLine{text: strings.Repeat("  ", depth) + icon + " " + child.title,
+				path: p, highlight: hl})
+
+			if expanded {
+				addText(child.text, depth+1)
+				visit(child, p, depth+1)
+			}
+		}
+	}
+
+	if !searching || root.matches(query) {
+		addText(root.text, 0)
+	}
+	visit(root, "", 0)
+
+	return lines
+}
+
+func (np *NotesPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	globalConfig.ReloadNotesIfChanged()
+
+	np.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&np.cb)
+
+	cs := ctx.cs
+	style := TextStyle{Font: np.font, Color: cs.Text}
+	highlightStyle := TextStyle{Font: np.font, Color: cs.TextHighlight}
+	cursorStyle := TextStyle{Font: np.font, Color: cs.Background,
+		DrawBackground: true, BackgroundColor: cs.Text}
+
+	lineHeight := float32(np.font.size)
+	sz2 := lineHeight / 2
+	height := ctx.paneExtent.Height()
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	// Search box on the first line
+	searchPos := [2]float32{sz2, height - sz2}
+	if ctx.haveFocus {
+		p := td.AddText("Search: ", searchPos, style)
+		uiDrawTextEdit(&np.search, &np.searchCursor, ctx.keyboard, p, style, cursorStyle, &np.cb)
+	} else if np.search == "" {
+		td.AddText("Search: (click to search)", searchPos, TextStyle{Font: np.font, Color: cs.TextDisabled})
+	} else {
+		td.AddText("Search: "+np.search, searchPos, style)
+	}
+	if ctx.mouse != nil && ctx.mouse.Clicked[MouseButtonPrimary] && ctx.mouse.Pos[1] > height-lineHeight-sz2 {
+		wmTakeKeyboardFocus(np, true)
+	}
+
+	root := globalConfig.notesRoot
+	if root == nil {
+		td.AddText("No notes file loaded.", [2]float32{sz2, height - sz2 - 1.5*lineHeight}, style)
+		td.GenerateCommands(&np.cb)
+		cb.Call(np.cb)
+		return
+	}
+
+	lines := np.layout(root)
+	if np.jumpPath != "" {
+		if idx := FindIf(lines, func(l notesLine) bool { return l.path == np.jumpPath }); idx != -1 {
+			np.sb.offset = idx
+		}
+		np.jumpPath = ""
+	}
+
+	top := height - sz2 - 1.5*lineHeight
+	nVisible := int(top / lineHeight)
+	np.sb.Update(len(lines), nVisible, ctx)
+	offset := np.sb.Offset()
+
+	for i := offset; i < len(lines) && i < offset+nVisible; i++ {
+		s := Select(lines[i].highlight, highlightStyle, style)
+		td.AddText(lines[i].text, [2]float32{sz2, top - float32(i-offset)*lineHeight}, s)
+	}
+
+	// Toggle expansion of clicked headers
+	if ctx.mouse != nil && ctx.mouse.Clicked[MouseButtonPrimary] && ctx.mouse.Pos[1] <= top {
+		i := offset + int((top-ctx.mouse.Pos[1])/lineHeight)
+		if i < len(lines) && lines[i].path != "" && np.search == "" {
+			if _, ok := np.expanded[lines[i].path]; ok {
+				delete(np.expanded, lines[i].path)
+			} else {
+				np.expanded[lines[i].path] = nil
+			}
+		}
+	}
+
+	td.GenerateCommands(&np.cb)
+	np.sb.Draw(ctx, &np.cb)
+	cb.Call(np.cb)
+}
@@ -0,0 +1,560 @@
+// overlays.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mmp/earcut-go"
+	"github.com/mmp/imgui-go/v4"
+)
+
+var ErrUnknownOverlayFormat = errors.New("Unknown map overlay format; expected .geojson, .json, or .kml")
+
+// MapOverlayGeometry stores the contents of a GeoJSON or KML file.
+type MapOverlayGeometry struct {
+	Lines [][]Point2LL
+	// Each polygon is given by its outer ring followed by any holes.
+	Polygons [][][]Point2LL
+	Points   []MapOverlayPoint
+}
+
+type MapOverlayPoint struct {
+	P     Point2LL
+	Label string
+}
+
+// LoadMapOverlayGeometry loads the given GeoJSON or KML file; the format
+// is determined from the file's extension.
+func LoadMapOverlayGeometry(filename string) (*MapOverlayGeometry, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".geojson", ".json":
+		return ParseGeoJSON(b)
+	case ".kml":
+		return ParseKML(b)
+	default:
+		return nil, ErrUnknownOverlayFormat
+	}
+}
+
+func (g *MapOverlayGeometry) Bounds() Extent2D {
+	var pts [][2]float32
+	for _, l := range g.Lines {
+		for _, p := range l {
+			pts = append(pts, p)
+		}
+	}
+	for _, poly := range g.Polygons {
+		for _, p := range poly[0] {
+			pts = append(pts, p)
+		}
+	}
+	for _, p := range g.Points {
+		pts = append(pts, p.P)
+	}
+	return Extent2DFromPoints(pts)
+}
+
+///////////////////////////////////////////////////////////////////////////
+// GeoJSON
+
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	Features    []geoJSONObject        `json:"features"`
+	Geometry    *geoJSONObject         `json:"geometry"`
+	Geometries  []geoJSONObject        `json:"geometries"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+// ParseGeoJSON returns the geometry in a GeoJSON (RFC 7946) object.
+// Features' "name", "label", or "title" properties are used to label
+// points.
+func ParseGeoJSON(b []byte) (*MapOverlayGeometry, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+
+	g := &MapOverlayGeometry{}
+	if err := g.addGeoJSON(&obj, ""); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func geoJSONPoint(c []float64) (Point2LL, error) {
+	if len(c) < 2 {
+		return Point2LL{}, fmt.Errorf("%v: invalid position", c)
+	}
+	return Point2LL{float32(c[0]), float32(c[1])}, nil
+}
+
+func geoJSONPoints(c [][]float64) ([]Point2LL, error) {
+	var pts []Point2LL
+	for _, ci := range c {
+		p, err := geoJSONPoint(ci)
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, p)
+	}
+	return pts, nil
+}
+
+// checkPolygonRing returns an error if the ring doesn't have at least
+// three distinct vertices.
+func checkPolygonRing(ring []Point2LL) error {
+	n := len(ring)
+	if n > 0 && ring[0] == ring[n-1] {
+		n--
+	}
+	if n < 3 {
+		return fmt.Errorf("polygon ring has %d vertices; at least 3 are required", n)
+	}
+	return nil
+}
+
+func geoJSONPolygon(c [][][]float64) ([][]Point2LL, error) {
+	var rings [][]Point2LL
+	for _, r := range c {
+		ring, err := geoJSONPoints(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPolygonRing(ring); err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+	}
+	if len(rings) == 0 {
+		return nil, errors.New("polygon has no rings")
+	}
+	return rings, nil
+}
+
+func (g *MapOverlayGeometry) addGeoJSON(obj *geoJSONObject, label string) error {
+	switch obj.Type {
+	case "FeatureCollection":
+		for i := range obj.Features {
+			if err := g.addGeoJSON(&obj.Features[i], ""); err != nil {
+				return err
+			}
+		}
+
+	case "Feature":
+		for _, key := range []string{"name", "label", "title"} {
+			if s, ok := obj.Properties[key].(string); ok {
+				label = s
+				break
+			}
+		}
+		if obj.Geometry != nil {
+			return g.addGeoJSON(obj.Geometry, label)
+		}
+
+	case "GeometryCollection":
+		for i := range obj.Geometries {
+			if err := g.addGeoJSON(&obj.Geometries[i], label); err != nil {
+				return err
+			}
+		}
+
+	case "Point":
+		var c []float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		p, err := geoJSONPoint(c)
+		if err != nil {
+			return err
+		}
+		g.Points = append(g.Points, MapOverlayPoint{P: p, Label: label})
+
+	case "MultiPoint":
+		var c [][]float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		pts, err := geoJSONPoints(c)
+		if err != nil {
+			return err
+		}
+		for _, p := range pts {
+			g.Points = append(g.Points, MapOverlayPoint{P: p, Label: label})
+		}
+
+	case "LineString":
+		var c [][]float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		pts, err := geoJSONPoints(c)
+		if err != nil {
+			return err
+		}
+		g.Lines = append(g.Lines, pts)
+
+	case "MultiLineString":
+		var c [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, l := range c {
+			pts, err := geoJSONPoints(l)
+			if err != nil {
+				return err
+			}
+			g.Lines = append(g.Lines, pts)
+		}
+
+	case "Polygon":
+		var c [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		poly, err := geoJSONPolygon(c)
+		if err != nil {
+			return err
+		}
+		g.Polygons = append(g.Polygons, poly)
+
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, pc := range c {
+			poly, err := geoJSONPolygon(pc)
+			if err != nil {
+				return err
+			}
+			g.Polygons = append(g.Polygons, poly)
+		}
+
+	default:
+		return fmt.Errorf("%s: unsupported GeoJSON type", obj.Type)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// KML
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer kmlCoordinates   `xml:"outerBoundaryIs>LinearRing"`
+	Inner []kmlCoordinates `xml:"innerBoundaryIs>LinearRing"`
+}
+
+type kmlGeometry struct {
+	Points        []kmlCoordinates `xml:"Point"`
+	LineStrings   []kmlCoordinates `xml:"LineString"`
+	LinearRings   []kmlCoordinates `xml:"LinearRing"`
+	Polygons      []kmlPolygon     `xml:"Polygon"`
+	MultiGeometry []kmlGeometry    `xml:"MultiGeometry"`
+}
+
+type kmlPlacemark struct {
+	Name string `xml:"name"`
+	kmlGeometry
+}
+
+// ParseKML returns the geometry of all of the placemarks in a KML
+// document, wherever they are in its folder hierarchy. Placemarks' names
+// are used to label points.
+func ParseKML(b []byte) (*MapOverlayGeometry, error) {
+	g := &MapOverlayGeometry{}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "Placemark" {
+			var pm kmlPlacemark
+			if err := d.DecodeElement(&pm, &se); err != nil {
+				return nil, err
+			}
+			if err := g.addKML(&pm.kmlGeometry, strings.TrimSpace(pm.Name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// parseKMLCoordinates parses a KML coordinate list: whitespace-separated
+// tuples of the form "longitude,latitude[,altitude]".
+func parseKMLCoordinates(s string) ([]Point2LL, error) {
+	var pts []Point2LL
+	for _, tuple := range strings.Fields(s) {
+		c := strings.Split(tuple, ",")
+		if len(c) < 2 {
+			return nil, fmt.Errorf("%s: invalid KML coordinates", tuple)
+		}
+		lon, err := strconv.ParseFloat(c[0], 32)
+		if err != nil {
+			return nil, err
+		}
+		lat, err := strconv.ParseFloat(c[1], 32)
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, Point2LL{float32(lon), float32(lat)})
+	}
+	return pts, nil
+}
+
+func (g *MapOverlayGeometry) addKML(geom *kmlGeometry, label string) error {
+	for _, c := range geom.Points {
+		pts, err := parseKMLCoordinates(c.Coordinates)
+		if err != nil {
+			return err
+		}
+		for _, p := range pts {
+			g.Points = append(g.Points, MapOverlayPoint{P: p, Label: label})
+		}
+	}
+	for _, c := range append(geom.LineStrings, geom.LinearRings...) {
+		pts, err := parseKMLCoordinates(c.Coordinates)
+		if err != nil {
+			return err
+		}
+		g.Lines = append(g.Lines, pts)
+	}
+	for _, p := range geom.Polygons {
+		var poly [][]Point2LL
+		for _, c := range append([]kmlCoordinates{p.Outer}, p.Inner...) {
+			ring, err := parseKMLCoordinates(c.Coordinates)
+			if err != nil {
+				return err
+			}
+			if err := checkPolygonRing(ring); err != nil {
+				return err
+			}
+			poly = append(poly, ring)
+		}
+		g.Polygons = append(g.Polygons, poly)
+	}
+	for i := range geom.MultiGeometry {
+		if err := g.addKML(&geom.MultiGeometry[i], label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// MapOverlay
+
+// MapOverlay is a user-supplied GeoJSON or KML file that is drawn as a
+// layer in a StaticDrawConfig.
+type MapOverlay struct {
+	Filename    string
+	Visible     bool
+	Color       RGB
+	Fill        bool
+	FillColor   RGB
+	FillOpacity float32
+	DrawLabels  bool
+
+	geometry *MapOverlayGeometry
+	err      error
+	bounds   Extent2D
+	linesCb  CommandBuffer
+	fillCb   CommandBuffer
+}
+
+func NewMapOverlay(filename string) *MapOverlay {
+	o := &MapOverlay{
+		Filename:    filename,
+		Visible:     true,
+		Color:       RGB{1, 1, 0},
+		FillColor:   RGB{1, 1, 0},
+		FillOpacity: 0.25,
+		DrawLabels:  true,
+	}
+	o.load()
+	return o
+}
+
+func (o *MapOverlay) Duplicate() *MapOverlay {
+	dupe := &MapOverlay{}
+	*dupe = *o
+	dupe.linesCb, dupe.fillCb = CommandBuffer{}, CommandBuffer{}
+	dupe.load()
+	return dupe
+}
+
+func (o *MapOverlay) Activate() {
+	o.load()
+}
+
+// load reads the overlay's file and generates the command buffers used
+// to draw its lines and polygons.
+func (o *MapOverlay) load() {
+	o.linesCb.Reset()
+	o.fillCb.Reset()
+	o.geometry, o.err = LoadMapOverlayGeometry(o.Filename)
+	if o.err != nil {
+		lg.Errorf("%s: %v", o.Filename, o.err)
+		return
+	}
+	o.bounds = o.geometry.Bounds()
+
+	ld := GetLinesDrawBuilder()
+	defer ReturnLinesDrawBuilder(ld)
+	addPolyline := func(pts []Point2LL, closed bool) {
+		for i := 1; i < len(pts); i++ {
+			ld.AddLine(pts[i-1], pts[i])
+		}
+		if closed && len(pts) > 2 && pts[0] != pts[len(pts)-1] {
+			ld.AddLine(pts[len(pts)-1], pts[0])
+		}
+	}
+	for _, l := range o.geometry.Lines {
+		addPolyline(l, false)
+	}
+
+	td := GetTrianglesDrawBuilder()
+	defer ReturnTrianglesDrawBuilder(td)
+	for _, poly := range o.geometry.Polygons {
+		for _, ring := range poly {
+			addPolyline(ring, true)
+		}
+
+		// earcut doesn't support holes, so polygons that have them are
+		// only drawn with their outlines.
+		if len(poly) > 1 {
+			continue
+		}
+		outer := poly[0]
+		if n := len(outer); n > 3 && outer[0] == outer[n-1] {
+			outer = outer[:n-1]
+		}
+		var ep earcut.Polygon
+		for _, p := range outer {
+			ep.Vertices = append(ep.Vertices, earcut.Vertex{P: [2]float64{float64(p[0]), float64(p[1])}})
+		}
+		for _, tri := range earcut.Triangulate(ep) {
+			var v [3]Point2LL
+			for i := range v {
+				v[i] = Point2LL{float32(tri.Vertices[i].P[0]), float32(tri.Vertices[i].P[1])}
+			}
+			td.AddTriangle(v[0], v[1], v[2])
+		}
+	}
+
+	ld.GenerateCommands(&o.linesCb)
+	td.GenerateCommands(&o.fillCb)
+}
+
+// drawGeometry draws the overlay's lines and polygons; the current
+// viewing matrices should be set for lat-long coordinates. If color is
+// non-nil, it overrides the overlay's colors.
+func (o *MapOverlay) drawGeometry(color *RGB, viewBounds Extent2D, cb *CommandBuffer) {
+	if !o.Visible || o.geometry == nil || !Overlaps(o.bounds, viewBounds) {
+		return
+	}
+
+	if o.Fill {
+		fill := o.FillColor
+		if color != nil {
+			fill = *color
+		}
+		cb.SetRGBA(RGBA{fill.R, fill.G, fill.B, o.FillOpacity})
+		cb.Blend()
+		cb.Call(o.fillCb)
+		cb.DisableBlend()
+	}
+
+	if color != nil {
+		cb.SetRGB(*color)
+	} else {
+		cb.SetRGB(o.Color)
+	}
+	cb.Call(o.linesCb)
+}
+
+// drawPoints draws the overlay's points and their labels; the current
+// viewing matrices should be set for window coordinates.
+func (o *MapOverlay) drawPoints(color *RGB, viewBounds Extent2D, transforms ScopeTransformations,
+	labelFont *Font, background RGB, ld *ColoredLinesDrawBuilder, td *TextDrawBuilder) {
+	if !o.Visible || o.geometry == nil || !Overlaps(o.bounds, viewBounds) {
+		return
+	}
+
+	rgb := o.Color
+	if color != nil {
+		rgb = *color
+	}
+	square := [][2]float32{[2]float32{-2, -2}, [2]float32{2, -2}, [2]float32{2, 2}, [2]float32{-2, 2}}
+	for _, pt := range o.geometry.Points {
+		if !viewBounds.Inside(pt.P) {
+			continue
+		}
+		pw := transforms.WindowFromLatLongP(pt.P)
+		ld.AddPolyline(pw, rgb, square)
+		if o.DrawLabels && pt.Label != "" {
+			style := TextStyle{Font: labelFont, Color: rgb, DropShadow: true, DropShadowColor: background}
+			td.AddTextCentered(pt.Label, add2f(pw, [2]float32{0, float32(-labelFont.size)}), style)
+		}
+	}
+}
+
+// DrawUI draws the controls for the overlay and returns true if the user
+// asked for it to be removed.
+func (o *MapOverlay) DrawUI() (remove bool) {
+	imgui.PushID(fmt.Sprintf("%p", o))
+	defer imgui.PopID()
+
+	imgui.Checkbox(filepath.Base(o.Filename), &o.Visible)
+	imgui.SameLine()
+	o.Color.DrawUI("Color")
+	imgui.SameLine()
+	imgui.Checkbox("Fill", &o.Fill)
+	if o.Fill {
+		imgui.SameLine()
+		o.FillColor.DrawUI("Fill color")
+		imgui.SameLine()
+		imgui.PushItemWidth(100)
+		imgui.SliderFloatV("Opacity", &o.FillOpacity, 0, 1, "%.2f", 0)
+		imgui.PopItemWidth()
+	}
+	imgui.SameLine()
+	imgui.Checkbox("Labels", &o.DrawLabels)
+	imgui.SameLine()
+	if imgui.Button("Reload") {
+		o.load()
+	}
+	imgui.SameLine()
+	remove = imgui.Button(FontAwesomeIconTrash)
+
+	if o.err != nil {
+		imgui.Text(FontAwesomeIconExclamationTriangle + " " + o.err.Error())
+	}
+	return
+}
@@ -0,0 +1,92 @@
+// overlays_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestParseGeoJSON(t *testing.T) {
+	g, err := ParseGeoJSON([]byte(`{
+  "type": "FeatureCollection",
+  "features": [
+    { "type": "Feature", "properties": { "name": "GATE" },
+      "geometry": { "type": "Point", "coordinates": [-73.5, 40.5] } },
+    { "type": "Feature", "properties": {},
+      "geometry": { "type": "LineString", "coordinates": [[-74, 40], [-73, 41, 1500]] } },
+    { "type": "Feature", "properties": null,
+      "geometry": { "type": "MultiPolygon", "coordinates": [
+        [[[-74, 40], [-73, 40], [-73, 41], [-74, 40]]],
+        [[[-72, 40], [-71, 40], [-71, 41], [-72, 40]], [[-71.8, 40.2], [-71.5, 40.2], [-71.5, 40.5], [-71.8, 40.2]]]
+      ] } }
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(g.Points) != 1 || g.Points[0].Label != "GATE" || g.Points[0].P != (Point2LL{-73.5, 40.5}) {
+		t.Errorf("unexpected points: %+v", g.Points)
+	}
+	if len(g.Lines) != 1 || len(g.Lines[0]) != 2 || g.Lines[0][1] != (Point2LL{-73, 41}) {
+		t.Errorf("unexpected lines: %+v", g.Lines)
+	}
+	if len(g.Polygons) != 2 || len(g.Polygons[1]) != 2 {
+		t.Errorf("unexpected polygons: %+v", g.Polygons)
+	}
+
+	if _, err := ParseGeoJSON([]byte(`{"type": "Circle", "coordinates": [1, 2]}`)); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+	if _, err := ParseGeoJSON([]byte(`{"type": "Point", "coordinates": [1]}`)); err == nil {
+		t.Errorf("expected error for invalid position")
+	}
+	for _, c := range []string{`[[]]`, `[[[-74, 40], [-73, 40], [-74, 40]]]`} {
+		if _, err := ParseGeoJSON([]byte(`{"type": "Polygon", "coordinates": ` + c + `}`)); err == nil {
+			t.Errorf("%s: expected error for degenerate polygon", c)
+		}
+	}
+}
+
+func TestParseKML(t *testing.T) {
+	g, err := ParseKML([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Folder>
+      <Placemark>
+        <name> VFR1 </name>
+        <Point><coordinates>-73.5,40.5,0</coordinates></Point>
+      </Placemark>
+      <Placemark>
+        <name>Corridor</name>
+        <MultiGeometry>
+          <LineString><coordinates>-74,40 -73,41</coordinates></LineString>
+          <Polygon>
+            <outerBoundaryIs><LinearRing><coordinates>
+              -74,40 -73,40 -73,41 -74,40
+            </coordinates></LinearRing></outerBoundaryIs>
+          </Polygon>
+        </MultiGeometry>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(g.Points) != 1 || g.Points[0].Label != "VFR1" || g.Points[0].P != (Point2LL{-73.5, 40.5}) {
+		t.Errorf("unexpected points: %+v", g.Points)
+	}
+	if len(g.Lines) != 1 || len(g.Lines[0]) != 2 {
+		t.Errorf("unexpected lines: %+v", g.Lines)
+	}
+	if len(g.Polygons) != 1 || len(g.Polygons[0]) != 1 || len(g.Polygons[0][0]) != 4 {
+		t.Errorf("unexpected polygons: %+v", g.Polygons)
+	}
+
+	if _, err := ParseKML([]byte(`<kml><Placemark><Polygon><outerBoundaryIs><LinearRing><coordinates/>` +
+		`</LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`)); err == nil {
+		t.Errorf("expected error for empty polygon")
+	}
+}
@@ -0,0 +1,116 @@
+// ownership.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file uses the .ese file's sectors and their owners to determine
+// which online controller should be working each aircraft and to find
+// aircraft that aren't tuned to that controller's frequency.
+
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// FrequencyCheck is the result of checking an aircraft's tuned
+// frequencies against the airspace it is in.
+type FrequencyCheck struct {
+	// The staffed sector the aircraft is in and the callsign and
+	// frequency of the controller who owns it; these are empty if the
+	// aircraft isn't in staffed airspace.
+	Sector         string
+	Owner          string
+	OwnerFrequency Frequency
+	// Problem describes what is wrong, e.g. "not on NY_CTR 125.325"; it
+	// is empty if the aircraft is where it should be.
+	Problem string
+}
+
+// CheckFrequency determines which of the given controllers owns the
+// airspace the aircraft is in and whether the aircraft is tuned to their
+// frequency. Aircraft that are inside a staffed sector but not on its
+// owner's frequency are flagged, as are aircraft that are tuned to a
+// controller who owns airspace elsewhere but not where they are.
+//
+// Aircraft on the ground aren't checked, nor are those that aren't tuned
+// to any frequency, since then we don't know what they are listening to.
+// Nothing is flagged if there is no .ese file.
+func (db *StaticDatabase) CheckFrequency(ac *Aircraft, controllers []*Controller) FrequencyCheck {
+	var fc FrequencyCheck
+	if len(db.eseSectors) == 0 || !ac.HaveTrack() || ac.OnGround() || len(ac.TunedFrequencies) == 0 {
+		return fc
+	}
+
+	// Position identifiers -> the controllers working them.
+	online := make(map[string]*Controller)
+	for _, ctrl := range controllers {
+		if pos := db.LookupPosition(ctrl.Callsign, ctrl.Frequency); pos != nil && pos.SectorId != "" {
+			online[pos.SectorId] = ctrl
+		}
+	}
+	isOnline := func(id string) bool { _, ok := online[id]; return ok }
+
+	// Find the controllers who own at least one sector and the owner of
+	// the sector the aircraft is in. If there are multiple staffed sectors
+	// at the aircraft's position, the one with the smallest vertical
+	// extent is used, since lower sectors are generally carved out of
+	// higher ones.
+	owners := make(map[*Controller]interface{})
+	var sector *ESESector
+	p, alt := ac.Position(), ac.Altitude()
+	for i := range db.eseSectors {
+		s := &db.eseSectors[i]
+		id := s.Owner(isOnline)
+		if id == "" {
+			continue
+		}
+		owners[online[id]] = nil
+
+		if alt < s.Floor || alt > s.Ceiling || len(s.Boundary) == 0 || !PointInPolygon(p, s.Boundary) {
+			continue
+		}
+		if sector == nil || s.Ceiling-s.Floor < sector.Ceiling-sector.Floor {
+			sector = s
+			fc.Sector, fc.Owner, fc.OwnerFrequency = s.Name, online[id].Callsign, online[id].Frequency
+		}
+	}
+
+	tuned := func(f Frequency) bool { return Find(ac.TunedFrequencies, f) != -1 }
+	if sector != nil {
+		if !tuned(fc.OwnerFrequency) {
+			fc.Problem = "not on " + fc.Owner + " " + fc.OwnerFrequency.String()
+		}
+		return fc
+	}
+
+	// The aircraft is in unstaffed airspace; if it's tuned to a controller
+	// who owns airspace, it must have left it. Check them in callsign
+	// order so that the result is deterministic.
+	var left []string
+	for ctrl := range owners {
+		if tuned(ctrl.Frequency) {
+			left = append(left, ctrl.Callsign)
+		}
+	}
+	if len(left) > 0 {
+		sort.Strings(left)
+		fc.Problem = "left " + left[0] + " airspace"
+	}
+	return fc
+}
+
+// FrequencyCheck returns the result of StaticDatabase.CheckFrequency for
+// the aircraft with the currently online controllers. The result is
+// cached until the aircraft's position is updated, or for at most 15
+// seconds, so that it's inexpensive to call every frame.
+func (a *Aircraft) FrequencyCheck() FrequencyCheck {
+	now := server.CurrentTime()
+	if a.frequencyCheckTrackTime.Equal(a.Tracks[0].Time) && now.Sub(a.frequencyCheckTime) < 15*time.Second {
+		return a.frequencyCheck
+	}
+
+	a.frequencyCheck = database.CheckFrequency(a, server.GetAllControllers())
+	a.frequencyCheckTime, a.frequencyCheckTrackTime = now, a.Tracks[0].Time
+	return a.frequencyCheck
+}
@@ -0,0 +1,60 @@
+// ownership_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckFrequency(t *testing.T) {
+	square := func(x0, y0, x1, y1 float32) []Point2LL {
+		return []Point2LL{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}
+	}
+	ctr, app := NewFrequency(125.325), NewFrequency(132.8)
+	db := &StaticDatabase{
+		positions: map[string][]Position{
+			"NY_CTR":  {{Callsign: "NY_CTR", Frequency: ctr, SectorId: "N"}},
+			"N90_APP": {{Callsign: "N90_APP", Frequency: app, SectorId: "A"}},
+		},
+		eseSectors: []ESESector{
+			// The approach sector is under the center one; the center
+			// controller owns it if approach is offline.
+			{Name: "CTR", Floor: 0, Ceiling: 60000, Owners: []string{"N"}, Boundary: square(-76, 39, -72, 42)},
+			{Name: "APP", Floor: 0, Ceiling: 17000, Owners: []string{"A", "N"}, Boundary: square(-75, 40, -73, 41)},
+		},
+	}
+	both := []*Controller{{Callsign: "NY_CTR", Frequency: ctr}, {Callsign: "N90_APP", Frequency: app}}
+	ctrOnly := both[:1]
+
+	aircraft := func(p Point2LL, alt int, freqs ...Frequency) *Aircraft {
+		ac := &Aircraft{Callsign: "AAL1", TunedFrequencies: freqs}
+		ac.Tracks[0] = RadarTrack{Position: p, Altitude: alt, Groundspeed: 250}
+		return ac
+	}
+
+	for i, test := range []struct {
+		ac          *Aircraft
+		controllers []*Controller
+		sector      string
+		owner       string
+		problem     string
+	}{
+		{aircraft(Point2LL{-74, 40.5}, 5000, app), both, "APP", "N90_APP", ""},
+		{aircraft(Point2LL{-74, 40.5}, 5000, ctr), both, "APP", "N90_APP", "not on N90_APP 132.800"},
+		{aircraft(Point2LL{-74, 40.5}, 5000, ctr), ctrOnly, "APP", "NY_CTR", ""},
+		{aircraft(Point2LL{-74, 40.5}, 25000, ctr), both, "CTR", "NY_CTR", ""},
+		{aircraft(Point2LL{-80, 30}, 5000, app), both, "", "", "left N90_APP airspace"},
+		// Not tuned to anything; we don't know.
+		{aircraft(Point2LL{-74, 40.5}, 5000), both, "", "", ""},
+		// Nobody online.
+		{aircraft(Point2LL{-74, 40.5}, 5000, app), nil, "", "", ""},
+	} {
+		fc := db.CheckFrequency(test.ac, test.controllers)
+		if fc.Sector != test.sector || fc.Owner != test.owner || fc.Problem != test.problem {
+			t.Errorf("%d: got %+v, expected sector %q owner %q problem %q", i, fc, test.sector,
+				test.owner, test.problem)
+		}
+	}
+}
@@ -239,15 +239,30 @@ func unmarshalPane(paneType string, data []byte) (Pane, error) {
 	case "*main.CLIPane":
 		return unmarshalPaneHelper[*CLIPane](data)
 
+	case "*main.ControllerRosterPane":
+		return unmarshalPaneHelper[*ControllerRosterPane](data)
+
+	case "*main.ETALadderPane":
+		return unmarshalPaneHelper[*ETALadderPane](data)
+
 	case "*main.EmptyPane":
 		return unmarshalPaneHelper[*EmptyPane](data)
 
 	case "*main.FlightPlanPane":
 		return unmarshalPaneHelper[*FlightPlanPane](data)
 
+	case "*main.FlightStripPane":
+		return unmarshalPaneHelper[*FlightStripPane](data)
+
 	case "*main.ImageViewPane":
 		return unmarshalPaneHelper[*ImageViewPane](data)
 
+	case "*main.MessagesPane":
+		return unmarshalPaneHelper[*MessagesPane](data)
+
+	case "*main.NotesPane":
+		return unmarshalPaneHelper[*NotesPane](data)
+
 	case "*main.PluginPane":
 		return unmarshalPaneHelper[*PluginPane](data)
 
@@ -257,6 +272,12 @@ func unmarshalPane(paneType string, data []byte) (Pane, error) {
 	case "*main.TabbedPane":
 		return unmarshalPaneHelper[*TabbedPane](data)
 
+	case "*main.TrafficStatsPane":
+		return unmarshalPaneHelper[*TrafficStatsPane](data)
+
+	case "*main.TrafficTablePane":
+		return unmarshalPaneHelper[*TrafficTablePane](data)
+
 	default:
 		lg.Errorf("%s: Unhandled type in config file", paneType)
 		return NewEmptyPane(), nil // don't crash at least
@@ -271,6 +292,7 @@ type AirportInfoPane struct {
 
 	ShowTime         bool
 	ShowMETAR        bool
+	ShowRunways      bool
 	ShowATIS         bool
 	ShowApproaches   bool
 	ShowRandomOnFreq bool
@@ -282,9 +304,15 @@ type AirportInfoPane struct {
 
 	ControllerFrequency Frequency
 
+	// Only aircraft that pass the filter are listed.
+	Filter AircraftFilter
+
 	lastATIS       map[string][]ATIS
 	seenDepartures map[string]interface{}
 	seenArrivals   map[string]interface{}
+	// callsign -> when and where the aircraft took off or landed
+	departed map[string]runwayMovement
+	landed   map[string]runwayMovement
 
 	FontIdentifier FontIdentifier
 	font           *Font
@@ -302,6 +330,19 @@ type AirportInfoPane struct {
 	drawnApproaches  map[string]map[string]interface{}
 }
 
+type runwayMovement struct {
+	runway string
+	time   time.Time
+}
+
+func (rm runwayMovement) String() string {
+	rwy := rm.runway
+	if rwy == "" {
+		rwy = "?"
+	}
+	return fmt.Sprintf("%3s %s", rwy, rm.time.UTC().Format("1504Z"))
+}
+
 type ApproachFix struct {
 	Fix        string
 	Altitude   int
@@ -347,6 +388,7 @@ func NewAirportInfoPane() *AirportInfoPane {
 		Airports:        make(map[string]interface{}),
 		ShowTime:        true,
 		ShowMETAR:       true,
+		ShowRunways:     true,
 		ShowATIS:        true,
 		ShowDepartures:  true,
 		ShowDeparted:    true,
@@ -361,6 +403,8 @@ func (a *AirportInfoPane) Duplicate(nameAsCopy bool) Pane {
 	dupe.lastATIS = DuplicateMap(a.lastATIS)
 	dupe.seenDepartures = DuplicateMap(a.seenDepartures)
 	dupe.seenArrivals = DuplicateMap(a.seenArrivals)
+	dupe.departed = DuplicateMap(a.departed)
+	dupe.landed = DuplicateMap(a.landed)
 	dupe.eventsId = eventStream.Subscribe()
 	dupe.sb = NewScrollBar(4, false)
 	dupe.cb = CommandBuffer{}
@@ -381,6 +425,12 @@ func (a *AirportInfoPane) Activate() {
 	if a.seenArrivals == nil {
 		a.seenArrivals = make(map[string]interface{})
 	}
+	if a.departed == nil {
+		a.departed = make(map[string]runwayMovement)
+	}
+	if a.landed == nil {
+		a.landed = make(map[string]runwayMovement)
+	}
 	if a.sb == nil {
 		a.sb = NewScrollBar(4, false)
 	}
@@ -605,6 +655,7 @@ func (a *AirportInfoPane) DrawUI() {
 	}
 	imgui.Checkbox("Show time", &a.ShowTime)
 	imgui.Checkbox("Show weather", &a.ShowMETAR)
+	imgui.Checkbox("Show active runways", &a.ShowRunways)
 	imgui.Checkbox("Show ATIS", &a.ShowATIS)
 	imgui.Checkbox("Show randoms on frequency", &a.ShowRandomOnFreq)
 	imgui.SameLine()
@@ -616,6 +667,7 @@ func (a *AirportInfoPane) DrawUI() {
 	imgui.Checkbox("Show arriving aircraft", &a.ShowArrivals)
 	imgui.Checkbox("Show landed aircraft", &a.ShowLanded)
 	imgui.Checkbox("Show controllers", &a.ShowControllers)
+	a.Filter.DrawUI("Filter")
 
 	imgui.Separator()
 	imgui.Text("Active approaches")
@@ -727,6 +779,27 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 					}
 				}
 			}
+
+		case *DepartedEvent:
+			if _, ok := a.Airports[ev.airport]; ok {
+				a.departed[ev.ac.Callsign] = runwayMovement{runway: ev.runway, time: ev.time}
+				globalConfig.AudioSettings.HandleEvent(AudioEventDeparture)
+			}
+
+		case *LandedEvent:
+			if _, ok := a.Airports[ev.airport]; ok {
+				a.landed[ev.ac.Callsign] = runwayMovement{runway: ev.runway, time: ev.time}
+				globalConfig.AudioSettings.HandleEvent(AudioEventLanding)
+			}
+
+		case *GoAroundEvent:
+			if _, ok := a.Airports[ev.airport]; ok {
+				globalConfig.AudioSettings.HandleEvent(AudioEventGoAround)
+			}
+
+		case *RemovedAircraftEvent:
+			delete(a.departed, ev.ac.Callsign)
+			delete(a.landed, ev.ac.Callsign)
 		}
 	}
 
@@ -819,6 +892,33 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 		}
 	}
 
+	if a.ShowRunways && len(a.Airports) > 0 {
+		startLine("")
+		addText(basicStyle, "Runways:")
+		endLine()
+
+		runwayList := func(rwys []string, observed bool) string {
+			if len(rwys) == 0 {
+				return "?"
+			}
+			s := strings.Join(rwys, " ")
+			if !observed {
+				// Only inferred from the wind
+				s += " (wind)"
+			}
+			return s
+		}
+		for _, ap := range SortedMapKeys(a.Airports) {
+			active := runwayTracker.ActiveRunways(ap)
+			startLine("")
+			addText(basicStyle, "\u200a\u200a\u200a  %4s dep %-16s arr %s", ap,
+				runwayList(active.Departing, active.DepartingObserved),
+				runwayList(active.Arriving, active.ArrivingObserved))
+			endLine()
+		}
+		emptyLine()
+	}
+
 	anyApproaches := false
 	for _, ap := range a.activeApproaches {
 		if len(ap) > 0 {
@@ -907,7 +1007,7 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 
 	var departures, airborne, landed, randomOnFreq []*Aircraft
 	for _, ac := range server.GetFilteredAircraft(func(ac *Aircraft) bool {
-		return ac.FlightPlan != nil && !ac.LostTrack(now)
+		return ac.FlightPlan != nil && !ac.LostTrack(now) && a.Filter.Match(ac)
 	}) {
 		if _, ok := a.Airports[ac.FlightPlan.DepartureAirport]; ok {
 			if ac.OnGround() {
@@ -1124,6 +1224,11 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 			addText(basicStyle, "%-8s %s %s %8s %6s %6s %14s", ac.Callsign, rules(ac),
 				ac.FlightPlan.DepartureAirport, ac.FlightPlan.AircraftType,
 				formatAltitude(ac.Altitude()), formatAltitude(ac.FlightPlan.Altitude), route)
+			if dep, ok := a.departed[ac.Callsign]; ok {
+				addText(basicStyle, " %s", dep)
+			} else {
+				addText(basicStyle, " %9s", "")
+			}
 			radioTuned(ac)
 			checkSquawk(ac)
 			endLine()
@@ -1131,7 +1236,8 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 		emptyLine()
 	}
 
-	arrivals := getDistanceSortedArrivals(a.Airports)
+	arrivals := FilterSlice(getDistanceSortedArrivals(a.Airports),
+		func(arr Arrival) bool { return a.Filter.Match(arr.aircraft) })
 	if a.ShowArrivals && len(arrivals) > 0 {
 		startLine("")
 		addText(basicStyle, "Arrivals:")
@@ -1186,6 +1292,11 @@ func (a *AirportInfoPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 			experienceIcon(ac)
 			addText(basicStyle, "%-8s %s %8s", ac.Callsign, ac.FlightPlan.ArrivalAirport,
 				ac.FlightPlan.AircraftType)
+			if ldg, ok := a.landed[ac.Callsign]; ok {
+				addText(basicStyle, " %s", ldg)
+			} else {
+				addText(basicStyle, " %9s", "")
+			}
 			radioTuned(ac)
 			endLine()
 		}
@@ -1433,22 +1544,33 @@ func (fp *FlightPlanPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 // ImageViewPane
 
 type ImageViewPane struct {
-	Directory         string
-	SelectedImage     string
-	ImageCalibrations map[string]*ImageCalibration
-	InvertImages      bool
+	Directory     string
+	SelectedImage string
+	Calibrations  map[string]*ChartCalibration
+	InvertImages  bool
+
+	DrawAircraft    bool
+	AircraftSize    int32
+	ShowCalibration bool
 
-	DrawAircraft bool
-	AircraftSize int32
+	// Two-point calibrations from older configs; they are converted to
+	// Calibrations when the pane is activated.
+	ImageCalibrations map[string]*ImageCalibration `json:",omitempty"`
 
 	scale         float32
 	offset        [2]float32
 	mouseDragging bool
 
+	calibrating      bool
 	enteredFixPos    [2]float32
 	enteredFix       string
 	enteredFixCursor int
 
+	charts       *ChartIndex
+	searching    bool
+	searchText   string
+	searchCursor int
+
 	nImagesLoading int
 	ctx            context.Context
 	cancel         context.CancelFunc
@@ -1462,9 +1584,8 @@ type ImageViewPane struct {
 }
 
 type ImageCalibration struct {
-	Fix     [2]string
-	Pimage  [2][2]float32
-	lastSet int
+	Fix    [2]string
+	Pimage [2][2]float32
 }
 
 type LoadedImage struct {
@@ -1480,21 +1601,25 @@ type ImageViewImage struct {
 
 func NewImageViewPane() *ImageViewPane {
 	return &ImageViewPane{
-		Directory:         "/Users/mmp/vatsim/KPHL",
-		ImageCalibrations: make(map[string]*ImageCalibration),
-		AircraftSize:      16,
+		Directory:    "/Users/mmp/vatsim/KPHL",
+		Calibrations: make(map[string]*ChartCalibration),
+		AircraftSize: 16,
 	}
 }
 
 func (iv *ImageViewPane) Duplicate(nameAsCopy bool) Pane {
 	dupe := &ImageViewPane{
-		Directory:         iv.Directory,
-		SelectedImage:     iv.SelectedImage,
-		ImageCalibrations: DuplicateMap(iv.ImageCalibrations),
-		InvertImages:      iv.InvertImages,
-		DrawAircraft:      iv.DrawAircraft,
-		AircraftSize:      iv.AircraftSize,
-		scrollBar:         NewScrollBar(4, false),
+		Directory:       iv.Directory,
+		SelectedImage:   iv.SelectedImage,
+		Calibrations:    make(map[string]*ChartCalibration),
+		InvertImages:    iv.InvertImages,
+		DrawAircraft:    iv.DrawAircraft,
+		AircraftSize:    iv.AircraftSize,
+		ShowCalibration: iv.ShowCalibration,
+		scrollBar:       NewScrollBar(4, false),
+	}
+	for name, cal := range iv.Calibrations {
+		dupe.Calibrations[name] = cal.Duplicate()
 	}
 	dupe.loadImages()
 	return dupe
@@ -1505,6 +1630,23 @@ func (iv *ImageViewPane) Activate() {
 	iv.expanded = make(map[string]interface{})
 	iv.scale = 1
 
+	if iv.Calibrations == nil {
+		iv.Calibrations = make(map[string]*ChartCalibration)
+	}
+	for name, cal := range iv.ImageCalibrations {
+		if _, ok := iv.Calibrations[name]; ok {
+			continue
+		}
+		c := &ChartCalibration{}
+		for i, fix := range cal.Fix {
+			if fix != "" {
+				c.Points = append(c.Points, ChartCalibrationPoint{Location: fix, Image: cal.Pimage[i]})
+			}
+		}
+		iv.Calibrations[name] = c
+	}
+	iv.ImageCalibrations = nil
+
 	iv.loadImages()
 }
 
@@ -1519,6 +1661,7 @@ func (iv *ImageViewPane) loadImages() {
 	loadImage(iv.ctx, path.Join(iv.Directory, iv.SelectedImage), iv.InvertImages, iv.loadChan)
 
 	// Now kick off loading the rest asynchronously
+	var names []string
 	err := filepath.WalkDir(iv.Directory, func(filename string, entry os.DirEntry, err error) error {
 		// TODO: figure out how to handle this... It's likely a permissions issue or the like.
 		if err != nil {
@@ -1529,6 +1672,9 @@ func (iv *ImageViewPane) loadImages() {
 		if entry.IsDir() || (ext != ".PNG" && ext != ".JPG" && ext != ".JPEG") {
 			return nil
 		}
+		if rel, err := filepath.Rel(iv.Directory, filename); err == nil {
+			names = append(names, rel)
+		}
 		if entry.Name() == iv.SelectedImage {
 			// Already loaded it
 			return nil
@@ -1541,6 +1687,30 @@ func (iv *ImageViewPane) loadImages() {
 	if err != nil {
 		lg.Errorf("%s: %v", iv.Directory, err)
 	}
+
+	iv.charts = NewChartIndex(iv.Directory, names)
+}
+
+// SelectImage shows the specified image, which is given relative to the
+// pane's directory.
+func (iv *ImageViewPane) SelectImage(name string) {
+	iv.SelectedImage = name
+	iv.showImageList = false
+	iv.scale = 1
+	iv.offset = [2]float32{0, 0}
+}
+
+func (iv *ImageViewPane) startSearch() {
+	iv.searching = true
+	iv.searchText = ""
+	iv.searchCursor = 0
+	wmTakeKeyboardFocus(iv, true)
+}
+
+func (iv *ImageViewPane) endSearch() {
+	iv.searching = false
+	iv.searchText = ""
+	wmReleaseKeyboardFocus()
 }
 
 func loadImage(ctx context.Context, path string, invertImage bool, loadChan chan LoadedImage) {
@@ -1678,6 +1848,83 @@ func (iv *ImageViewPane) DrawUI() {
 	}
 
 	// TODO?: refresh button
+
+	if iv.SelectedImage != "" && imgui.CollapsingHeader("Calibration") {
+		iv.drawCalibrationUI()
+	}
+}
+
+func (iv *ImageViewPane) drawCalibrationUI() {
+	imgui.Text("Right-click in the image and enter a fix, navaid, airport, or latitude-longitude to add a point.")
+	imgui.Checkbox("Show calibration points", &iv.ShowCalibration)
+
+	cal, ok := iv.Calibrations[iv.SelectedImage]
+	if ok {
+		imgui.Checkbox("Projective fit (4+ points; for images scanned at an angle)", &cal.Projective)
+
+		residuals, err := cal.Residuals()
+		flags := imgui.TableFlagsBordersV | imgui.TableFlagsBordersOuterH | imgui.TableFlagsRowBg
+		remove := -1
+		if imgui.BeginTableV("calibration", 3, flags, imgui.Vec2{}, 0) {
+			imgui.TableSetupColumn("Location")
+			imgui.TableSetupColumn("Error")
+			imgui.TableSetupColumn("")
+			imgui.TableHeadersRow()
+
+			for i := range cal.Points {
+				imgui.PushID(fmt.Sprintf("%d", i))
+				imgui.TableNextRow()
+				imgui.TableNextColumn()
+				imgui.InputTextV("##location", &cal.Points[i].Location, imgui.InputTextFlagsCharsUppercase, nil)
+				imgui.TableNextColumn()
+				if err != nil {
+					imgui.Text("-")
+				} else if residuals[i] < 0 {
+					imgui.Text(FontAwesomeIconExclamationTriangle + " unknown location")
+				} else {
+					imgui.Text(fmt.Sprintf("%.2f nm", residuals[i]))
+				}
+				imgui.TableNextColumn()
+				if imgui.Button(FontAwesomeIconTrash) {
+					remove = i
+				}
+				imgui.PopID()
+			}
+			imgui.EndTable()
+		}
+		if remove != -1 {
+			cal.Points = DeleteSliceElement(cal.Points, remove)
+		}
+
+		if err != nil {
+			imgui.Text(err.Error())
+		} else {
+			var sum float32
+			n := 0
+			for _, r := range residuals {
+				if r >= 0 {
+					sum += r * r
+					n++
+				}
+			}
+			imgui.Text(fmt.Sprintf("RMS error: %.2f nm", sqrt(sum/float32(n))))
+		}
+
+		if imgui.Button("Clear calibration") {
+			delete(iv.Calibrations, iv.SelectedImage)
+		}
+	}
+
+	// Images with the same layout (e.g., successive revisions of a
+	// chart) can share a calibration.
+	if imgui.BeginCombo("Copy calibration from", "") {
+		for _, name := range SortedMapKeys(iv.Calibrations) {
+			if name != iv.SelectedImage && imgui.Selectable(name) {
+				iv.Calibrations[iv.SelectedImage] = iv.Calibrations[name].Duplicate()
+			}
+		}
+		imgui.EndCombo()
+	}
 }
 
 func (iv *ImageViewPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
@@ -1708,13 +1955,17 @@ func (iv *ImageViewPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 
 	ctx.SetWindowCoordinateMatrices(cb)
 
-	enteringCalibration := wm.keyboardFocusPane == iv
+	if wm.keyboardFocusPane != iv {
+		iv.calibrating, iv.searching = false, false
+	}
+	wasSearching := iv.searching
 
-	if !enteringCalibration && iv.showImageList {
+	if !iv.calibrating && iv.showImageList {
 		iv.drawImageList(ctx, cb)
 	} else {
 		quad := iv.drawImage(ctx, cb)
 		iv.drawAircraft(ctx, cb)
+		iv.drawCalibrationPoints(ctx, cb)
 		iv.handleCalibration(ctx, cb)
 
 		if ctx.mouse != nil {
@@ -1752,7 +2003,8 @@ func (iv *ImageViewPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 		}
 	}
 
-	if ctx.keyboard != nil && ctx.mouse != nil { // check mouse since otherwise multiple panes pick up the message
+	// check mouse since otherwise multiple panes pick up the message
+	if ctx.keyboard != nil && ctx.mouse != nil && !wasSearching {
 		if _, ok := ctx.keyboard.Pressed[KeyEscape]; ok {
 			iv.showImageList = !iv.showImageList
 		}
@@ -1764,8 +2016,21 @@ func (iv *ImageViewPane) drawImageList(ctx *PaneContext, cb *CommandBuffer) {
 	indent := float32(int(font.size / 2)) // left and top spacing
 	lineHeight := font.size
 
+	var results, suggestions []ChartIndexEntry
+	showResults := iv.searching && iv.searchText != ""
+	nLines := 1 // search
+	if showResults {
+		results = iv.charts.Search(iv.searchText)
+		nLines += max(1, len(results))
+	} else {
+		if suggestions = iv.charts.SuggestedCharts(positionConfig.selectedAircraft); len(suggestions) > 0 {
+			nLines += len(suggestions) + 2
+		}
+		nLines += len(iv.loadedImages)
+	}
+
 	nVisibleLines := (int(ctx.paneExtent.Height()) - font.size) / font.size
-	iv.scrollBar.Update(len(iv.loadedImages), nVisibleLines, ctx)
+	iv.scrollBar.Update(nLines, nVisibleLines, ctx)
 	iv.scrollBar.Draw(ctx, cb)
 	textOffset := iv.scrollBar.Offset()
 
@@ -1799,6 +2064,52 @@ func (iv *ImageViewPane) drawImageList(ctx *PaneContext, cb *CommandBuffer) {
 		return ctx.mouse.Released[0]
 	}
 
+	// Search for charts by airport and procedure
+	if iv.searching {
+		p := td.AddText(" Search: ", pText, style)
+		cursorStyle := TextStyle{Font: font, Color: ctx.cs.Background,
+			DrawBackground: true, BackgroundColor: ctx.cs.Text}
+		exit, _ := uiDrawTextEdit(&iv.searchText, &iv.searchCursor, ctx.keyboard, p, style, cursorStyle, cb)
+		pText[1] -= float32(lineHeight)
+
+		if exit == TextEditReturnEnter && len(results) > 0 {
+			iv.SelectImage(results[0].Filename)
+			iv.endSearch()
+		} else if ctx.keyboard != nil && ctx.keyboard.IsPressed(KeyEscape) {
+			iv.endSearch()
+		}
+	} else {
+		if selected() {
+			iv.startSearch()
+		}
+		pText = td.AddText(" Search...\n", pText, style)
+	}
+
+	offerCharts := func(charts []ChartIndexEntry) {
+		for _, e := range charts {
+			if selected() {
+				iv.SelectImage(e.Filename)
+				if iv.searching {
+					iv.endSearch()
+				}
+			}
+			pText = td.AddText("  "+e.Label()+"\n", pText, Select(e.Filename == iv.SelectedImage, selectedStyle, style))
+		}
+	}
+	if showResults {
+		if len(results) == 0 {
+			pText = td.AddText("  (no matching charts)\n", pText, style)
+		}
+		offerCharts(results)
+		td.GenerateCommands(cb)
+		return
+	}
+	if len(suggestions) > 0 {
+		pText = td.AddText(" Charts for "+positionConfig.selectedAircraft.Callsign+":\n", pText, style)
+		offerCharts(suggestions)
+		pText = td.AddText("\n", pText, style)
+	}
+
 	for _, name := range SortedMapKeys(iv.loadedImages) {
 		var dirs []string
 		if runtime.GOOS == "windows" {
@@ -1854,10 +2165,7 @@ func (iv *ImageViewPane) drawImageList(ctx *PaneContext, cb *CommandBuffer) {
 		}
 		if offerImage {
 			if selected() {
-				iv.SelectedImage = name
-				iv.showImageList = false
-				iv.scale = 1
-				iv.offset = [2]float32{0, 0}
+				iv.SelectImage(name)
 			}
 			indent := fmt.Sprintf("%*c", 2*len(dirs)+1, ' ')
 			if atRoot {
@@ -1900,6 +2208,7 @@ func (iv *ImageViewPane) drawImage(ctx *PaneContext, cb *CommandBuffer) Extent2D
 		iv.enteredFixPos[1] /= e.Height()
 		iv.enteredFix = ""
 		iv.enteredFixCursor = 0
+		iv.calibrating = true
 		wmTakeKeyboardFocus(iv, true)
 	}
 
@@ -1936,53 +2245,28 @@ func (iv *ImageViewPane) drawAircraft(ctx *PaneContext, cb *CommandBuffer) {
 		return
 	}
 
-	cal, ok := iv.ImageCalibrations[iv.SelectedImage]
+	cal, ok := iv.Calibrations[iv.SelectedImage]
 	if !ok {
 		return
 	}
-
-	var pll [2]Point2LL
-	if pll[0], ok = database.Locate(cal.Fix[0]); !ok {
-		return
-	}
-	if pll[1], ok = database.Locate(cal.Fix[1]); !ok {
+	llFromImage, err := cal.LatLongFromImage()
+	if err != nil {
 		return
 	}
+	imageFromLatLong := llFromImage.Inverse()
 
-	var image *ImageViewImage
-	if image, ok = iv.loadedImages[iv.SelectedImage]; !ok {
+	image, ok := iv.loadedImages[iv.SelectedImage]
+	if !ok {
 		return
 	}
-
-	// Find the  window coordinates of the marked points
-	var pw [2][2]float32
 	e := iv.getImageExtent(image, ctx)
-	for i := 0; i < 2; i++ {
-		pw[i] = e.Lerp(cal.Pimage[i])
-	}
-
-	// rotate to align
-	llTheta := atan2(pll[1][1]-pll[0][1], pll[1][0]-pll[0][0])
-	wTheta := atan2(pw[1][1]-pw[0][1], pw[1][0]-pw[0][0])
-	scale := distance2f(pw[0], pw[1]) / distance2f(pll[0], pll[1])
-
-	windowFromLatLong := Identity3x3().
-		// translate so that the origin is at pw[0]
-		Translate(pw[0][0], pw[0][1]).
-		// scale it so that the second points line up
-		Scale(scale, scale).
-		// rotate to align the vector from p0 to p1 in texture space
-		// with the vector from p0 to p1 in window space
-		Rotate(wTheta-llTheta).
-		// translate so pll[0] is the origin
-		Translate(-pll[0][0], -pll[0][1])
 
 	var icons []PlaneIconSpec
 	// FIXME: draw in consistent order
 	for _, ac := range server.GetAllAircraft() {
 		// FIXME: cull based on altitude range
 		icons = append(icons, PlaneIconSpec{
-			P:       windowFromLatLong.TransformPoint(ac.Position()),
+			P:       e.Lerp(projectPoint(imageFromLatLong, ac.Position())),
 			Heading: ac.Heading(),
 			Size:    float32(iv.AircraftSize)})
 	}
@@ -1990,13 +2274,12 @@ func (iv *ImageViewPane) drawAircraft(ctx *PaneContext, cb *CommandBuffer) {
 }
 
 func (iv *ImageViewPane) handleCalibration(ctx *PaneContext, cb *CommandBuffer) {
-	enteringCalibration := wm.keyboardFocusPane == iv
-
-	if !enteringCalibration {
+	if !iv.calibrating {
 		return
 	}
 
 	if ctx.keyboard != nil && ctx.keyboard.IsPressed(KeyEscape) {
+		iv.calibrating = false
 		wmReleaseKeyboardFocus()
 		return
 	}
@@ -2005,7 +2288,7 @@ func (iv *ImageViewPane) handleCalibration(ctx *PaneContext, cb *CommandBuffer)
 	pInput := [2]float32{10, 20}
 	td := GetTextDrawBuilder()
 	defer ReturnTextDrawBuilder(td)
-	if _, ok := database.Locate(iv.enteredFix); iv.enteredFix != "" && !ok {
+	if _, ok := locateCalibrationPoint(iv.enteredFix); iv.enteredFix != "" && !ok {
 		style := TextStyle{Font: ui.fixedFont, Color: ctx.cs.Error}
 		pInput = td.AddText(FontAwesomeIconExclamationTriangle+" ", pInput, style)
 	}
@@ -2020,35 +2303,61 @@ func (iv *ImageViewPane) handleCalibration(ctx *PaneContext, cb *CommandBuffer)
 		inputStyle, cursorStyle, cb)
 	iv.enteredFix = strings.ToUpper(iv.enteredFix)
 
-	if exit == TextEditReturnEnter {
-		cal, ok := iv.ImageCalibrations[iv.SelectedImage]
+	if exit == TextEditReturnEnter && iv.enteredFix != "" {
+		cal, ok := iv.Calibrations[iv.SelectedImage]
 		if !ok {
-			cal = &ImageCalibration{}
-			iv.ImageCalibrations[iv.SelectedImage] = cal
+			cal = &ChartCalibration{}
+			iv.Calibrations[iv.SelectedImage] = cal
 		}
 
-		for i, fix := range cal.Fix {
-			if fix == iv.enteredFix {
+		for i := range cal.Points {
+			if cal.Points[i].Location == iv.enteredFix {
 				// new location for existing one
-				cal.Pimage[i] = iv.enteredFixPos
-				return
-			}
-		}
-		// find a slot. any unset?
-		for i, fix := range cal.Fix {
-			if fix == "" {
-				cal.Pimage[i] = iv.enteredFixPos
-				cal.Fix[i] = iv.enteredFix
+				cal.Points[i].Image = iv.enteredFixPos
 				return
 			}
 		}
+		cal.Points = append(cal.Points, ChartCalibrationPoint{Location: iv.enteredFix, Image: iv.enteredFixPos})
+	}
+}
+
+// drawCalibrationPoints marks the selected image's calibration points,
+// labeled with their residual errors.
+func (iv *ImageViewPane) drawCalibrationPoints(ctx *PaneContext, cb *CommandBuffer) {
+	cal, ok := iv.Calibrations[iv.SelectedImage]
+	if !iv.ShowCalibration || !ok {
+		return
+	}
+	image, ok := iv.loadedImages[iv.SelectedImage]
+	if !ok {
+		return
+	}
+	e := iv.getImageExtent(image, ctx)
+	residuals, err := cal.Residuals()
 
-		// alternate between the two
-		i := (cal.lastSet + 1) % len(cal.Fix)
-		cal.Pimage[i] = iv.enteredFixPos
-		cal.Fix[i] = iv.enteredFix
-		cal.lastSet = i
+	ld := GetLinesDrawBuilder()
+	defer ReturnLinesDrawBuilder(ld)
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	for i, pt := range cal.Points {
+		p := e.Lerp(pt.Image)
+		ld.AddLine(add2f(p, [2]float32{-5, 0}), add2f(p, [2]float32{5, 0}))
+		ld.AddLine(add2f(p, [2]float32{0, -5}), add2f(p, [2]float32{0, 5}))
+
+		label, color := pt.Location, ctx.cs.Text
+		if err == nil && residuals[i] >= 0 {
+			label += fmt.Sprintf(" %.2fnm", residuals[i])
+		} else if err == nil {
+			color = ctx.cs.Error
+		}
+		td.AddText(label, add2f(p, [2]float32{6, -4}),
+			TextStyle{Font: ui.fixedFont, Color: color, DrawBackground: true, BackgroundColor: ctx.cs.Background})
 	}
+
+	cb.SetRGB(ctx.cs.Text)
+	ld.GenerateCommands(cb)
+	td.GenerateCommands(cb)
 }
 
 ///////////////////////////////////////////////////////////////////////////
@@ -137,6 +137,18 @@ type PluginUpdate struct {
 	RemovedAircraft    []string
 	Controllers        []*Controller
 	RemovedControllers []string
+	Movements          []PluginMovement
+}
+
+// PluginMovement describes an aircraft takeoff, landing, go-around, or
+// entry into a hold; Type is one of "departed", "landed", "goaround", or
+// "holding".
+type PluginMovement struct {
+	Type     string
+	Callsign string
+	Airport  string
+	Runway   string
+	Time     time.Time
 }
 
 type DrawCommand interface {
@@ -174,7 +186,7 @@ func (pp *PluginPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 
 	update.Context.NmPerLatitude = database.NmPerLatitude
 	update.Context.NmPerLongitude = database.NmPerLongitude
-	update.Context.MagneticVariation = database.MagneticVariation
+	update.Context.MagneticVariation = database.MagneticCorrection(database.defaultCenter)
 
 	update.Context.CurrentTime = server.CurrentTime()
 
@@ -201,6 +213,22 @@ func (pp *PluginPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 		case *RemovedControllerEvent:
 			removedControllers[e.Controller.Callsign] = nil
 
+		case *DepartedEvent:
+			update.Movements = append(update.Movements, PluginMovement{Type: "departed",
+				Callsign: e.ac.Callsign, Airport: e.airport, Runway: e.runway, Time: e.time})
+
+		case *LandedEvent:
+			update.Movements = append(update.Movements, PluginMovement{Type: "landed",
+				Callsign: e.ac.Callsign, Airport: e.airport, Runway: e.runway, Time: e.time})
+
+		case *GoAroundEvent:
+			update.Movements = append(update.Movements, PluginMovement{Type: "goaround",
+				Callsign: e.ac.Callsign, Airport: e.airport, Runway: e.runway, Time: e.time})
+
+		case *HoldingEvent:
+			update.Movements = append(update.Movements, PluginMovement{Type: "holding",
+				Callsign: e.ac.Callsign, Airport: e.airport, Time: e.time})
+
 		case *ReceivedMETAREvent:
 			// TODO: note also we need to send the current initial...
 			// (may need some more ATCControl interface support...)
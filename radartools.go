@@ -260,6 +260,7 @@ type CRDAConfig struct {
 	Airport                  string
 	PrimaryRunway            string
 	SecondaryRunway          string
+	UseActiveRunways         bool
 	Mode                     int
 	TieStaggerDistance       float32
 	ShowGhostsOnPrimary      bool
@@ -295,12 +296,35 @@ func (c *CRDAConfig) getRunway(n string) *Runway {
 	return nil
 }
 
+// activeRunways returns a pair of intersecting runways from the airport's
+// currently active arrival runways, if there are two such runways.
+func (c *CRDAConfig) activeRunways() (primary string, secondary string) {
+	active := runwayTracker.ActiveRunways(c.Airport).Arriving
+	for i, a := range active {
+		for _, b := range active[i+1:] {
+			ra, rb := c.getRunway(a), c.getRunway(b)
+			if ra == nil || rb == nil {
+				continue
+			}
+			if _, ok := runwayIntersection(ra, rb); ok {
+				return a, b
+			}
+		}
+	}
+	return "", ""
+}
+
 func (c *CRDAConfig) getRunways() (ghostSource *Runway, ghostDestination *Runway) {
+	primary, secondary := c.PrimaryRunway, c.SecondaryRunway
+	if c.UseActiveRunways {
+		primary, secondary = c.activeRunways()
+	}
+
 	for i, rwy := range database.runways[c.Airport] {
-		if rwy.Number == c.PrimaryRunway {
+		if rwy.Number == primary {
 			ghostSource = &database.runways[c.Airport][i]
 		}
-		if rwy.Number == c.SecondaryRunway {
+		if rwy.Number == secondary {
 			ghostDestination = &database.runways[c.Airport][i]
 		}
 	}
@@ -313,8 +337,9 @@ func (c *CRDAConfig) getRunways() (ghostSource *Runway, ghostDestination *Runway
 }
 
 func runwayIntersection(a *Runway, b *Runway) (Point2LL, bool) {
-	p1, p2 := ll2nm(a.Threshold), ll2nm(a.End)
-	p3, p4 := ll2nm(b.Threshold), ll2nm(b.End)
+	nmPerLongitude := nmPerLongitudeAt(a.Threshold[1])
+	p1, p2 := ll2nm(a.Threshold, nmPerLongitude), ll2nm(a.End, nmPerLongitude)
+	p3, p4 := ll2nm(b.Threshold, nmPerLongitude), ll2nm(b.End, nmPerLongitude)
 	p, ok := LineLineIntersect(p1, p2, p3, p4)
 
 	centroid := mid2f(mid2f(p1, p2), mid2f(p3, p4))
@@ -324,7 +349,7 @@ func runwayIntersection(a *Runway, b *Runway) (Point2LL, bool) {
 		ok = false
 	}
 
-	return nm2ll(p), ok
+	return nm2ll(p, nmPerLongitude), ok
 }
 
 func (c *CRDAConfig) GetGhost(ac *Aircraft) *Aircraft {
@@ -356,7 +381,7 @@ func (c *CRDAConfig) GetGhost(ac *Aircraft) *Aircraft {
 	// Is it on the glideslope?
 	// Laterally: compute the heading to the threshold and compare to the
 	// glideslope's lateral spread.
-	h := headingp2ll(ac.Position(), src.Threshold, database.MagneticVariation)
+	h := ac.HeadingTo(src.Threshold)
 	if abs(h-src.Heading) > c.GlideslopeLateralSpread {
 		return nil
 	}
@@ -392,10 +417,11 @@ func (c *CRDAConfig) GetGhost(ac *Aircraft) *Aircraft {
 	// Now we just need to update the track positions to be those for
 	// the ghost. We'll again do this in nm space before going to
 	// lat-long in the end.
-	pi := ll2nm(pIntersect)
+	nmPerLongitude := nmPerLongitudeAt(pIntersect[1])
+	pi := ll2nm(pIntersect, nmPerLongitude)
 	for i, t := range ghost.Tracks {
 		// Vector from the intersection point to the track location
-		v := sub2f(ll2nm(t.Position), pi)
+		v := sub2f(ll2nm(t.Position, nmPerLongitude), pi)
 
 		// For tie mode, offset further by the specified distance.
 		if c.Mode == CRDAModeTie {
@@ -411,7 +437,7 @@ func (c *CRDAConfig) GetGhost(ac *Aircraft) *Aircraft {
 		pr := add2f(pi, vr)
 
 		// TODO: offset it as appropriate
-		ghost.Tracks[i].Position = nm2ll(pr)
+		ghost.Tracks[i].Position = nm2ll(pr, nmPerLongitude)
 	}
 	return &ghost
 }
@@ -443,8 +469,9 @@ func (c *CRDAConfig) DrawRegions(ctx *PaneContext, transforms ScopeTransformatio
 
 	// we have the runway heading, but we want to go the opposite direction
 	// and then +/- HeadingTolerance.
-	rota := src.Heading + 180 - c.GlideslopeLateralSpread - database.MagneticVariation
-	rotb := src.Heading + 180 + c.GlideslopeLateralSpread - database.MagneticVariation
+	magCorrection := database.MagneticCorrection(src.Threshold)
+	rota := src.Heading + 180 - c.GlideslopeLateralSpread - magCorrection
+	rotb := src.Heading + 180 + c.GlideslopeLateralSpread - magCorrection
 
 	// Lay out the vectors in nm space, not lat-long
 	sina, cosa := sin(radians(rota)), cos(radians(rota))
@@ -456,7 +483,8 @@ func (c *CRDAConfig) DrawRegions(ctx *PaneContext, transforms ScopeTransformatio
 	vb := scale2f([2]float32{sinb, cosb}, dist)
 
 	// Over to lat-long to draw the lines
-	vall, vbll := nm2ll(va), nm2ll(vb)
+	nmPerLongitude := nmPerLongitudeAt(src.Threshold[1])
+	vall, vbll := nm2ll(va, nmPerLongitude), nm2ll(vb, nmPerLongitude)
 	ld := GetColoredLinesDrawBuilder()
 	defer ReturnColoredLinesDrawBuilder(ld)
 	ld.AddLine(src.Threshold, add2ll(src.Threshold, vall), ctx.cs.Caution)
@@ -479,6 +507,18 @@ func (c *CRDAConfig) DrawUI() bool {
 	} else {
 		sort.Slice(runways, func(i, j int) bool { return runways[i].Number < runways[j].Number })
 
+		if imgui.Checkbox("Use active arrival runways", &c.UseActiveRunways) {
+			updateGhosts = true
+		}
+		if c.UseActiveRunways {
+			if p, s := c.activeRunways(); p == "" {
+				imgui.Text("No intersecting active arrival runways")
+			} else {
+				imgui.Text("Active runways: " + p + ", " + s)
+			}
+		}
+
+		uiStartDisable(c.UseActiveRunways)
 		primary, secondary := c.getRunway(c.PrimaryRunway), c.getRunway(c.SecondaryRunway)
 		if imgui.BeginComboV("Primary runway", c.PrimaryRunway, imgui.ComboFlagsHeightLarge) {
 			if imgui.SelectableV("(None)", c.PrimaryRunway == "", 0, imgui.Vec2{}) {
@@ -528,6 +568,8 @@ func (c *CRDAConfig) DrawUI() bool {
 			}
 			imgui.EndCombo()
 		}
+		uiEndDisable(c.UseActiveRunways)
+
 		if imgui.Checkbox("Ghosts on primary", &c.ShowGhostsOnPrimary) {
 			updateGhosts = true
 		}
@@ -561,13 +603,16 @@ const (
 	DatablockFormatGround
 	DatablockFormatTower
 	DatablockFormatFull
+	// DatablockFormatTemplate uses a user-defined template; see
+	// datablock.go.
+	DatablockFormatTemplate
 	DatablockFormatCount
 )
 
 type DatablockFormat int
 
 func (d DatablockFormat) String() string {
-	return [...]string{"None", "Simple", "Ground", "Tower", "Full"}[d]
+	return [...]string{"None", "Simple", "Ground", "Tower", "Full", "Template"}[d]
 }
 
 func (d *DatablockFormat) DrawUI() bool {
@@ -586,7 +631,8 @@ func (d *DatablockFormat) DrawUI() bool {
 }
 
 func (d DatablockFormat) Format(ac *Aircraft, duplicateSquawk bool, flashcycle int) string {
-	if d == DatablockFormatNone {
+	if d == DatablockFormatNone || d == DatablockFormatTemplate {
+		// Templates are handled by FormatDatablockTemplate.
 		return ""
 	}
 
@@ -777,6 +823,10 @@ func DrawCompass(p Point2LL, ctx *PaneContext, rotationAngle float32, font *Font
 	ld := GetColoredLinesDrawBuilder()
 	defer ReturnColoredLinesDrawBuilder(ld)
 
+	// The scope is oriented using the magnetic variation at its center,
+	// which may differ from the variation at p.
+	rotationAngle += transforms.magCorrection - database.MagneticCorrection(p)
+
 	// Draw lines at a 5 degree spacing.
 	for h := float32(5); h <= 360; h += 5 {
 		hr := h + rotationAngle
@@ -872,6 +922,13 @@ type ScopeTransformations struct {
 	ndcFromLatLong                       Matrix3
 	ndcFromWindow                        Matrix3
 	latLongFromWindow, windowFromLatLong Matrix3
+
+	// The magnetic correction at the scope's center, which is used to
+	// orient it.
+	magCorrection float32
+	// The scope uses a flat-earth projection with the scale at its
+	// center.
+	nmPerLongitude float32
 }
 
 // GetScopeTransformations returns a ScopeTransformations object
@@ -880,14 +937,16 @@ type ScopeTransformations struct {
 func GetScopeTransformations(ctx *PaneContext, center Point2LL, rangenm float32, rotationAngle float32) ScopeTransformations {
 	width, height := ctx.paneExtent.Width(), ctx.paneExtent.Height()
 	aspect := width / height
+	magCorrection := database.MagneticCorrection(center)
+	nmPerLongitude := nmPerLongitudeAt(center[1])
 	ndcFromLatLong := Identity3x3().
 		// Final orthographic projection including the effect of the
 		// window's aspect ratio.
 		Ortho(-aspect, aspect, -1, 1).
 		// Account for magnetic variation and any user-specified rotation
-		Rotate(-radians(rotationAngle+database.MagneticVariation)).
+		Rotate(-radians(rotationAngle+magCorrection)).
 		// Scale based on range and nm per latitude / longitude
-		Scale(database.NmPerLongitude/rangenm, database.NmPerLatitude/rangenm).
+		Scale(nmPerLongitude/rangenm, nmPerLatitude/rangenm).
 		// Translate to center point
 		Translate(-center[0], -center[1])
 
@@ -904,6 +963,8 @@ func GetScopeTransformations(ctx *PaneContext, center Point2LL, rangenm float32,
 		ndcFromWindow:     ndcFromWindow,
 		latLongFromWindow: latLongFromWindow,
 		windowFromLatLong: windowFromLatLong,
+		magCorrection:     magCorrection,
+		nmPerLongitude:    nmPerLongitude,
 	}
 }
 
@@ -952,7 +1013,7 @@ func (st *ScopeTransformations) LatLongFromWindowV(v [2]float32) Point2LL {
 // nautical miles.
 func (st *ScopeTransformations) PixelDistanceNM() float32 {
 	ll := st.LatLongFromWindowV([2]float32{1, 0})
-	return nmlength2ll(ll)
+	return sqrt(sqr(ll[0]*st.nmPerLongitude) + sqr(ll[1]*nmPerLatitude))
 }
 
 ///////////////////////////////////////////////////////////////////////////
@@ -996,10 +1057,10 @@ func (ml *MeasuringLine) Draw(ctx *PaneContext, font *Font, transforms ScopeTran
 	// distance between the two points in nm
 	p0 := transforms.LatLongFromWindowP(ml.dragStart)
 	p1 := transforms.LatLongFromWindowP(ml.dragEnd)
-	dist := nmdistance2ll(p0, p1)
+	dist, h := ellipsoidalHeading(p0, p1)
 
 	// heading and reciprocal
-	hdg := int(headingp2ll(p0, p1, database.MagneticVariation) + 0.5)
+	hdg := int(h + 0.5)
 	if hdg == 0 {
 		hdg = 360
 	}
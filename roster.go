@@ -0,0 +1,334 @@
+// roster.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// controllersCovering returns the controllers that provide service at the
+// given airport, ordered from the lowest (delivery) to the highest
+// (center) facility.  Local positions are matched by callsign prefix;
+// approach and center controllers are included if the airport is within
+// their scope range.
+func controllersCovering(icao string, controllers []*Controller) []*Controller {
+	ap, ok := database.airports[icao]
+	if !ok {
+		return nil
+	}
+
+	// Local positions generally drop the leading K of US airports
+	// (e.g., "JFK_TWR").
+	prefixes := []string{icao + "_"}
+	if len(icao) == 4 && icao[0] == 'K' {
+		prefixes = append(prefixes, icao[1:]+"_")
+	}
+
+	var covering []*Controller
+	for _, ctrl := range controllers {
+		if ctrl.Frequency == 0 {
+			continue
+		}
+		switch ctrl.Facility {
+		case FacilityDEL, FacilityGND, FacilityTWR:
+			for _, p := range prefixes {
+				if strings.HasPrefix(ctrl.Callsign, p) {
+					covering = append(covering, ctrl)
+					break
+				}
+			}
+		case FacilityAPP, FacilityCTR, FacilityFSS:
+			if !ctrl.Location.IsZero() && ctrl.ScopeRange > 0 &&
+				nmdistance2ll(ctrl.Location, ap.Location) < float32(ctrl.ScopeRange) {
+				covering = append(covering, ctrl)
+			}
+		}
+	}
+
+	sort.SliceStable(covering, func(i, j int) bool {
+		if covering[i].Facility != covering[j].Facility {
+			return covering[i].Facility < covering[j].Facility
+		}
+		return covering[i].Callsign < covering[j].Callsign
+	})
+	return covering
+}
+
+///////////////////////////////////////////////////////////////////////////
+// ControllerRosterPane
+
+const (
+	RosterSortCallsign = iota
+	RosterSortFrequency
+	RosterSortRating
+	RosterSortLogon
+	RosterSortCount
+)
+
+var rosterSortNames = [...]string{"Callsign", "Frequency", "Rating", "Logon time"}
+
+// ControllerRosterPane lists all of the controllers that the server knows
+// about, grouped by facility, along with an optional summary of which
+// controllers cover a given airport.
+type ControllerRosterPane struct {
+	SortBy int
+	// Comma-separated callsign prefixes (e.g., "NY,ZNY,JFK"); if
+	// non-empty, only controllers whose callsigns start with one of them
+	// are listed.
+	Filter string
+	// Facilities that are not shown.
+	HiddenFacilities map[Facility]interface{}
+	ShowATIS         bool
+	ShowObservers    bool
+	// If set, controllers covering this airport are listed at the top.
+	CoverageAirport string
+
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	sb *ScrollBar
+	cb CommandBuffer
+}
+
+func NewControllerRosterPane() *ControllerRosterPane {
+	return &ControllerRosterPane{
+		HiddenFacilities: make(map[Facility]interface{}),
+		ShowATIS:         true,
+	}
+}
+
+func (cr *ControllerRosterPane) Duplicate(nameAsCopy bool) Pane {
+	return &ControllerRosterPane{
+		SortBy:           cr.SortBy,
+		Filter:           cr.Filter,
+		HiddenFacilities: DuplicateMap(cr.HiddenFacilities),
+		ShowATIS:         cr.ShowATIS,
+		ShowObservers:    cr.ShowObservers,
+		CoverageAirport:  cr.CoverageAirport,
+		FontIdentifier:   cr.FontIdentifier,
+		font:             cr.font,
+		sb:               NewScrollBar(4, false),
+	}
+}
+
+func (cr *ControllerRosterPane) Activate() {
+	if cr.font = GetFont(cr.FontIdentifier); cr.font == nil {
+		cr.font = GetDefaultFont()
+		cr.FontIdentifier = cr.font.id
+	}
+	if cr.HiddenFacilities == nil {
+		cr.HiddenFacilities = make(map[Facility]interface{})
+	}
+	if cr.SortBy < 0 || cr.SortBy >= RosterSortCount {
+		cr.SortBy = RosterSortCallsign
+	}
+	if cr.sb == nil {
+		cr.sb = NewScrollBar(4, false)
+	}
+}
+
+func (cr *ControllerRosterPane) Deactivate()                {}
+func (cr *ControllerRosterPane) CanTakeKeyboardFocus() bool { return false }
+
+func (cr *ControllerRosterPane) Name() string {
+	n := "Controller Roster"
+	if cr.Filter != "" {
+		n += ": " + cr.Filter
+	}
+	return n
+}
+
+func (cr *ControllerRosterPane) DrawUI() {
+	if imgui.BeginComboV("Sort by", rosterSortNames[cr.SortBy], imgui.ComboFlagsHeightLarge) {
+		for i, name := range rosterSortNames {
+			if imgui.SelectableV(name, i == cr.SortBy, 0, imgui.Vec2{}) {
+				cr.SortBy = i
+			}
+		}
+		imgui.EndCombo()
+	}
+	imgui.InputTextV("Callsign prefixes", &cr.Filter, imgui.InputTextFlagsCharsUppercase, nil)
+	if imgui.IsItemHovered() {
+		imgui.SetTooltip("Comma-separated list of callsign prefixes (e.g., ARTCC or airport identifiers) to show")
+	}
+
+	imgui.Text("Facilities:")
+	for f := Facility(FacilityFSS); f <= FacilityCTR; f++ {
+		_, hidden := cr.HiddenFacilities[f]
+		show := !hidden
+		imgui.SameLine()
+		if imgui.Checkbox(f.String(), &show) {
+			if show {
+				delete(cr.HiddenFacilities, f)
+			} else {
+				cr.HiddenFacilities[f] = nil
+			}
+		}
+	}
+	imgui.Checkbox("Show observers", &cr.ShowObservers)
+	imgui.Checkbox("Show controller ATIS", &cr.ShowATIS)
+
+	imgui.InputTextV("Coverage for airport", &cr.CoverageAirport, imgui.InputTextFlagsCharsUppercase, nil)
+	if cr.CoverageAirport != "" {
+		if _, ok := database.airports[cr.CoverageAirport]; !ok {
+			imgui.Text(cr.CoverageAirport + ": unknown airport")
+		}
+	}
+
+	if newFont, changed := DrawFontPicker(&cr.FontIdentifier, "Font"); changed {
+		cr.font = newFont
+	}
+}
+
+// visible returns true if the controller should be listed given the
+// pane's filter settings.
+func (cr *ControllerRosterPane) visible(ctrl *Controller) bool {
+	if ctrl.Facility == FacilityOBS {
+		return cr.ShowObservers
+	}
+	if _, ok := cr.HiddenFacilities[ctrl.Facility]; ok {
+		return false
+	}
+	if cr.Filter == "" {
+		return true
+	}
+	for _, p := range strings.Split(cr.Filter, ",") {
+		if p = strings.TrimSpace(p); p != "" && strings.HasPrefix(ctrl.Callsign, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cr *ControllerRosterPane) sort(controllers []*Controller) {
+	sort.Slice(controllers, func(i, j int) bool {
+		a, b := controllers[i], controllers[j]
+		switch cr.SortBy {
+		case RosterSortFrequency:
+			if a.Frequency != b.Frequency {
+				return a.Frequency < b.Frequency
+			}
+		case RosterSortRating:
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+		case RosterSortLogon:
+			if !a.Logon.Equal(b.Logon) {
+				return a.Logon.Before(b.Logon)
+			}
+		}
+		return a.Callsign < b.Callsign
+	})
+}
+
+func (cr *ControllerRosterPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	cs := ctx.cs
+	style := TextStyle{Font: cr.font, Color: cs.Text}
+	headerStyle := TextStyle{Font: cr.font, Color: cs.TextHighlight}
+	reliefStyle := TextStyle{Font: cr.font, Color: cs.TextError}
+	dimStyle := TextStyle{Font: cr.font, Color: cs.TextDisabled}
+
+	type rosterLine struct {
+		text  string
+		style TextStyle
+	}
+	var lines []rosterLine
+	addLine := func(s TextStyle, f string, args ...interface{}) {
+		lines = append(lines, rosterLine{text: fmt.Sprintf(f, args...), style: s})
+	}
+
+	now := server.CurrentTime()
+	controllerLine := func(ctrl *Controller) (string, TextStyle) {
+		pos := "      "
+		if p := ctrl.GetPosition(); p != nil {
+			pos = fmt.Sprintf("%-3s %-2s", p.SectorId, p.Scope)
+		}
+		min := int(now.Sub(ctrl.Logon).Round(time.Minute).Minutes())
+		relief := Select(ctrl.RequestRelief, "RELIEF", "")
+		text := fmt.Sprintf("  %-12s %s %-4s %s %02d:%02d %-6s %s", ctrl.Callsign, ctrl.Frequency,
+			ctrl.Rating, pos, min/60, min%60, relief, ctrl.Name)
+		return text, Select(ctrl.RequestRelief, reliefStyle, style)
+	}
+
+	controllers := server.GetAllControllers()
+
+	if cr.CoverageAirport != "" {
+		addLine(headerStyle, "Coverage for %s", cr.CoverageAirport)
+		covering := controllersCovering(cr.CoverageAirport, controllers)
+		for _, f := range []Facility{FacilityDEL, FacilityGND, FacilityTWR, FacilityAPP, FacilityCTR} {
+			// Service at a given level is provided by the lowest staffed
+			// facility at or above it.
+			idx := FindIf(covering, func(c *Controller) bool { return c.Facility >= f })
+			if idx == -1 {
+				addLine(reliefStyle, "  %-9s (unstaffed)", f)
+			} else if covering[idx].Facility == f {
+				addLine(style, "  %-9s %s %s", f, covering[idx].Callsign, covering[idx].Frequency)
+			} else {
+				addLine(dimStyle, "  %-9s %s %s (top-down)", f, covering[idx].Callsign, covering[idx].Frequency)
+			}
+		}
+		lines = append(lines, rosterLine{})
+	}
+
+	byFacility := make(map[Facility][]*Controller)
+	for _, ctrl := range controllers {
+		if cr.visible(ctrl) {
+			byFacility[ctrl.Facility] = append(byFacility[ctrl.Facility], ctrl)
+		}
+	}
+
+	for _, f := range []Facility{FacilityCTR, FacilityAPP, FacilityTWR, FacilityGND, FacilityDEL,
+		FacilityFSS, FacilityOBS, FacilityUndefined} {
+		fc := byFacility[f]
+		if len(fc) == 0 {
+			continue
+		}
+		cr.sort(fc)
+
+		addLine(headerStyle, "%s (%d)", f, len(fc))
+		for _, ctrl := range fc {
+			text, s := controllerLine(ctrl)
+			addLine(s, "%s", text)
+
+			if cr.ShowATIS {
+				if atis := server.GetControllerATIS(ctrl.Callsign); atis != "" {
+					for _, l := range strings.Split(atis, "\n") {
+						addLine(dimStyle, "      %s", l)
+					}
+				}
+			}
+		}
+	}
+	if len(byFacility) == 0 {
+		addLine(dimStyle, "No controllers.")
+	}
+
+	lineHeight := float32(cr.font.size)
+	nVisible := int(ctx.paneExtent.Height()/lineHeight) - 1
+	cr.sb.Update(len(lines), nVisible, ctx)
+	offset := cr.sb.Offset()
+
+	cr.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&cr.cb)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	sz2 := lineHeight / 2
+	y := ctx.paneExtent.Height() - sz2
+	for i := offset; i < len(lines) && i < offset+nVisible; i++ {
+		td.AddText(lines[i].text, [2]float32{sz2, y}, lines[i].style)
+		y -= lineHeight
+	}
+
+	td.GenerateCommands(&cr.cb)
+	cr.sb.Draw(ctx, &cr.cb)
+	cb.Call(cr.cb)
+}
@@ -0,0 +1,230 @@
+// runways.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// RunwayTracker
+
+// RunwayTracker infers which runways are in use at airports by watching
+// the runways that aircraft actually take off from and land on (as
+// reported by the MovementDetector); when there isn't enough recent
+// traffic to go on, it falls back to choosing the runways best aligned
+// with the wind reported in the airport's METAR.
+type RunwayTracker struct {
+	// ICAO code -> recent runway usage, most recent last.
+	observations map[string][]RunwayObservation
+
+	eventsId EventSubscriberId
+}
+
+// RunwayObservation records a single aircraft's use of a runway.
+type RunwayObservation struct {
+	Runway    string
+	Callsign  string
+	Departure bool
+	Time      time.Time
+}
+
+// ActiveRunways summarizes the runways that are believed to be in use at
+// an airport.
+type ActiveRunways struct {
+	Departing []string
+	Arriving  []string
+	// DepartingObserved and ArrivingObserved indicate whether the
+	// corresponding runways were inferred from observed traffic; if
+	// false, they are based on the winds alone.
+	DepartingObserved bool
+	ArrivingObserved  bool
+}
+
+const (
+	// Observations older than this are discarded.
+	runwayObservationWindow = 30 * time.Minute
+	// Aircraft must be within this many degrees of the runway heading to
+	// be considered to be using it.
+	runwayHeadingTolerance = 15
+	// Lateral distance from the extended runway centerline (in nm) for an
+	// aircraft to be considered to be using a runway.
+	runwayCenterlineTolerance = 0.75
+	// Winds lighter than this (in knots) aren't used to choose runways.
+	runwayMinimumWind = 4
+)
+
+func NewRunwayTracker() *RunwayTracker {
+	return &RunwayTracker{
+		observations: make(map[string][]RunwayObservation),
+		eventsId:     eventStream.Subscribe(),
+	}
+}
+
+// Update should be called once per frame, after the MovementDetector has
+// been updated; it records the runway usage implied by aircraft
+// movements.
+func (rt *RunwayTracker) Update(es *EventStream) {
+	for _, event := range es.Get(rt.eventsId) {
+		switch v := event.(type) {
+		case *DepartedEvent:
+			rt.observe(v.airport, v.runway, v.ac.Callsign, true, v.time)
+		case *LandedEvent:
+			rt.observe(v.airport, v.runway, v.ac.Callsign, false, v.time)
+		case *GoAroundEvent:
+			// Still tells us which runway arrivals are using.
+			rt.observe(v.airport, v.runway, v.ac.Callsign, false, v.time)
+		case *ClosedServerConnectionEvent:
+			rt.observations = make(map[string][]RunwayObservation)
+		}
+	}
+}
+
+func (rt *RunwayTracker) observe(icao string, runway string, callsign string, departure bool, t time.Time) {
+	if runway == "" {
+		return
+	}
+
+	obs := FilterSlice(rt.observations[icao], func(o RunwayObservation) bool {
+		return t.Sub(o.Time) < runwayObservationWindow
+	})
+	rt.observations[icao] = append(obs, RunwayObservation{
+		Runway:    runway,
+		Callsign:  callsign,
+		Departure: departure,
+		Time:      t,
+	})
+}
+
+// aircraftRunway returns the runway at the given airport that the
+// aircraft is aligned with and positioned to be departing from (if
+// departure is true) or arriving at, if any.
+func aircraftRunway(ac *Aircraft, icao string, departure bool) *Runway {
+	nmPerLongitude := nmPerLongitudeAt(ac.Position()[1])
+	p := ll2nm(ac.Position(), nmPerLongitude)
+	for i, rwy := range database.runways[icao] {
+		if headingDifference(ac.Heading(), rwy.Heading) > runwayHeadingTolerance {
+			continue
+		}
+
+		// Work in nm space: find the distance along the runway's
+		// centerline from the threshold and the lateral distance from it.
+		t, e := ll2nm(rwy.Threshold, nmPerLongitude), ll2nm(rwy.End, nmPerLongitude)
+		length := distance2f(t, e)
+		if length == 0 {
+			continue
+		}
+		d := normalize2f(sub2f(e, t))
+		v := sub2f(p, t)
+		along := v[0]*d[0] + v[1]*d[1]
+		lateral := abs(v[0]*d[1] - v[1]*d[0])
+
+		if lateral > runwayCenterlineTolerance {
+			continue
+		}
+		if departure && along >= 0 && along < length+3 {
+			// On the runway or just past its departure end.
+			return &database.runways[icao][i]
+		} else if !departure && along > -8 && along < length {
+			// On final or on the runway.
+			return &database.runways[icao][i]
+		}
+	}
+	return nil
+}
+
+// ActiveRunways returns the runways that are believed to be in use for
+// departures and arrivals at the specified airport.
+func (rt *RunwayTracker) ActiveRunways(icao string) ActiveRunways {
+	var active ActiveRunways
+	now := server.CurrentTime()
+
+	// Weight observations so that more recent ones count for more; this
+	// way a change in the airport configuration is picked up reasonably
+	// quickly.
+	dep, arr := make(map[string]float32), make(map[string]float32)
+	for _, obs := range rt.observations[icao] {
+		age := now.Sub(obs.Time)
+		if age > runwayObservationWindow {
+			continue
+		}
+		w := 1 - float32(age.Minutes()/runwayObservationWindow.Minutes())
+		if obs.Departure {
+			dep[obs.Runway] += w
+		} else {
+			arr[obs.Runway] += w
+		}
+	}
+
+	// Only keep runways with a reasonable fraction of the most-used
+	// runway's usage, so that the occasional oddball doesn't make a runway
+	// look active.
+	used := func(w map[string]float32) []string {
+		var maxWeight float32
+		for _, v := range w {
+			maxWeight = max(maxWeight, v)
+		}
+		return SortedMapKeys(FilterMap(w, func(rwy string, v float32) bool { return v >= maxWeight/4 }))
+	}
+
+	if len(dep) > 0 {
+		active.Departing = used(dep)
+		active.DepartingObserved = true
+	}
+	if len(arr) > 0 {
+		active.Arriving = used(arr)
+		active.ArrivingObserved = true
+	}
+
+	if active.Departing == nil || active.Arriving == nil {
+		if m := server.GetMETAR(icao); m != nil {
+			if dir, speed, _, err := m.ParseWind(); err == nil {
+				windRunways := RunwaysForWind(database.runways[icao], dir, speed)
+				if active.Departing == nil {
+					active.Departing = windRunways
+				}
+				if active.Arriving == nil {
+					active.Arriving = windRunways
+				}
+			}
+		}
+	}
+
+	return active
+}
+
+// RunwaysForWind returns the runways from the provided slice that are
+// best aligned with the given wind (direction in degrees true, speed in
+// knots). Parallel runways are all returned. If the wind is variable or
+// too light to matter, nil is returned.
+func RunwaysForWind(runways []Runway, windDirection int, windSpeed int) []string {
+	if windDirection < 0 || windSpeed < runwayMinimumWind || len(runways) == 0 {
+		return nil
+	}
+
+	// Runway headings are magnetic, while METAR winds are true.
+	windMagnetic := float32(windDirection) + database.MagneticCorrection(runways[0].Threshold)
+
+	headwind := func(rwy Runway) float32 {
+		return float32(windSpeed) * cos(radians(rwy.Heading-windMagnetic))
+	}
+
+	best := runways[0]
+	for _, rwy := range runways {
+		if headwind(rwy) > headwind(best) {
+			best = rwy
+		}
+	}
+
+	var rwys []string
+	for _, rwy := range runways {
+		if headingDifference(rwy.Heading, best.Heading) < runwayHeadingTolerance {
+			rwys = append(rwys, rwy.Number)
+		}
+	}
+	sort.Strings(rwys)
+	return rwys
+}
@@ -24,8 +24,11 @@ type RadarScopePane struct {
 	Range              float32
 	DatablockFormat    DatablockFormat `json:"DataBlockFormat"`
 	DatablockFrequency int32           `json:"DataBlockFrequency"`
-	PointSize          float32
-	LineWidth          float32
+	// Name of the position config's datablock template used when
+	// DatablockFormat is DatablockFormatTemplate.
+	DatablockTemplate string
+	PointSize         float32
+	LineWidth         float32
 
 	StaticDraw *StaticDrawConfig
 
@@ -33,6 +36,8 @@ type RadarScopePane struct {
 	WeatherIntensity float32
 	WeatherRadar     WeatherRadar
 
+	Chart ChartOverlay
+
 	DrawRangeRings  bool
 	RangeRingRadius float32
 	RangeRingCenter string
@@ -43,6 +48,7 @@ type RadarScopePane struct {
 
 	MinAltitude int32
 	MaxAltitude int32
+	Filter      AircraftFilter
 
 	GroundRadarTracks bool
 	GroundTracksScale float32
@@ -65,6 +71,31 @@ type RadarScopePane struct {
 
 	DrawCompass bool
 
+	// Show the closest fix or navaid to the mouse cursor.
+	DrawNearestFix bool
+
+	// Mark datablocks of aircraft that aren't on the frequency of the
+	// controller whose airspace they are in; see ownership.go.
+	DrawFrequencyCheck bool
+
+	// Special use airspace and TFRs; see sua.go.
+	DrawSUA         bool
+	DrawInactiveSUA bool
+	// Aircraft predicted to enter active SUA within this many minutes are
+	// highlighted; zero disables the alerts.
+	SUAAlertMinutes int32
+	// "callsign area" for the current alerts, so that new ones can be
+	// announced.
+	suaAlerts map[string]interface{}
+
+	// Flag IFR aircraft that are below the minimum vectoring altitude;
+	// see msaw.go.
+	DrawMSAW bool
+	// Aircraft with MSAW alerts the last time we checked and when the
+	// audio alert was last played.
+	msawAlerts         map[*Aircraft]interface{}
+	lastMSAWAlertAudio time.Time
+
 	DatablockFontIdentifier FontIdentifier
 	datablockFont           *Font
 	LabelFontIdentifier     FontIdentifier
@@ -105,7 +136,7 @@ type AircraftScopeState struct {
 
 	datablockAutomaticOffset [2]float32
 	datablockManualOffset    [2]float32
-	datablockText            [2]string
+	datablockText            []string // one per flash page
 	datablockTextCurrent     bool
 	datablockBounds          Extent2D // w.r.t. lower-left corner (so (0,0) p0 always)
 }
@@ -141,6 +172,12 @@ func NewRadarScopePane(n string) *RadarScopePane {
 		GroundTracksScale:  1,
 		CRDAConfig:         NewCRDAConfig(),
 		AutoMITAirports:    make(map[string]interface{}),
+		Chart:              NewChartOverlay(),
+		DrawNearestFix:     true,
+		DrawFrequencyCheck: true,
+		DrawSUA:            true,
+		SUAAlertMinutes:    3,
+		DrawMSAW:           true,
 	}
 }
 
@@ -152,14 +189,17 @@ func (rs *RadarScopePane) Duplicate(nameAsCopy bool) Pane {
 	}
 
 	dupe.StaticDraw = rs.StaticDraw.Duplicate()
+	dupe.Chart = rs.Chart.Duplicate()
 
 	dupe.rangeWarnings = DuplicateMap(rs.rangeWarnings)
+	dupe.suaAlerts = DuplicateMap(rs.suaAlerts)
+	dupe.msawAlerts = DuplicateMap(rs.msawAlerts)
 
 	dupe.aircraft = make(map[*Aircraft]*AircraftScopeState)
 	for ac, tracked := range rs.aircraft {
 		dupe.aircraft[ac] = &AircraftScopeState{
 			isGhost:       tracked.isGhost,
-			datablockText: tracked.datablockText}
+			datablockText: DuplicateSlice(tracked.datablockText)}
 	}
 
 	dupe.ghostAircraft = make(map[*Aircraft]*Aircraft)
@@ -198,6 +238,8 @@ func (rs *RadarScopePane) Activate() {
 		rs.WeatherRadar.Activate(rs.Center)
 	}
 
+	rs.Chart.Activate()
+
 	// start tracking all of the active aircraft
 	rs.initializeAircraft()
 }
@@ -236,6 +278,8 @@ func (rs *RadarScopePane) Deactivate() {
 	if rs.DrawWeather {
 		rs.WeatherRadar.Deactivate()
 	}
+
+	rs.Chart.Deactivate()
 }
 
 func (rs *RadarScopePane) Name() string { return rs.ScopeName }
@@ -248,8 +292,19 @@ func (rs *RadarScopePane) DrawUI() {
 	if imgui.InputIntV("Maximum altitude", &rs.MaxAltitude, 100, 1000, 0 /* flags */) {
 		rs.initializeAircraft()
 	}
+	rs.Filter.DrawUI("Filter")
 	if imgui.CollapsingHeader("Aircraft rendering") {
-		if rs.DatablockFormat.DrawUI() {
+		changed := rs.DatablockFormat.DrawUI()
+		if rs.DatablockFormat == DatablockFormatTemplate {
+			changed = drawDatablockTemplateSelector(&rs.DatablockTemplate) || changed
+		}
+		if imgui.Checkbox("Mark aircraft on the wrong frequency", &rs.DrawFrequencyCheck) {
+			changed = true
+		}
+		if imgui.Checkbox("Minimum safe altitude warnings", &rs.DrawMSAW) {
+			changed = true
+		}
+		if changed {
 			for _, state := range rs.aircraft {
 				state.datablockTextCurrent = false
 			}
@@ -284,6 +339,9 @@ func (rs *RadarScopePane) DrawUI() {
 			rs.labelFont = newFont
 		}
 	}
+	if imgui.CollapsingHeader("Chart overlay") {
+		rs.Chart.DrawUI()
+	}
 	if imgui.CollapsingHeader("Tools") {
 		if imgui.Checkbox("Weather radar", &rs.DrawWeather) {
 			if rs.DrawWeather {
@@ -302,6 +360,13 @@ func (rs *RadarScopePane) DrawUI() {
 			imgui.Separator()
 		}
 		imgui.Checkbox("Draw compass directions at edges", &rs.DrawCompass)
+		imgui.Checkbox("Show nearest fix to the mouse", &rs.DrawNearestFix)
+		imgui.Checkbox("Draw special use airspace", &rs.DrawSUA)
+		if rs.DrawSUA {
+			imgui.SameLine()
+			imgui.Checkbox("Include inactive areas", &rs.DrawInactiveSUA)
+		}
+		imgui.SliderIntV("SUA alert lookahead (minutes, 0 disables)", &rs.SUAAlertMinutes, 0, 10, "%d", 0 /* flags */)
 		imgui.Checkbox("Draw range rings", &rs.DrawRangeRings)
 		if rs.DrawRangeRings {
 			flags := imgui.InputTextFlagsCharsNoBlank | imgui.InputTextFlagsCharsUppercase
@@ -411,6 +476,9 @@ func (rs *RadarScopePane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 
 	transforms := GetScopeTransformations(ctx, rs.Center, rs.Range, rs.RotationAngle)
 
+	// The chart goes underneath everything else.
+	rs.Chart.Draw(transforms, cb)
+
 	if rs.DrawWeather && rs.WeatherIntensity > 0 {
 		rs.WeatherRadar.Draw(rs.WeatherIntensity, transforms, cb)
 	}
@@ -425,6 +493,9 @@ func (rs *RadarScopePane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 			mouseLatLong := transforms.LatLongFromWindowP(ctx.mouse.Pos)
 			label += "\nMouse position: " + mouseLatLong.DDString() + " " + mouseLatLong.DMSString()
 		}*/
+		if rs.DrawNearestFix && ctx.mouse != nil {
+			label += "\n" + nearestFixDescription(transforms.LatLongFromWindowP(ctx.mouse.Pos))
+		}
 		td.AddText(label, [2]float32{float32(rs.labelFont.size) / 2, height - float32(rs.labelFont.size)/2},
 			TextStyle{Font: rs.labelFont, Color: ctx.cs.Text})
 		transforms.LoadWindowViewingMatrices(cb)
@@ -463,11 +534,13 @@ func (rs *RadarScopePane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 	rs.drawRoute(ctx, transforms, cb)
 
 	rs.CRDAConfig.DrawRegions(ctx, transforms, cb)
+	rs.drawSUA(ctx, transforms, cb)
 
 	// Per-aircraft stuff: tracks, datablocks, vector lines, range rings, ...
 	rs.drawTracks(ctx, transforms, cb)
 	rs.drawTools(ctx, transforms, cb)
 
+	rs.checkMSAW(ctx)
 	rs.updateDatablockTextAndBounds(ctx)
 	rs.layoutDatablocks(ctx, transforms)
 	rs.drawDatablocks(ctx, transforms, cb)
@@ -481,7 +554,7 @@ func (rs *RadarScopePane) Draw(ctx *PaneContext, cb *CommandBuffer) {
 func (rs *RadarScopePane) visible(ac *Aircraft) bool {
 	now := server.CurrentTime()
 	return !ac.LostTrack(now) && ac.Altitude() >= int(rs.MinAltitude) && ac.Altitude() <= int(rs.MaxAltitude) &&
-		nmdistance2ll(ac.Position(), rs.Center) < 2*rs.Range
+		nmdistance2ll(ac.Position(), rs.Center) < 2*rs.Range && rs.Filter.Match(ac)
 }
 
 func (rs *RadarScopePane) drawMIT(ctx *PaneContext, transforms ScopeTransformations, cb *CommandBuffer) {
@@ -512,8 +585,7 @@ func (rs *RadarScopePane) drawMIT(ctx *PaneContext, transforms ScopeTransformati
 		inTrail := func(front Arrival, back Arrival) bool {
 			dalt := back.aircraft.Altitude() - front.aircraft.Altitude()
 			backHeading := back.aircraft.Heading()
-			angle := headingp2ll(back.aircraft.Position(), front.aircraft.Position(),
-				database.MagneticVariation)
+			angle := back.aircraft.HeadingTo(front.aircraft.Position())
 			diff := headingDifference(backHeading, angle)
 
 			return diff < 150 && dalt < 3000
@@ -635,7 +707,7 @@ func (rs *RadarScopePane) drawTracks(ctx *PaneContext, transforms ScopeTransform
 			iconSpecs = append(iconSpecs, PlaneIconSpec{
 				P:       transforms.WindowFromLatLongP(ac.Position()),
 				Size:    size,
-				Heading: ac.Heading() + rs.RotationAngle})
+				Heading: rs.windowHeading(ac, transforms)})
 		}
 
 		transforms.LoadWindowViewingMatrices(cb)
@@ -651,7 +723,8 @@ func (rs *RadarScopePane) drawTracks(ctx *PaneContext, transforms ScopeTransform
 
 	now := server.CurrentTime()
 	for ac, state := range rs.aircraft {
-		if ac.LostTrack(now) || ac.Altitude() < int(rs.MinAltitude) || ac.Altitude() > int(rs.MaxAltitude) {
+		if ac.LostTrack(now) || ac.Altitude() < int(rs.MinAltitude) || ac.Altitude() > int(rs.MaxAltitude) ||
+			!rs.Filter.Match(ac) {
 			continue
 		}
 
@@ -753,8 +826,7 @@ func (rs *RadarScopePane) drawRangeBearingLines(ctx *PaneContext, transforms Sco
 	for _, rbl := range rs.rangeBearingLines {
 		p0, p1 := rbl.ac.Position(), rbl.p
 
-		hdg := headingp2ll(p0, p1, database.MagneticVariation)
-		dist := nmdistance2ll(p0, p1)
+		dist, hdg := ellipsoidalHeading(p0, p1)
 		oclock := headingAsHour(hdg - rbl.ac.Heading())
 		text := fmt.Sprintf("%d°/%d\n%.1f nm", int(hdg+.5), oclock, dist)
 
@@ -784,8 +856,7 @@ func (rs *RadarScopePane) updateDatablockTextAndBounds(ctx *PaneContext) {
 
 		if !state.datablockTextCurrent {
 			if ctx.thumbnail {
-				state.datablockText[0] = ac.Callsign
-				state.datablockText[1] = ac.Callsign
+				state.datablockText = []string{ac.Callsign}
 				state.datablockTextCurrent = true
 			} else {
 				hopo := ""
@@ -798,18 +869,46 @@ func (rs *RadarScopePane) updateDatablockTextAndBounds(ctx *PaneContext) {
 				if controller, ok := rs.pointedOutAircraft.Get(ac); ok {
 					hopo += FontAwesomeIconExclamationTriangle + controller
 				}
+				if rs.DrawMSAW {
+					if _, ok := ac.MSAW(); ok {
+						hopo += "LA"
+					}
+				}
+				if rs.DrawFrequencyCheck {
+					if fc := ac.FrequencyCheck(); fc.Problem != "" {
+						// Show the frequency they should be on, if known.
+						hopo += FontAwesomeIconHeadphones + Select(fc.Owner != "", fc.OwnerFrequency.String(), "?")
+					}
+				}
 				if hopo != "" {
 					hopo = "\n" + hopo
 				}
 
-				state.datablockText[0] = rs.DatablockFormat.Format(ac, squawkCount[ac.Squawk] != 1, 0) + hopo
-				state.datablockText[1] = rs.DatablockFormat.Format(ac, squawkCount[ac.Squawk] != 1, 1) + hopo
+				duplicateSquawk := squawkCount[ac.Squawk] != 1
+				if rs.DatablockFormat == DatablockFormatTemplate {
+					template, ok := positionConfig.DatablockTemplates[rs.DatablockTemplate]
+					var err error
+					if ok {
+						state.datablockText, err = FormatDatablockTemplate(template, ac, duplicateSquawk)
+					}
+					if !ok || err != nil {
+						state.datablockText = []string{ac.Callsign}
+					}
+				} else {
+					state.datablockText = []string{rs.DatablockFormat.Format(ac, duplicateSquawk, 0),
+						rs.DatablockFormat.Format(ac, duplicateSquawk, 1)}
+				}
+				for i := range state.datablockText {
+					state.datablockText[i] += hopo
+				}
 				state.datablockTextCurrent = true
 			}
 
-			bx0, by0 := rs.datablockFont.BoundText(state.datablockText[0], -2)
-			bx1, by1 := rs.datablockFont.BoundText(state.datablockText[1], -2)
-			bx, by := max(float32(bx0), float32(bx1)), max(float32(by0), float32(by1))
+			var bx, by float32
+			for _, text := range state.datablockText {
+				x, y := rs.datablockFont.BoundText(text, -2)
+				bx, by = max(bx, float32(x)), max(by, float32(y))
+			}
 			state.datablockBounds = Extent2D{p0: [2]float32{0, -by}, p1: [2]float32{bx, 0}}
 		}
 	}
@@ -850,12 +949,19 @@ func datablockConnectP(bbox Extent2D, heading float32) ([2]float32, bool) {
 	}
 }
 
+// windowHeading returns the aircraft's heading with respect to the
+// window. The scope is oriented using the magnetic variation at its
+// center, which may differ from the variation where the aircraft is.
+func (rs *RadarScopePane) windowHeading(ac *Aircraft, transforms ScopeTransformations) float32 {
+	return ac.Heading() + rs.RotationAngle + transforms.magCorrection - database.MagneticCorrection(ac.Position())
+}
+
 func (rs *RadarScopePane) layoutDatablocks(ctx *PaneContext, transforms ScopeTransformations) {
 	offsetSelfOnly := func(ac *Aircraft, info *AircraftScopeState) [2]float32 {
 		bbox := info.datablockBounds.Expand(5)
 
 		// We want the heading w.r.t. the window
-		heading := ac.Heading() + rs.RotationAngle
+		heading := rs.windowHeading(ac, transforms)
 		pConnect, isCorner := datablockConnectP(bbox, heading)
 
 		// Translate the datablock to put the (padded) connection point
@@ -1092,6 +1198,12 @@ func (rs *RadarScopePane) datablockColor(ac *Aircraft, cs *ColorScheme) RGB {
 		return cs.SelectedDatablock
 	}
 
+	if rs.DrawMSAW {
+		if _, ok := ac.MSAW(); ok {
+			return cs.Error
+		}
+	}
+
 	if ac.InboundHandoffController != "" {
 		return cs.HandingOffDatablock
 	}
@@ -1142,6 +1254,9 @@ func (rs *RadarScopePane) drawDatablocks(ctx *PaneContext, transforms ScopeTrans
 
 		pac := transforms.WindowFromLatLongP(ac.Position())
 		state := rs.aircraft[ac]
+		if len(state.datablockText) == 0 {
+			continue
+		}
 		bbox := state.WindowDatablockBounds(pac)
 
 		if !Overlaps(paneBounds, bbox) {
@@ -1151,7 +1266,7 @@ func (rs *RadarScopePane) drawDatablocks(ctx *PaneContext, transforms ScopeTrans
 		color := rs.datablockColor(ac, ctx.cs)
 
 		// Draw characters starting at the upper left.
-		flashCycle := (actualNow.Second() / int(rs.DatablockFrequency)) & 1
+		flashCycle := (actualNow.Second() / int(rs.DatablockFrequency)) % len(state.datablockText)
 		td.AddText(state.datablockText[flashCycle], [2]float32{bbox.p0[0], bbox.p1[1]},
 			TextStyle{
 				Font:            rs.datablockFont,
@@ -1352,6 +1467,127 @@ func (rs *RadarScopePane) drawRangeIndicators(ctx *PaneContext, transforms Scope
 	}
 }
 
+// checkMSAW plays the audio alert when an aircraft starts to have an MSAW
+// alert and periodically while any do.
+func (rs *RadarScopePane) checkMSAW(ctx *PaneContext) {
+	if !rs.DrawMSAW || ctx.thumbnail {
+		return
+	}
+
+	alerts := make(map[*Aircraft]interface{})
+	newAlert := false
+	for ac, state := range rs.aircraft {
+		if state.isGhost || !rs.visible(ac) {
+			continue
+		}
+		if _, ok := ac.MSAW(); ok {
+			alerts[ac] = nil
+			if _, ok := rs.msawAlerts[ac]; !ok {
+				newAlert = true
+				// Make sure that the datablock shows the alert.
+				state.datablockTextCurrent = false
+			}
+		}
+	}
+	for ac := range rs.msawAlerts {
+		if _, ok := alerts[ac]; !ok {
+			if state, ok := rs.aircraft[ac]; ok {
+				state.datablockTextCurrent = false
+			}
+		}
+	}
+
+	if newAlert || (len(alerts) > 0 && time.Since(rs.lastMSAWAlertAudio) > 30*time.Second) {
+		globalConfig.AudioSettings.HandleEvent(AudioEventConflictAlert)
+		rs.lastMSAWAlertAudio = time.Now()
+	}
+	rs.msawAlerts = alerts
+}
+
+// drawSUA draws the special use airspace and, for aircraft that are
+// predicted to enter an active area, a line to where they will do so.
+func (rs *RadarScopePane) drawSUA(ctx *PaneContext, transforms ScopeTransformations, cb *CommandBuffer) {
+	if !rs.DrawSUA && rs.SUAAlertMinutes == 0 {
+		return
+	}
+
+	now := server.CurrentTime()
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	if rs.DrawSUA {
+		width, height := ctx.paneExtent.Width(), ctx.paneExtent.Height()
+		viewBounds := Extent2DFromPoints([][2]float32{
+			transforms.LatLongFromWindowP([2]float32{0, 0}),
+			transforms.LatLongFromWindowP([2]float32{width, 0}),
+			transforms.LatLongFromWindowP([2]float32{0, height}),
+			transforms.LatLongFromWindowP([2]float32{width, height})})
+
+		for i := range database.sua {
+			s := &database.sua[i]
+			if !Overlaps(s.geometry.bounds, viewBounds) {
+				continue
+			}
+			active := s.Active(now)
+			if !active && !rs.DrawInactiveSUA {
+				continue
+			}
+
+			color := Select(active, ctx.cs.Caution, ctx.cs.TextDisabled)
+			for _, ring := range append([][]Point2LL{s.Boundary}, s.Holes...) {
+				for j := 0; j+1 < len(ring); j++ {
+					ld.AddLine(ring[j], ring[j+1], color)
+				}
+			}
+			if !ctx.thumbnail {
+				p := transforms.WindowFromLatLongP(s.geometry.bounds.Center())
+				td.AddTextCentered(s.Name+"\n"+s.AltitudeDescription(), p,
+					TextStyle{Font: rs.labelFont, Color: color})
+			}
+		}
+	}
+
+	if rs.SUAAlertMinutes > 0 && !ctx.thumbnail {
+		alerts := make(map[string]interface{})
+		for ac, state := range rs.aircraft {
+			if state.isGhost || !rs.visible(ac) {
+				continue
+			}
+			for _, alert := range ac.SUAAlerts(float32(rs.SUAAlertMinutes)) {
+				alerts[ac.Callsign+" "+alert.SUA.Name] = nil
+
+				text := alert.SUA.Name
+				if alert.Minutes > 0 {
+					ld.AddLine(ac.Position(), alert.P, ctx.cs.Error)
+					text += fmt.Sprintf(" %.0fm", max(1, alert.Minutes))
+				}
+				style := TextStyle{
+					Font:            rs.labelFont,
+					Color:           ctx.cs.Error,
+					DrawBackground:  true,
+					BackgroundColor: ctx.cs.Background}
+				td.AddTextCentered(text, transforms.WindowFromLatLongP(alert.P), style)
+			}
+		}
+
+		for a := range alerts {
+			if _, ok := rs.suaAlerts[a]; !ok {
+				globalConfig.AudioSettings.HandleEvent(AudioEventAlert)
+				break
+			}
+		}
+		rs.suaAlerts = alerts
+	}
+
+	transforms.LoadLatLongViewingMatrices(cb)
+	cb.LineWidth(rs.LineWidth)
+	ld.GenerateCommands(cb)
+	transforms.LoadWindowViewingMatrices(cb)
+	td.GenerateCommands(cb)
+}
+
 func (rs *RadarScopePane) drawRoute(ctx *PaneContext, transforms ScopeTransformations, cb *CommandBuffer) {
 	remaining := time.Until(positionConfig.drawnRouteEndTime)
 	if remaining < 0 {
@@ -1368,8 +1604,10 @@ func (rs *RadarScopePane) drawRoute(ctx *PaneContext, transforms ScopeTransforma
 	ld := GetColoredLinesDrawBuilder()
 	defer ReturnColoredLinesDrawBuilder(ld)
 	var pPrev Point2LL
-	for _, waypoint := range strings.Split(positionConfig.drawnRoute, " ") {
-		if p, ok := database.Locate(waypoint); !ok {
+	addWaypoint := func(waypoint string) {
+		// Resolve ambiguous names using the previous waypoint.
+		near := Select(pPrev.IsZero(), database.defaultCenter, pPrev)
+		if p, ok := database.LocateNear(waypoint, near); !ok {
 			// no worries; most likely it's a SID, STAR, or airway..
 		} else {
 			if !pPrev.IsZero() {
@@ -1378,12 +1616,39 @@ func (rs *RadarScopePane) drawRoute(ctx *PaneContext, transforms ScopeTransforma
 			pPrev = p
 		}
 	}
+	waypoints := strings.Fields(positionConfig.drawnRoute)
+	for i, waypoint := range waypoints {
+		// Follow airways between the fixes before and after them.
+		if i > 0 && i+1 < len(waypoints) {
+			for _, fix := range database.AirwayFixes(waypoint, waypoints[i-1], waypoints[i+1]) {
+				addWaypoint(fix)
+			}
+		}
+		addWaypoint(waypoint)
+	}
 
 	transforms.LoadLatLongViewingMatrices(cb)
 	cb.LineWidth(3 * rs.LineWidth)
 	ld.GenerateCommands(cb)
 }
 
+// nearestFixDescription returns a description of the fix or navaid
+// closest to the given point and its distance and bearing from it, e.g.,
+// "nearest fix: CAMRN 3.2 nm 045°".
+func nearestFixDescription(p Point2LL) string {
+	nearest := database.Nearest(p, 1, func(o LocatedObject) bool { return o.Kind != "Airport" })
+	if len(nearest) == 0 {
+		return ""
+	}
+	n := nearest[0]
+	hdg := int(headingp2ll(p, n.Location, database.MagneticCorrection(p)) + 0.5)
+	if hdg == 0 {
+		hdg = 360
+	}
+	return fmt.Sprintf("nearest %s: %s %.1f nm %03d°", Select(n.Kind == "Fix", "fix", n.Kind), n.Name,
+		n.Distance, hdg)
+}
+
 func (rs *RadarScopePane) consumeMouseEvents(ctx *PaneContext, transforms ScopeTransformations) {
 	if ctx.mouse == nil {
 		return
@@ -1501,14 +1766,11 @@ func (rs *RadarScopePane) consumeMouseEvents(ctx *PaneContext, transforms ScopeT
 func (rs *RadarScopePane) vectorLineEnd(ac *Aircraft) Point2LL {
 	switch rs.VectorLineMode {
 	case VectorLineNM:
-		// we want the vector length to be l=rs.VectorLineExtent.
-		// we have a heading vector (hx, hy) and scale factors (sx, sy) due to lat/long compression.
-		// we want a t to scale the heading by to have that length.
-		// solve (sx t hx)^2 + (hy t hy)^2 = l^2 ->
-		// t = sqrt(l^2 / ((sx hx)^2 + (sy hy)^2)
-		h := ac.HeadingVector()
-		t := sqrt(sqr(rs.VectorLineExtent) / (sqr(h[1]*database.NmPerLatitude) + sqr(h[0]*database.NmPerLongitude)))
-		return add2ll(ac.Position(), scale2ll(h, t))
+		// Follow the great circle along the aircraft's track for
+		// rs.VectorLineExtent nm.
+		p := ac.Position()
+		bearing := greatCircleBearing(p, add2ll(p, ac.HeadingVector()))
+		return greatCircleDestination(p, bearing, rs.VectorLineExtent)
 
 	case VectorLineMinutes:
 		// HeadingVector() comes back scaled for one minute in the future.
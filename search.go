@@ -0,0 +1,279 @@
+// search.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// LocatedObject is a named thing in the StaticDatabase that has a
+// location: a fix, navaid, or airport.
+type LocatedObject struct {
+	Name     string
+	Kind     string // e.g. "Fix", "VOR", "Airport"
+	Location Point2LL
+}
+
+// SearchResult is returned by the StaticDatabase's Search and Nearest
+// methods. Airlines and aircraft types don't have locations; for them,
+// HasLocation is false and Distance is infinite.
+type SearchResult struct {
+	Name        string
+	Kind        string
+	Description string
+	Location    Point2LL
+	HasLocation bool
+	Distance    float32 // nm
+}
+
+// locatedObjectIndex stores all of the LocatedObjects in the database in
+// a grid of one-degree cells so that nearby ones can be found quickly.
+type locatedObjectIndex struct {
+	objects []LocatedObject
+	cells   map[[2]int][]int
+}
+
+func locatedObjectCell(p Point2LL) [2]int {
+	return [2]int{int(math.Floor(float64(p[0]))), int(math.Floor(float64(p[1])))}
+}
+
+// locatedObjects returns the index of LocatedObjects, building it if
+// needed. Objects from the sector file take precedence over ones with
+// the same name and (nearly) the same location from the other databases.
+func (db *StaticDatabase) locatedObjects() *locatedObjectIndex {
+	if db.objectIndex != nil {
+		return db.objectIndex
+	}
+
+	idx := &locatedObjectIndex{cells: make(map[[2]int][]int)}
+	byName := make(map[string][]int)
+	add := func(name, kind string, p Point2LL) {
+		if name == "" || p.IsZero() {
+			return
+		}
+		for _, i := range byName[name] {
+			if nmdistance2ll(idx.objects[i].Location, p) < 1 {
+				return
+			}
+		}
+		i := len(idx.objects)
+		idx.objects = append(idx.objects, LocatedObject{Name: name, Kind: kind, Location: p})
+		byName[name] = append(byName[name], i)
+		c := locatedObjectCell(p)
+		idx.cells[c] = append(idx.cells[c], i)
+	}
+
+	// Iterate over the maps in sorted order so that the results are
+	// deterministic.
+	for _, name := range SortedMapKeys(db.VORs) {
+		add(name, "VOR", db.VORs[name])
+	}
+	for _, name := range SortedMapKeys(db.NDBs) {
+		add(name, "NDB", db.NDBs[name])
+	}
+	for _, name := range SortedMapKeys(db.fixes) {
+		add(name, "Fix", db.fixes[name])
+	}
+	for _, name := range SortedMapKeys(db.airports) {
+		add(name, "Airport", db.airports[name].Location)
+	}
+	for _, name := range SortedMapKeys(db.FAA.navaids) {
+		add(name, db.FAA.navaids[name].Type, db.FAA.navaids[name].Location)
+	}
+	for _, name := range SortedMapKeys(db.FAA.fixes) {
+		add(name, "Fix", db.FAA.fixes[name].Location)
+	}
+	for _, name := range SortedMapKeys(db.world.navaids) {
+		for _, n := range db.world.navaids[name] {
+			add(name, n.Type, n.Location)
+		}
+	}
+	for _, name := range SortedMapKeys(db.world.fixes) {
+		for _, f := range db.world.fixes[name] {
+			add(name, "Fix", f.Location)
+		}
+	}
+
+	db.objectIndex = idx
+	return idx
+}
+
+// Nearest returns up to n LocatedObjects closest to p, closest first. If
+// filter is non-nil, only objects for which it returns true are
+// considered.
+func (db *StaticDatabase) Nearest(p Point2LL, n int, filter func(LocatedObject) bool) []SearchResult {
+	idx := db.locatedObjects()
+	if n <= 0 || len(idx.objects) == 0 {
+		return nil
+	}
+
+	// Visit rings of cells around the one that p is in until there are n
+	// candidates and the remaining cells are all farther away than the
+	// n-th closest one.
+	var results []SearchResult
+	c := locatedObjectCell(p)
+	visited := 0
+	for r := 0; r <= 360 && visited < len(idx.objects); r++ {
+		visit := func(dx, dy int) {
+			for _, i := range idx.cells[[2]int{c[0] + dx, c[1] + dy}] {
+				visited++
+				o := idx.objects[i]
+				if filter != nil && !filter(o) {
+					continue
+				}
+				results = append(results, SearchResult{Name: o.Name, Kind: o.Kind, Location: o.Location,
+					HasLocation: true, Distance: nmdistance2ll(p, o.Location)})
+			}
+		}
+		if r == 0 {
+			visit(0, 0)
+		}
+		for d := -r; d < r; d++ {
+			// Walk the four sides of the ring.
+			visit(d, -r)
+			visit(r, d)
+			visit(-d, r)
+			visit(-r, -d)
+		}
+
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Distance < results[j].Distance })
+		if len(results) > n {
+			results = results[:n]
+		}
+
+		// Anything that hasn't been visited is at least r degrees away
+		// in latitude or longitude.
+		maxLat := min(89, abs(p[1])+float32(r)+1)
+		if len(results) == n && results[n-1].Distance <= float32(r)*60*cos(radians(maxLat)) {
+			break
+		}
+	}
+	return results
+}
+
+// searchNameMatch returns how well the query matches the name, with
+// smaller values indicating better matches: 0 for an exact match, 1 for a
+// prefix match, 2 if they differ by a single edit, and 3 if the query's
+// characters all appear in order in the name, starting with its first
+// one. -1 is returned if there is no match. Both should be upper case.
+func searchNameMatch(query, name string) int {
+	switch {
+	case query == "" || name == "":
+		return -1
+	case query == name:
+		return 0
+	case strings.HasPrefix(name, query):
+		return 1
+	case len(query) >= 3 && withinOneEdit(query, name):
+		return 2
+	case len(query) >= 3 && query[0] == name[0] && isSubsequence(query, name):
+		return 3
+	default:
+		return -1
+	}
+}
+
+// withinOneEdit reports whether a can be turned into b with at most one
+// insertion, deletion, substitution, or transposition of adjacent
+// characters.
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if i == len(a) {
+		return true
+	}
+	if len(a) == len(b) {
+		// Substitution or transposition
+		return a[i+1:] == b[i+1:] ||
+			(i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:])
+	}
+	// Insertion
+	return a[i:] == b[i+1:]
+}
+
+// isSubsequence reports whether all of the characters of a appear in b in
+// order.
+func isSubsequence(a, b string) bool {
+	i := 0
+	for j := 0; i < len(a) && j < len(b); j++ {
+		if a[i] == b[j] {
+			i++
+		}
+	}
+	return i == len(a)
+}
+
+// Search returns up to limit things in the database that match the
+// query: fixes, navaids, airports, airlines, and aircraft types. Exact
+// matches come first, then prefix matches, then approximate matches;
+// within each, results are sorted by distance from center.
+func (db *StaticDatabase) Search(query string, center Point2LL, limit int) []SearchResult {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	type match struct {
+		SearchResult
+		score int
+	}
+	var matches []match
+	inf := float32(math.Inf(1))
+
+	for _, o := range db.locatedObjects().objects {
+		if s := searchNameMatch(query, o.Name); s != -1 {
+			r := SearchResult{Name: o.Name, Kind: o.Kind, Location: o.Location, HasLocation: true,
+				Distance: nmdistance2ll(center, o.Location)}
+			if o.Kind == "Airport" {
+				r.Description = db.airports[o.Name].Name
+			} else if n, ok := db.FAA.navaids[o.Name]; ok && n.Type == o.Kind {
+				r.Description = stopShouting(n.Name)
+			}
+			matches = append(matches, match{SearchResult: r, score: s})
+		}
+	}
+
+	for _, tl := range SortedMapKeys(db.callsigns) {
+		cs := db.callsigns[tl]
+		// Match either the three-letter code or the telephony.
+		s := searchNameMatch(query, tl)
+		if ts := searchNameMatch(query, strings.ToUpper(cs.Telephony)); ts != -1 && (s == -1 || ts < s) {
+			s = ts
+		}
+		if s != -1 {
+			matches = append(matches, match{score: s, SearchResult: SearchResult{Name: tl, Kind: "Airline",
+				Description: cs.Telephony + " (" + cs.Company + ")", Distance: inf}})
+		}
+	}
+
+	for _, name := range SortedMapKeys(db.AircraftTypes) {
+		if s := searchNameMatch(query, name); s != -1 {
+			matches = append(matches, match{score: s, SearchResult: SearchResult{Name: name,
+				Kind: "Aircraft type", Description: db.AircraftTypes[name].Manufacturer, Distance: inf}})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].Distance < matches[j].Distance
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return MapSlice(matches, func(m match) SearchResult { return m.SearchResult })
+}
@@ -0,0 +1,119 @@
+// search_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSearchNameMatch(t *testing.T) {
+	for _, test := range []struct {
+		query, name string
+		expected    int
+	}{
+		{"CAMRN", "CAMRN", 0},
+		{"CAM", "CAMRN", 1},
+		{"CAMRM", "CAMRN", 2},
+		{"CAMRNN", "CAMRN", 2},
+		{"CMARN", "CAMRN", 2},
+		{"CMRN", "CAMRN", 2},
+		{"CRN", "CAMRN", 3},
+		{"ARN", "CAMRN", -1},
+		{"XY", "XZY", -1},
+		{"", "CAMRN", -1},
+	} {
+		if m := searchNameMatch(test.query, test.name); m != test.expected {
+			t.Errorf("%q vs %q: got %d, expected %d", test.query, test.name, m, test.expected)
+		}
+	}
+}
+
+func makeSearchTestDatabase() *StaticDatabase {
+	db := &StaticDatabase{
+		fixes: map[string]Point2LL{"CAMRN": {-73.86, 40.02}},
+		airports: map[string]Airport{
+			"KJFK": {Id: "KJFK", Name: "John F Kennedy Intl", Location: Point2LL{-73.78, 40.64}},
+		},
+		callsigns: map[string]Callsign{
+			"AAL": {Company: "American Airlines", Telephony: "American", ThreeLetter: "AAL"},
+			"CMR": {Company: "Camair", Telephony: "Camair", ThreeLetter: "CMR"},
+		},
+		AircraftTypes: map[string]AircraftType{"C172": {Name: "C172", Manufacturer: "Cessna"}},
+	}
+	db.FAA.fixes = map[string]Fix{
+		// Same as the sector file's, so it should be deduplicated.
+		"CAMRN": {Id: "CAMRN", Location: Point2LL{-73.86, 40.02}},
+		"CAMER": {Id: "CAMER", Location: Point2LL{-80, 35}},
+	}
+	db.world.fixes = map[string][]Fix{"CAMRN": {{Id: "CAMRN", Location: Point2LL{10, 50}}}}
+	return db
+}
+
+func TestSearch(t *testing.T) {
+	db := makeSearchTestDatabase()
+
+	var got []string
+	for _, r := range db.Search("cam", Point2LL{-74, 40}, 10) {
+		got = append(got, r.Kind+":"+r.Name)
+	}
+	// All are prefix matches (CMR via its telephony), so the ones closest
+	// to the center come first and the airline, with no location, is last.
+	expected := []string{"Fix:CAMRN", "Fix:CAMER", "Fix:CAMRN", "Airline:CMR"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// Sorting by distance from a point in Europe should give the other
+	// CAMRN first.
+	if r := db.Search("CAMRN", Point2LL{8, 49}, 1); len(r) != 1 || r[0].Location != (Point2LL{10, 50}) {
+		t.Errorf("expected European CAMRN, got %+v", r)
+	}
+
+	if r := db.Search("americn", Point2LL{}, 0); len(r) != 1 || r[0].Name != "AAL" {
+		t.Errorf("expected AAL, got %+v", r)
+	}
+	if r := db.Search("C17", Point2LL{}, 0); len(r) != 1 || r[0].Kind != "Aircraft type" || r[0].HasLocation {
+		t.Errorf("expected C172, got %+v", r)
+	}
+	if r := db.Search("KJFK", Point2LL{}, 0); len(r) != 1 || r[0].Description != "John F Kennedy Intl" {
+		t.Errorf("expected KJFK, got %+v", r)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	db := &StaticDatabase{}
+	db.FAA.fixes = make(map[string]Fix)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprintf("F%04d", i)
+		db.FAA.fixes[id] = Fix{Id: id, Location: Point2LL{-80 + 10*r.Float32(), 35 + 10*r.Float32()}}
+	}
+
+	for _, p := range []Point2LL{{-75, 40}, {-79.9, 35.1}, {-60, 30}} {
+		var all []SearchResult
+		for _, f := range db.FAA.fixes {
+			all = append(all, SearchResult{Name: f.Id, Distance: nmdistance2ll(p, f.Location)})
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].Distance < all[j].Distance })
+
+		nearest := db.Nearest(p, 5, nil)
+		if len(nearest) != 5 {
+			t.Errorf("%v: expected 5 results, got %d", p, len(nearest))
+			continue
+		}
+		for i := range nearest {
+			if nearest[i].Name != all[i].Name {
+				t.Errorf("%v: result %d: got %s, expected %s", p, i, nearest[i].Name, all[i].Name)
+			}
+		}
+	}
+
+	if n := db.Nearest(Point2LL{-75, 40}, 3, func(o LocatedObject) bool { return o.Kind == "VOR" }); len(n) != 0 {
+		t.Errorf("expected no VORs, got %+v", n)
+	}
+}
@@ -0,0 +1,210 @@
+// sectorgeometry.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file provides spatial queries on the geometry from the sector file
+// (and the .ese file, if there is one): whether a point is inside an
+// ARTCC or sector and where a path crosses their boundaries.
+
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// StaticGeometry stores the lines and polygons of a StaticDrawable so
+// that they can be queried.
+type StaticGeometry struct {
+	segments [][2]Point2LL
+	// Closed polygons: either given as such (regions) or found by
+	// chaining together segments (ARTCC boundaries).
+	polygons [][]Point2LL
+	bounds   Extent2D
+}
+
+func NewStaticGeometry(segments [][2]Point2LL, polygons [][]Point2LL) StaticGeometry {
+	g := StaticGeometry{segments: segments, polygons: polygons}
+	var pts [][2]float32
+	for _, s := range segments {
+		pts = append(pts, s[0], s[1])
+	}
+	for _, poly := range polygons {
+		for _, p := range poly {
+			pts = append(pts, p)
+		}
+	}
+	if len(pts) > 0 {
+		g.bounds = Extent2DFromPoints(pts)
+	}
+	return g
+}
+
+// Inside returns true if the point is inside any of the geometry's
+// polygons.
+func (g *StaticGeometry) Inside(p Point2LL) bool {
+	if len(g.polygons) == 0 || !g.bounds.Inside(p) {
+		return false
+	}
+	for _, poly := range g.polygons {
+		if PointInPolygon(p, poly) {
+			return true
+		}
+	}
+	return false
+}
+
+// FirstIntersection returns the first point where the segment from p0 to
+// p1 crosses a line or polygon edge of the geometry and the parametric
+// distance along the segment where it does so.
+func (g *StaticGeometry) FirstIntersection(p0, p1 Point2LL) (Point2LL, float32, bool) {
+	segBounds := Extent2DFromPoints([][2]float32{p0, p1})
+	if !Overlaps(segBounds, g.bounds) {
+		return Point2LL{}, 0, false
+	}
+
+	var pi Point2LL
+	ti := float32(math.Inf(1))
+	check := func(q0, q1 Point2LL) {
+		if p, t, ok := SegmentSegmentIntersect(p0, p1, q0, q1); ok && t < ti {
+			pi, ti = p, t
+		}
+	}
+	for _, s := range g.segments {
+		check(s[0], s[1])
+	}
+	for _, poly := range g.polygons {
+		for i := range poly {
+			check(poly[i], poly[(i+1)%len(poly)])
+		}
+	}
+	return pi, ti, !math.IsInf(float64(ti), 1)
+}
+
+// polygonsFromSegments chains together line segments that share
+// endpoints, returning the closed loops that they form; segments that
+// aren't part of a loop are ignored.
+func polygonsFromSegments(segs [][2]Point2LL) [][]Point2LL {
+	key := func(p Point2LL) [2]int32 {
+		return [2]int32{int32(math.Round(float64(p[0]) * 1e4)), int32(math.Round(float64(p[1]) * 1e4))}
+	}
+	ends := make(map[[2]int32][]int)
+	for i, s := range segs {
+		ends[key(s[0])] = append(ends[key(s[0])], i)
+		ends[key(s[1])] = append(ends[key(s[1])], i)
+	}
+
+	var polys [][]Point2LL
+	used := make([]bool, len(segs))
+	for i, s := range segs {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		loop := []Point2LL{s[0], s[1]}
+		for key(loop[len(loop)-1]) != key(loop[0]) {
+			end := loop[len(loop)-1]
+			next := -1
+			for _, j := range ends[key(end)] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next == -1 {
+				// Dead end; not a closed loop.
+				loop = nil
+				break
+			}
+			used[next] = true
+			if key(segs[next][0]) == key(end) {
+				loop = append(loop, segs[next][1])
+			} else {
+				loop = append(loop, segs[next][0])
+			}
+		}
+		if len(loop) >= 4 {
+			polys = append(polys, loop)
+		}
+	}
+	return polys
+}
+
+// AirspaceContaining returns the names of the ARTCCs (from the ARTCC,
+// ARTCC HIGH, and ARTCC LOW sections of the sector file) and the .ese
+// file sectors that include the given point. ESE sectors are only
+// included if the altitude is between their floor and ceiling.
+func (db *StaticDatabase) AirspaceContaining(p Point2LL, altitude int) []string {
+	var names []string
+	add := func(name string) {
+		if FindIf(names, func(n string) bool { return n == name }) == -1 {
+			names = append(names, name)
+		}
+	}
+
+	for _, artccs := range [][]StaticDrawable{db.ARTCC, db.ARTCCHigh, db.ARTCCLow} {
+		for i := range artccs {
+			if artccs[i].geometry.Inside(p) {
+				add(artccs[i].name)
+			}
+		}
+	}
+	for _, s := range db.eseSectors {
+		if altitude >= s.Floor && altitude <= s.Ceiling && len(s.Boundary) > 0 &&
+			PointInPolygon(p, s.Boundary) {
+			add(s.Name)
+		}
+	}
+	return names
+}
+
+// BoundaryCrossing describes where a path crosses an airspace boundary.
+type BoundaryCrossing struct {
+	Name string // ARTCC or sector
+	P    Point2LL
+	T    float32 // Parametric distance along the path
+}
+
+// BoundaryCrossings returns the points where the path from p0 to p1
+// crosses ARTCC or .ese sector boundaries, sorted by distance from p0.
+func (db *StaticDatabase) BoundaryCrossings(p0, p1 Point2LL, altitude int) []BoundaryCrossing {
+	var crossings []BoundaryCrossing
+	for _, artccs := range [][]StaticDrawable{db.ARTCC, db.ARTCCHigh, db.ARTCCLow} {
+		for i := range artccs {
+			if p, t, ok := artccs[i].geometry.FirstIntersection(p0, p1); ok {
+				crossings = append(crossings, BoundaryCrossing{Name: artccs[i].name, P: p, T: t})
+			}
+		}
+	}
+	for _, s := range db.eseSectors {
+		if altitude < s.Floor || altitude > s.Ceiling || len(s.Boundary) == 0 {
+			continue
+		}
+		g := NewStaticGeometry(nil, [][]Point2LL{s.Boundary})
+		if p, t, ok := g.FirstIntersection(p0, p1); ok {
+			crossings = append(crossings, BoundaryCrossing{Name: s.Name, P: p, T: t})
+		}
+	}
+
+	sort.SliceStable(crossings, func(i, j int) bool { return crossings[i].T < crossings[j].T })
+	return crossings
+}
+
+// NextBoundaryCrossing returns the next ARTCC or sector boundary that the
+// aircraft will cross if it continues on its current track, looking
+// ahead at most maxMinutes, as well as the number of minutes until it
+// does so.
+func (a *Aircraft) NextBoundaryCrossing(maxMinutes float32) (string, float32, bool) {
+	if !a.HaveTrack() || a.Groundspeed() == 0 {
+		return "", 0, false
+	}
+
+	p0 := a.Position()
+	// HeadingVector gives the distance covered in one minute.
+	p1 := add2ll(p0, scale2ll(a.HeadingVector(), maxMinutes))
+	crossings := database.BoundaryCrossings(p0, p1, a.Altitude())
+	if len(crossings) == 0 {
+		return "", 0, false
+	}
+	return crossings[0].Name, crossings[0].T * maxMinutes, true
+}
@@ -0,0 +1,105 @@
+// sectorgeometry_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSegmentSegmentIntersect(t *testing.T) {
+	for _, test := range []struct {
+		p0, p1, q0, q1 [2]float32
+		ok             bool
+		p              [2]float32
+		t              float32
+	}{
+		{[2]float32{0, 0}, [2]float32{2, 0}, [2]float32{1, -1}, [2]float32{1, 1}, true, [2]float32{1, 0}, 0.5},
+		{[2]float32{0, 0}, [2]float32{4, 4}, [2]float32{0, 3}, [2]float32{3, 0}, true, [2]float32{1.5, 1.5}, 0.375},
+		// Parallel
+		{[2]float32{0, 0}, [2]float32{2, 0}, [2]float32{0, 1}, [2]float32{2, 1}, false, [2]float32{}, 0},
+		// The lines intersect but the segments don't.
+		{[2]float32{0, 0}, [2]float32{2, 0}, [2]float32{3, -1}, [2]float32{3, 1}, false, [2]float32{}, 0},
+	} {
+		p, tt, ok := SegmentSegmentIntersect(test.p0, test.p1, test.q0, test.q1)
+		if ok != test.ok {
+			t.Errorf("%v-%v vs %v-%v: got ok %v, expected %v", test.p0, test.p1, test.q0, test.q1, ok, test.ok)
+		} else if ok && (abs(p[0]-test.p[0]) > 1e-5 || abs(p[1]-test.p[1]) > 1e-5 || abs(tt-test.t) > 1e-5) {
+			t.Errorf("%v-%v vs %v-%v: got %v t=%f, expected %v t=%f", test.p0, test.p1, test.q0, test.q1,
+				p, tt, test.p, test.t)
+		}
+	}
+}
+
+func TestPolygonsFromSegments(t *testing.T) {
+	// A square given out of order with some segments reversed, plus one
+	// that dangles off of it.
+	segs := [][2]Point2LL{
+		{{1, 0}, {1, 1}},
+		{{0, 0}, {1, 0}},
+		{{0, 1}, {1, 1}},
+		{{1, 1}, {2, 2}},
+		{{0, 0}, {0, 1}},
+	}
+	polys := polygonsFromSegments(segs)
+	if len(polys) != 1 || len(polys[0]) != 5 {
+		t.Fatalf("expected a single square, got %v", polys)
+	}
+
+	for _, test := range []struct {
+		p      Point2LL
+		inside bool
+	}{
+		{Point2LL{0.5, 0.5}, true},
+		{Point2LL{0.1, 0.9}, true},
+		{Point2LL{1.5, 0.5}, false},
+		{Point2LL{-0.5, 0.5}, false},
+		{Point2LL{1.5, 1.5}, false},
+	} {
+		if PointInPolygon(test.p, polys[0]) != test.inside {
+			t.Errorf("%v: expected inside %v", test.p, test.inside)
+		}
+	}
+}
+
+func TestAirspaceQueries(t *testing.T) {
+	square := func(x0, y0, x1, y1 float32) []Point2LL {
+		return []Point2LL{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}
+	}
+	db := &StaticDatabase{
+		ARTCC: []StaticDrawable{
+			{name: "ZNY", geometry: NewStaticGeometry(nil, [][]Point2LL{square(-76, 39, -72, 42)})},
+			{name: "ZBW", geometry: NewStaticGeometry(nil, [][]Point2LL{square(-72, 41, -69, 45)})},
+		},
+		eseSectors: []ESESector{
+			{Name: "N90", Floor: 0, Ceiling: 17000, Boundary: square(-75, 40, -73, 41.5)},
+		},
+	}
+
+	if a := db.AirspaceContaining(Point2LL{-74, 40.5}, 5000); fmt.Sprint(a) != "[ZNY N90]" {
+		t.Errorf("got %v, expected [ZNY N90]", a)
+	}
+	if a := db.AirspaceContaining(Point2LL{-74, 40.5}, 35000); fmt.Sprint(a) != "[ZNY]" {
+		t.Errorf("got %v, expected [ZNY]", a)
+	}
+	if a := db.AirspaceContaining(Point2LL{-70, 44}, 5000); fmt.Sprint(a) != "[ZBW]" {
+		t.Errorf("got %v, expected [ZBW]", a)
+	}
+	if a := db.AirspaceContaining(Point2LL{-80, 30}, 5000); len(a) != 0 {
+		t.Errorf("got %v, expected nothing", a)
+	}
+
+	// Heading east from inside N90: first N90's boundary, then ZNY's and
+	// ZBW's at the same longitude.
+	c := db.BoundaryCrossings(Point2LL{-74, 41.2}, Point2LL{-70, 41.2}, 5000)
+	var names []string
+	for _, bc := range c {
+		names = append(names, bc.Name)
+	}
+	if len(c) != 3 || names[0] != "N90" || abs(c[0].P[0]+73) > 1e-4 || abs(c[0].T-0.25) > 1e-4 ||
+		abs(c[1].T-0.5) > 1e-4 || abs(c[2].T-0.5) > 1e-4 {
+		t.Errorf("unexpected crossings: %+v", c)
+	}
+}
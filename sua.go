@@ -0,0 +1,839 @@
+// sua.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file handles special use airspace (restricted areas, MOAs, TFRs,
+// etc.): loading it from OpenAir files or the FAA's AIXM SAA export,
+// determining when it is active, and predicting when aircraft will enter
+// it.
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ceiling used for SUA with an unlimited upper altitude.
+const suaUnlimitedAltitude = 99999
+
+// SUA is a single special use airspace area.
+type SUA struct {
+	Name  string
+	Class string // e.g., "R", "P", "MOA", "TFR"
+	// Altitudes are in feet MSL; AGL altitudes are treated as MSL.
+	Floor, Ceiling int
+	Boundary       []Point2LL
+	// Regions inside the boundary that aren't part of the area.
+	Holes [][]Point2LL
+	// The period during which the area is in effect (e.g., for a TFR);
+	// zero times mean that it is unbounded.
+	EffectiveStart, EffectiveEnd time.Time
+	// Recurring times when the area is active; if there are none, it is
+	// always active while it is in effect.
+	Schedule []SUASchedule
+
+	geometry StaticGeometry
+}
+
+// SUASchedule describes a recurring period of time when an area is
+// active.
+type SUASchedule struct {
+	// Days of the week when it is active; if empty, it's active every
+	// day.
+	Days []time.Weekday
+	// Minutes after midnight; if End is before Start, the period extends
+	// past midnight into the following day.
+	Start, End int
+	// Offset from UTC in minutes of the time zone that the days and times
+	// are given in.
+	Offset int
+	// If true, the times are advanced by an hour while US daylight
+	// saving time is in effect.
+	DST bool
+}
+
+func (s SUASchedule) activeOn(d time.Weekday) bool {
+	return len(s.Days) == 0 || Find(s.Days, d) != -1
+}
+
+// usDaylightSavingTime returns true if US daylight saving time is in
+// effect at the given time in a time zone with the given offset from UTC
+// in minutes: from 2:00 local time on the second Sunday in March until
+// 2:00 local time on the first Sunday in November.
+func usDaylightSavingTime(t time.Time, offset int) bool {
+	// Local standard time
+	t = t.UTC().Add(time.Duration(offset) * time.Minute)
+	sunday := func(month time.Month, n, hour int) time.Time {
+		d := time.Date(t.Year(), month, 1, hour, 0, 0, 0, time.UTC)
+		return d.AddDate(0, 0, (7-int(d.Weekday()))%7+7*(n-1))
+	}
+	// The end is at 2:00 daylight time, which is 1:00 standard time.
+	return !t.Before(sunday(time.March, 2, 2)) && t.Before(sunday(time.November, 1, 1))
+}
+
+// Active returns true if the schedule includes the given time.
+func (s SUASchedule) Active(t time.Time) bool {
+	offset := s.Offset
+	if s.DST && usDaylightSavingTime(t, s.Offset) {
+		offset += 60
+	}
+	t = t.UTC().Add(time.Duration(offset) * time.Minute)
+	m := t.Hour()*60 + t.Minute()
+	if s.Start <= s.End {
+		return s.activeOn(t.Weekday()) && m >= s.Start && m < s.End
+	}
+	// Spans midnight
+	yesterday := (t.Weekday() + 6) % 7
+	return (s.activeOn(t.Weekday()) && m >= s.Start) || (s.activeOn(yesterday) && m < s.End)
+}
+
+// Active returns true if the area is active at the given time.
+func (s *SUA) Active(t time.Time) bool {
+	if !s.EffectiveStart.IsZero() && t.Before(s.EffectiveStart) {
+		return false
+	}
+	if !s.EffectiveEnd.IsZero() && !t.Before(s.EffectiveEnd) {
+		return false
+	}
+	if len(s.Schedule) == 0 {
+		return true
+	}
+	return FindIf(s.Schedule, func(sched SUASchedule) bool { return sched.Active(t) }) != -1
+}
+
+// AltitudeDescription returns the area's altitudes in the usual
+// "floor-ceiling" format, in hundreds of feet.
+func (s *SUA) AltitudeDescription() string {
+	alt := func(a int) string {
+		if a == 0 {
+			return "SFC"
+		} else if a >= suaUnlimitedAltitude {
+			return "UNL"
+		}
+		return fmt.Sprintf("%03d", (a+50)/100)
+	}
+	return alt(s.Floor) + "-" + alt(s.Ceiling)
+}
+
+// LoadSUAFile loads special use airspace from either an OpenAir file or
+// an AIXM file.
+func LoadSUAFile(filename string) ([]SUA, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(contents), []byte("<")) {
+		return ParseAIXMSUA(bytes.NewReader(contents))
+	}
+	return ParseOpenAir(bytes.NewReader(contents))
+}
+
+// Inside returns true if the point is inside the area's boundary and not
+// inside any of its holes.
+func (s *SUA) Inside(p Point2LL) bool {
+	if !s.geometry.bounds.Inside(p) || !PointInPolygon(p, s.Boundary) {
+		return false
+	}
+	for _, hole := range s.Holes {
+		if PointInPolygon(p, hole) {
+			return false
+		}
+	}
+	return true
+}
+
+// closeSUA closes the area's boundary and holes if necessary and
+// initializes its geometry for queries. The geometry includes the holes'
+// edges, so that aircraft leaving a hole are found to enter the area.
+func closeSUA(s *SUA) {
+	closeRing := func(r []Point2LL) []Point2LL {
+		if r[0] != r[len(r)-1] {
+			r = append(r, r[0])
+		}
+		return r
+	}
+	s.Boundary = closeRing(s.Boundary)
+	s.Holes = FilterSlice(s.Holes, func(h []Point2LL) bool { return len(h) > 2 })
+	for i := range s.Holes {
+		s.Holes[i] = closeRing(s.Holes[i])
+	}
+	s.geometry = NewStaticGeometry(nil, append([][]Point2LL{s.Boundary}, s.Holes...))
+}
+
+// LoadSUAFiles replaces the database's special use airspace with the
+// areas in the given files, reporting errors via the log.
+func (db *StaticDatabase) LoadSUAFiles(filenames []string) {
+	db.sua = nil
+	for _, fn := range filenames {
+		lg.Printf("%s: loading SUA file", fn)
+		areas, err := LoadSUAFile(fn)
+		if err != nil {
+			lg.Errorf("%s: %v", fn, err)
+		}
+		db.sua = append(db.sua, areas...)
+	}
+}
+
+// parseSUAAltitude parses altitudes like "SFC", "FL180", "5000ft MSL",
+// "1500 AGL", or "UNL".
+func parseSUAAltitude(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "SFC", "GND", "0":
+		return 0, nil
+	case "UNL", "UNLIM", "UNLTD", "UNLIMITED":
+		return suaUnlimitedAltitude, nil
+	}
+
+	if strings.HasPrefix(s, "FL") {
+		fl, err := strconv.Atoi(strings.TrimSpace(s[2:]))
+		return 100 * fl, err
+	}
+
+	for _, suffix := range []string{"AMSL", "MSL", "AGL", "ASFC", "SFC", "GND"} {
+		s = strings.TrimSpace(strings.TrimSuffix(s, suffix))
+	}
+	scale := float64(1)
+	if strings.HasSuffix(s, "FT") {
+		s = strings.TrimSuffix(s, "FT")
+	} else if strings.HasSuffix(s, "F") {
+		s = strings.TrimSuffix(s, "F")
+	} else if strings.HasSuffix(s, "M") {
+		s, scale = strings.TrimSuffix(s, "M"), 3.28084
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid altitude", s)
+	}
+	return int(v*scale + 0.5), nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// OpenAir
+
+// parseOpenAirLatLong parses OpenAir coordinates like "39:29:03 N
+// 119:46:15 W" or "39:29.05N 119:46.25W".
+func parseOpenAirLatLong(s string) (Point2LL, error) {
+	s = strings.ToUpper(s)
+	ns := strings.IndexAny(s, "NS")
+	if ns == -1 {
+		return Point2LL{}, fmt.Errorf("%s: missing N/S", s)
+	}
+	ew := strings.IndexAny(s[ns+1:], "EW")
+	if ew == -1 {
+		return Point2LL{}, fmt.Errorf("%s: missing E/W", s)
+	}
+	ew += ns + 1
+
+	dms := func(v string) (float32, error) {
+		var d float64
+		for i, f := range strings.Split(strings.TrimSpace(v), ":") {
+			x, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil || i > 2 {
+				return 0, fmt.Errorf("%s: invalid coordinate", v)
+			}
+			d += x / [3]float64{1, 60, 3600}[i]
+		}
+		return float32(d), nil
+	}
+
+	lat, err := dms(s[:ns])
+	if err != nil {
+		return Point2LL{}, err
+	}
+	long, err := dms(s[ns+1 : ew])
+	if err != nil {
+		return Point2LL{}, err
+	}
+	if s[ns] == 'S' {
+		lat = -lat
+	}
+	if s[ew] == 'W' {
+		long = -long
+	}
+	return Point2LL{long, lat}, nil
+}
+
+// suaArc returns points along an arc around the center from the starting
+// bearing to the ending one (both in degrees true), going clockwise if
+// cw is true and counterclockwise otherwise. The endpoints are included.
+func suaArc(center Point2LL, radius, from, to float32, cw bool) []Point2LL {
+	sweep := to - from
+	if cw {
+		for sweep <= 0 {
+			sweep += 360
+		}
+	} else {
+		for sweep >= 0 {
+			sweep -= 360
+		}
+	}
+
+	nmPerLongitude := 60 * cos(radians(center[1]))
+	n := max(2, int(abs(sweep)/5))
+	var pts []Point2LL
+	for i := 0; i <= n; i++ {
+		theta := radians(from + sweep*float32(i)/float32(n))
+		pts = append(pts, Point2LL{center[0] + radius*sin(theta)/nmPerLongitude,
+			center[1] + radius*cos(theta)/60})
+	}
+	return pts
+}
+
+// ParseOpenAir parses an airspace file in the OpenAir format; see
+// http://www.winpilot.com/UsersGuide/UserAirspace.asp. OpenAir doesn't
+// specify when areas are active, so they are all taken to always be.
+func ParseOpenAir(r io.Reader) ([]SUA, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var areas []SUA
+	var errs []string
+	var cur *SUA
+	center := Point2LL{}
+	cw := true
+
+	finish := func() {
+		if cur != nil && len(cur.Boundary) > 2 {
+			closeSUA(cur)
+			areas = append(areas, *cur)
+		}
+		cur = nil
+		cw = true
+	}
+
+	lineno := 0
+	for _, line := range strings.Split(strings.ReplaceAll(string(contents), "\r", ""), "\n") {
+		lineno++
+		if i := strings.Index(line, "*"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		cmd, arg = strings.ToUpper(cmd), strings.TrimSpace(arg)
+		var err error
+		if cmd == "AC" {
+			finish()
+			cur = &SUA{Class: arg}
+			continue
+		} else if cur == nil {
+			// Records are expected to start with AC; skip anything else.
+			continue
+		}
+
+		switch cmd {
+		case "AN":
+			cur.Name = arg
+
+		case "AL":
+			cur.Floor, err = parseSUAAltitude(arg)
+
+		case "AH":
+			cur.Ceiling, err = parseSUAAltitude(arg)
+
+		case "DP":
+			var p Point2LL
+			if p, err = parseOpenAirLatLong(arg); err == nil {
+				cur.Boundary = append(cur.Boundary, p)
+			}
+
+		case "V":
+			k, v, _ := strings.Cut(arg, "=")
+			switch strings.ToUpper(strings.TrimSpace(k)) {
+			case "X":
+				center, err = parseOpenAirLatLong(v)
+			case "D":
+				cw = strings.TrimSpace(v) != "-"
+			}
+
+		case "DC":
+			var radius float64
+			if radius, err = strconv.ParseFloat(arg, 32); err == nil {
+				cur.Boundary = append(cur.Boundary, circleLatLong(center, float32(radius), 72)...)
+			}
+
+		case "DA":
+			f := strings.Split(arg, ",")
+			var v [3]float64
+			if len(f) != 3 {
+				err = errors.New("expected radius, start angle, end angle")
+			}
+			for i := 0; i < len(f) && i < 3 && err == nil; i++ {
+				v[i], err = strconv.ParseFloat(strings.TrimSpace(f[i]), 32)
+			}
+			if err == nil {
+				cur.Boundary = append(cur.Boundary, suaArc(center, float32(v[0]), float32(v[1]), float32(v[2]), cw)...)
+			}
+
+		case "DB":
+			f := strings.Split(arg, ",")
+			if len(f) != 2 {
+				err = errors.New("expected two coordinates")
+				break
+			}
+			var p0, p1 Point2LL
+			if p0, err = parseOpenAirLatLong(f[0]); err != nil {
+				break
+			}
+			if p1, err = parseOpenAirLatLong(f[1]); err != nil {
+				break
+			}
+			// Bearings from the center, measured clockwise from north.
+			nmPerLongitude := 60 * cos(radians(center[1]))
+			bearing := func(p Point2LL) float32 {
+				return degrees(atan2((p[0]-center[0])*nmPerLongitude, (p[1]-center[1])*60))
+			}
+			radius := nmdistance2ll(center, p0)
+			from, to := bearing(p0), bearing(p1)
+			arc := suaArc(center, radius, from, to, cw)
+			// Use the given endpoints exactly.
+			arc[0], arc[len(arc)-1] = p0, p1
+			cur.Boundary = append(cur.Boundary, arc...)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%d: %v", lineno, err))
+		}
+	}
+	finish()
+
+	if len(errs) > 0 {
+		return areas, errors.New(strings.Join(errs, "\n"))
+	}
+	return areas, nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// AIXM
+
+// aixmAirspace stores the parts of an AIXM 5 Airspace feature that we
+// use.
+type aixmAirspace struct {
+	designator, name, class string
+	// Validity period
+	start, end time.Time
+	// Activation timesheets
+	schedule []SUASchedule
+	volumes  []aixmVolume
+}
+
+type aixmVolume struct {
+	floor, ceiling int
+	// From minimumLimit, which is used for minimum vectoring altitudes;
+	// -1 if it isn't specified.
+	minimum   int
+	exterior  []Point2LL
+	interiors [][]Point2LL
+}
+
+// aixmCurve stores a GML CircleByCenterPoint or ArcByCenterPoint
+// segment. AIXM gives the angles of arcs as true bearings; the arc goes
+// clockwise if the end angle is greater than the start angle and
+// counterclockwise otherwise.
+type aixmCurve struct {
+	center     Point2LL
+	radius     float32 // nm
+	start, end float32 // degrees
+}
+
+// parseAIXMDistance parses a distance with the given unit of measure and
+// returns it in nautical miles.
+func parseAIXMDistance(s, uom string) (float32, error) {
+	d, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid distance", s)
+	}
+	switch strings.ToUpper(uom) {
+	case "NM", "[NMI_I]":
+		return float32(d), nil
+	case "KM":
+		return float32(d / 1.852), nil
+	case "M":
+		return float32(d / 1852), nil
+	case "FT", "[FT_I]":
+		return float32(d * 0.3048 / 1852), nil
+	case "MI", "[MI_I]":
+		return float32(d * 1609.344 / 1852), nil
+	default:
+		return 0, fmt.Errorf("%s: unsupported unit of measure for distance", uom)
+	}
+}
+
+// parseAIXMAngle parses an angle with the given unit of measure and
+// returns it in degrees.
+func parseAIXMAngle(s, uom string) (float32, error) {
+	a, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid angle", s)
+	}
+	switch strings.ToLower(uom) {
+	case "", "deg":
+		return float32(a), nil
+	case "rad":
+		return degrees(float32(a)), nil
+	default:
+		return 0, fmt.Errorf("%s: unsupported unit of measure for angle", uom)
+	}
+}
+
+// parseAIXMTimeReference parses an AIXM timeReference like "UTC" or
+// "UTC-5" and returns its offset from UTC in minutes.
+func parseAIXMTimeReference(s string) (int, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "UTC") {
+		return 0, fmt.Errorf("%s: unsupported time reference", s)
+	}
+	s = s[3:]
+	if s == "" {
+		return 0, nil
+	}
+
+	sign := 1
+	if s[0] == '-' {
+		sign = -1
+	} else if s[0] != '+' {
+		return 0, fmt.Errorf("UTC%s: unsupported time reference", s)
+	}
+	h, m, _ := strings.Cut(s[1:], ":")
+	hh, err := strconv.Atoi(h)
+	if err != nil || hh > 14 {
+		return 0, fmt.Errorf("UTC%s: invalid offset", s)
+	}
+	mm := 0
+	if m != "" {
+		if mm, err = strconv.Atoi(m); err != nil || mm >= 60 {
+			return 0, fmt.Errorf("UTC%s: invalid offset", s)
+		}
+	}
+	return sign * (60*hh + mm), nil
+}
+
+// parseAIXMAirspaces parses the Airspace features of an AIXM 5 file.
+// Boundaries may include GML circles and arcs around a center point,
+// which are approximated with line segments.
+//
+// Timesheets with an unsupported timeReference are skipped (and so, if
+// all of an airspace's are, it is taken to always be active). Daylight
+// saving time adjustments follow the US rules and are only applied to
+// time references west of UTC. Since FAA files have many timesheets,
+// problems with them are summarized in a single log message.
+func parseAIXMAirspaces(r io.Reader) ([]aixmAirspace, error) {
+	var airspaces []aixmAirspace
+	// Unsupported time references and the number of timesheets that had
+	// them, and the number of timesheets with daylight saving time
+	// adjustments that weren't applied.
+	badTimeReferences := make(map[string]int)
+	ignoredDST := 0
+	defer func() {
+		for _, ref := range SortedMapKeys(badTimeReferences) {
+			lg.Errorf("%q: unsupported time reference; ignored %d timesheets", ref, badTimeReferences[ref])
+		}
+		if ignoredDST > 0 {
+			lg.Errorf("%d timesheets: daylight saving time adjustments are only supported for "+
+				"time references west of UTC; activation times may be off by an hour", ignoredDST)
+		}
+	}()
+
+	var cur *aixmAirspace
+	var vol aixmVolume
+	var sched SUASchedule
+	schedValid := true
+	var text strings.Builder
+	var uom string
+	// Points of the ring currently being parsed and whether it is a hole.
+	var ring []Point2LL
+	interior := false
+	// The circle or arc currently being parsed, if any.
+	var curve *aixmCurve
+
+	limit := func(s, uom string) int {
+		alt, err := parseSUAAltitude(s)
+		if err != nil {
+			lg.Errorf("%s: %v", s, err)
+			return 0
+		}
+		if alt != suaUnlimitedAltitude {
+			switch strings.ToUpper(uom) {
+			case "FL":
+				alt *= 100
+			case "M":
+				alt = int(float32(alt)*3.28084 + 0.5)
+			}
+		}
+		return alt
+	}
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return airspaces, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			switch t.Name.Local {
+			case "Airspace":
+				cur = &aixmAirspace{}
+			case "AirspaceVolume":
+				vol = aixmVolume{ceiling: suaUnlimitedAltitude, minimum: -1}
+			case "Timesheet":
+				sched, schedValid = SUASchedule{}, true
+			case "exterior", "interior":
+				ring, interior = nil, t.Name.Local == "interior"
+			case "CircleByCenterPoint", "ArcByCenterPoint":
+				curve = &aixmCurve{}
+			case "upperLimit", "lowerLimit", "minimumLimit", "radius", "startAngle", "endAngle":
+				uom = ""
+				for _, a := range t.Attr {
+					if a.Name.Local == "uom" {
+						uom = a.Value
+					}
+				}
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			if cur == nil {
+				continue
+			}
+			s := strings.TrimSpace(text.String())
+			text.Reset()
+
+			switch t.Name.Local {
+			// Airspace features may include other ones with these
+			// elements (e.g., the controlling authority), so take the
+			// first of each.
+			case "designator":
+				if cur.designator == "" {
+					cur.designator = s
+				}
+			case "name":
+				if cur.name == "" {
+					cur.name = s
+				}
+			case "type":
+				if cur.class == "" {
+					cur.class = s
+				}
+
+			case "upperLimit":
+				vol.ceiling = limit(s, uom)
+			case "lowerLimit":
+				vol.floor = limit(s, uom)
+			case "minimumLimit":
+				vol.minimum = limit(s, uom)
+			case "posList", "pos":
+				// GML gives latitude first.
+				var pts []Point2LL
+				f := strings.Fields(s)
+				for i := 0; i+1 < len(f); i += 2 {
+					lat, err0 := strconv.ParseFloat(f[i], 32)
+					long, err1 := strconv.ParseFloat(f[i+1], 32)
+					if err0 != nil || err1 != nil {
+						return airspaces, fmt.Errorf("%s %s: invalid position", f[i], f[i+1])
+					}
+					pts = append(pts, Point2LL{float32(long), float32(lat)})
+				}
+				if curve == nil {
+					ring = append(ring, pts...)
+				} else if len(pts) > 0 {
+					curve.center = pts[0]
+				}
+			case "radius":
+				if curve != nil {
+					if curve.radius, err = parseAIXMDistance(s, uom); err != nil {
+						return airspaces, err
+					}
+				}
+			case "startAngle", "endAngle":
+				if curve != nil {
+					var a float32
+					if a, err = parseAIXMAngle(s, uom); err != nil {
+						return airspaces, err
+					}
+					if t.Name.Local == "startAngle" {
+						curve.start = a
+					} else {
+						curve.end = a
+					}
+				}
+			case "CircleByCenterPoint":
+				ring = append(ring, circleLatLong(curve.center, curve.radius, 72)...)
+				curve = nil
+			case "ArcByCenterPoint":
+				ring = append(ring, suaArc(curve.center, curve.radius, curve.start, curve.end, curve.end > curve.start)...)
+				curve = nil
+			case "exterior", "interior":
+				if interior {
+					vol.interiors = append(vol.interiors, ring)
+				} else {
+					vol.exterior = append(vol.exterior, ring...)
+				}
+				ring, interior = nil, false
+			case "AirspaceVolume":
+				// Positions that weren't inside an exterior or interior
+				// element are taken to be the exterior.
+				vol.exterior = append(vol.exterior, ring...)
+				ring = nil
+				if len(vol.exterior) > 2 {
+					cur.volumes = append(cur.volumes, vol)
+				}
+
+			case "beginPosition":
+				cur.start, _ = time.Parse(time.RFC3339, s)
+			case "endPosition":
+				cur.end, _ = time.Parse(time.RFC3339, s)
+
+			case "day":
+				days := map[string][]time.Weekday{
+					"MON": {time.Monday}, "TUE": {time.Tuesday}, "WED": {time.Wednesday},
+					"THU": {time.Thursday}, "FRI": {time.Friday}, "SAT": {time.Saturday},
+					"SUN": {time.Sunday}, "ANY": nil,
+					"WORK_DAY": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+				}
+				sched.Days = days[strings.ToUpper(s)]
+			case "startTime", "endTime":
+				if h, m, ok := strings.Cut(s, ":"); ok {
+					hh, _ := strconv.Atoi(h)
+					mm, _ := strconv.Atoi(m)
+					if t.Name.Local == "startTime" {
+						sched.Start = 60*hh + mm
+					} else {
+						sched.End = 60*hh + mm
+					}
+				}
+			case "timeReference":
+				var err error
+				if sched.Offset, err = parseAIXMTimeReference(s); err != nil {
+					badTimeReferences[s]++
+					schedValid = false
+				}
+			case "daylightSavingAdjust":
+				sched.DST = strings.ToUpper(s) == "YES"
+			case "Timesheet":
+				if !schedValid {
+					break
+				}
+				if sched.DST && sched.Offset >= 0 {
+					ignoredDST++
+					sched.DST = false
+				}
+				cur.schedule = append(cur.schedule, sched)
+
+			case "Airspace":
+				airspaces = append(airspaces, *cur)
+				cur = nil
+			}
+		}
+	}
+	return airspaces, nil
+}
+
+// ParseAIXMSUA parses the Airspace features of an AIXM 5 file such as
+// the FAA's special activity airspace (SAA) export. Only the parts that
+// we need are interpreted: the designator, name, and type, the vertical
+// limits and boundaries of its volumes, its validity period, and its
+// activation timesheets.
+func ParseAIXMSUA(r io.Reader) ([]SUA, error) {
+	airspaces, err := parseAIXMAirspaces(r)
+
+	var areas []SUA
+	for _, a := range airspaces {
+		for _, v := range a.volumes {
+			s := SUA{
+				Name:           Select(a.designator != "", a.designator, a.name),
+				Class:          a.class,
+				Floor:          v.floor,
+				Ceiling:        v.ceiling,
+				Boundary:       v.exterior,
+				Holes:          v.interiors,
+				EffectiveStart: a.start,
+				EffectiveEnd:   a.end,
+				Schedule:       a.schedule,
+			}
+			closeSUA(&s)
+			areas = append(areas, s)
+		}
+	}
+	return areas, err
+}
+
+///////////////////////////////////////////////////////////////////////////
+// Alerts
+
+// SUAAlert records that an aircraft is predicted to enter an active SUA
+// area.
+type SUAAlert struct {
+	SUA *SUA
+	// Where the aircraft will enter the area or its current position if
+	// it is already inside.
+	P Point2LL
+	// Minutes until the aircraft enters it; 0 if it's already inside.
+	Minutes float32
+}
+
+// suaAlerts returns the areas that are active at the given time that an
+// aircraft at p and the given altitude will enter within the given
+// number of minutes if it continues along the per-minute velocity v.
+// The aircraft's altitude is assumed to be constant. Alerts are sorted
+// by the time until the aircraft enters the area.
+func suaAlerts(areas []SUA, p, v Point2LL, altitude int, minutes float32, t time.Time) []SUAAlert {
+	var alerts []SUAAlert
+	p1 := add2ll(p, scale2ll(v, minutes))
+	for i := range areas {
+		s := &areas[i]
+		if altitude < s.Floor || altitude > s.Ceiling || !s.Active(t) {
+			continue
+		}
+		if s.Inside(p) {
+			alerts = append(alerts, SUAAlert{SUA: s, P: p})
+		} else if pi, ti, ok := s.geometry.FirstIntersection(p, p1); ok {
+			alerts = append(alerts, SUAAlert{SUA: s, P: pi, Minutes: ti * minutes})
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].Minutes < alerts[j].Minutes })
+	return alerts
+}
+
+// SUAAlerts returns the active SUA areas that the aircraft will enter
+// within the given number of minutes if it continues on its current
+// track.
+func (db *StaticDatabase) SUAAlerts(ac *Aircraft, minutes float32) []SUAAlert {
+	if len(db.sua) == 0 || !ac.HaveTrack() || ac.OnGround() {
+		return nil
+	}
+	return suaAlerts(db.sua, ac.Position(), ac.HeadingVector(), ac.Altitude(), minutes, server.CurrentTime())
+}
+
+// SUAAlerts returns the result of StaticDatabase.SUAAlerts for the
+// aircraft. It is cached until the aircraft's position is updated.
+func (a *Aircraft) SUAAlerts(minutes float32) []SUAAlert {
+	if !a.suaAlertsTrackTime.Equal(a.Tracks[0].Time) || a.suaAlertsTrackTime.IsZero() ||
+		a.suaAlertMinutes != minutes {
+		a.suaAlerts = database.SUAAlerts(a, minutes)
+		a.suaAlertMinutes, a.suaAlertsTrackTime = minutes, a.Tracks[0].Time
+	}
+	return a.suaAlerts
+}
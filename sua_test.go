@@ -0,0 +1,419 @@
+// sua_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSUAAltitude(t *testing.T) {
+	for _, test := range []struct {
+		s   string
+		alt int
+	}{
+		{"SFC", 0},
+		{"GND", 0},
+		{"FL180", 18000},
+		{"FL 65", 6500},
+		{"5000ft MSL", 5000},
+		{"5000 ft AMSL", 5000},
+		{"1500 AGL", 1500},
+		{"3500", 3500},
+		{"UNL", suaUnlimitedAltitude},
+	} {
+		if alt, err := parseSUAAltitude(test.s); err != nil || alt != test.alt {
+			t.Errorf("%q: got %d (err %v), expected %d", test.s, alt, err, test.alt)
+		}
+	}
+}
+
+const testOpenAir = `* Test airspace
+AC R
+AN R-2508
+AL SFC
+AH FL180
+DP 35:00:00 N 118:00:00 W
+DP 35:00:00 N 117:00:00 W
+DP 36:00:00 N 117:00:00 W
+DP 36:00:00 N 118:00:00 W
+
+AC P
+AN P-56
+AL SFC
+AH 18000ft MSL
+V X=38:53:24 N 077:02:12 W
+DC 1
+
+AC Q
+AN ARC
+AL 2000
+AH 5000
+V X=40:00:00 N 074:00:00 W
+V D=-
+DB 40:10:00 N 074:00:00 W, 39:50:00 N 074:00:00 W
+DP 40:00:00 N 074:00:00 W
+`
+
+func TestParseOpenAir(t *testing.T) {
+	areas, err := ParseOpenAir(strings.NewReader(testOpenAir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(areas) != 3 {
+		t.Fatalf("expected 3 areas, got %d", len(areas))
+	}
+
+	r := areas[0]
+	if r.Name != "R-2508" || r.Class != "R" || r.Floor != 0 || r.Ceiling != 18000 || len(r.Boundary) != 5 {
+		t.Errorf("R-2508 mismatch: %+v", r)
+	}
+	if r.Boundary[1] != (Point2LL{-117, 35}) {
+		t.Errorf("R-2508 point mismatch: %v", r.Boundary[1])
+	}
+	if r.AltitudeDescription() != "SFC-180" {
+		t.Errorf("R-2508 altitude description: %s", r.AltitudeDescription())
+	}
+
+	p56 := areas[1]
+	center := Point2LL{-(77 + 2./60 + 12./3600), 38 + 53./60 + 24./3600}
+	for _, p := range p56.Boundary {
+		if d := nmdistance2ll(center, p); abs(d-1) > 0.02 {
+			t.Errorf("P-56 point %v is %f nm from center", p, d)
+		}
+	}
+	if !p56.geometry.Inside(center) {
+		t.Errorf("P-56 center not inside")
+	}
+
+	// Counterclockwise from north to south goes around the west side.
+	arc := areas[2]
+	if arc.Boundary[0] != (Point2LL{-74, 40 + 10./60}) {
+		t.Errorf("arc start mismatch: %v", arc.Boundary[0])
+	}
+	if !arc.geometry.Inside(Point2LL{-74.1, 40}) || arc.geometry.Inside(Point2LL{-73.9, 40}) {
+		t.Errorf("arc went the wrong way: %v", arc.Boundary)
+	}
+}
+
+const testAIXM = `<?xml version="1.0" encoding="UTF-8"?>
+<message:AIXMBasicMessage xmlns:message="http://www.aixm.aero/schema/5.1/message"
+    xmlns:aixm="http://www.aixm.aero/schema/5.1" xmlns:gml="http://www.opengis.net/gml/3.2">
+  <message:hasMember>
+    <aixm:Airspace gml:id="A1">
+      <aixm:timeSlice>
+        <aixm:AirspaceTimeSlice gml:id="A1-1">
+          <gml:validTime>
+            <gml:TimePeriod gml:id="T1">
+              <gml:beginPosition>2023-10-01T00:00:00Z</gml:beginPosition>
+              <gml:endPosition>2023-11-01T00:00:00Z</gml:endPosition>
+            </gml:TimePeriod>
+          </gml:validTime>
+          <aixm:type>MOA</aixm:type>
+          <aixm:designator>TEST MOA</aixm:designator>
+          <aixm:name>TEST MILITARY OPERATIONS AREA</aixm:name>
+          <aixm:activation>
+            <aixm:AirspaceActivation gml:id="AA1">
+              <aixm:timeInterval>
+                <aixm:Timesheet gml:id="TS1">
+                  <aixm:timeReference>UTC</aixm:timeReference>
+                  <aixm:day>WORK_DAY</aixm:day>
+                  <aixm:startTime>13:00</aixm:startTime>
+                  <aixm:endTime>02:00</aixm:endTime>
+                </aixm:Timesheet>
+              </aixm:timeInterval>
+            </aixm:AirspaceActivation>
+          </aixm:activation>
+          <aixm:geometryComponent>
+            <aixm:AirspaceGeometryComponent gml:id="G1">
+              <aixm:theAirspaceVolume>
+                <aixm:AirspaceVolume gml:id="V1">
+                  <aixm:upperLimit uom="FL">180</aixm:upperLimit>
+                  <aixm:lowerLimit uom="FT">8000</aixm:lowerLimit>
+                  <aixm:horizontalProjection>
+                    <aixm:Surface gml:id="S1">
+                      <gml:patches>
+                        <gml:PolygonPatch>
+                          <gml:exterior>
+                            <gml:LinearRing>
+                              <gml:posList>35 -80 35 -79 36 -79 36 -80 35 -80</gml:posList>
+                            </gml:LinearRing>
+                          </gml:exterior>
+                        </gml:PolygonPatch>
+                      </gml:patches>
+                    </aixm:Surface>
+                  </aixm:horizontalProjection>
+                </aixm:AirspaceVolume>
+              </aixm:theAirspaceVolume>
+            </aixm:AirspaceGeometryComponent>
+          </aixm:geometryComponent>
+        </aixm:AirspaceTimeSlice>
+      </aixm:timeSlice>
+    </aixm:Airspace>
+  </message:hasMember>
+</message:AIXMBasicMessage>
+`
+
+func TestParseAIXMSUA(t *testing.T) {
+	areas, err := ParseAIXMSUA(strings.NewReader(testAIXM))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(areas) != 1 {
+		t.Fatalf("expected 1 area, got %d", len(areas))
+	}
+	moa := areas[0]
+	if moa.Name != "TEST MOA" || moa.Class != "MOA" || moa.Floor != 8000 || moa.Ceiling != 18000 ||
+		len(moa.Boundary) != 5 || moa.Boundary[1] != (Point2LL{-79, 35}) {
+		t.Errorf("MOA mismatch: %+v", moa)
+	}
+
+	for _, test := range []struct {
+		t      string
+		active bool
+	}{
+		{"2023-10-02T14:00:00Z", true},  // Monday afternoon
+		{"2023-10-03T01:00:00Z", true},  // Monday's period continues past midnight
+		{"2023-10-03T03:00:00Z", false}, // Tuesday morning
+		{"2023-10-07T14:00:00Z", false}, // Saturday
+		{"2023-10-07T01:00:00Z", true},  // Friday's period
+		{"2023-11-02T14:00:00Z", false}, // No longer in effect
+	} {
+		tm, _ := time.Parse(time.RFC3339, test.t)
+		if moa.Active(tm) != test.active {
+			t.Errorf("%s: expected active %v", test.t, test.active)
+		}
+	}
+}
+
+func TestSUAAlerts(t *testing.T) {
+	areas, err := ParseOpenAir(strings.NewReader(testOpenAir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	// Heading east at 360 knots, 6nm/minute, toward R-2508's western
+	// boundary at 118W.
+	v := Point2LL{6 / (60 * cos(radians(35.5))), 0}
+	p := Point2LL{-118.2, 35.5}
+	alerts := suaAlerts(areas, p, v, 10000, 3, now)
+	if len(alerts) != 1 || alerts[0].SUA.Name != "R-2508" || abs(alerts[0].P[0]+118) > 1e-4 ||
+		abs(alerts[0].Minutes-1.63) > 0.05 {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+
+	// Not there yet in one minute.
+	if alerts := suaAlerts(areas, p, v, 10000, 1, now); len(alerts) != 0 {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+	// Above it
+	if alerts := suaAlerts(areas, p, v, 20000, 3, now); len(alerts) != 0 {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+	// Already inside
+	if alerts := suaAlerts(areas, Point2LL{-117.5, 35.5}, v, 10000, 3, now); len(alerts) != 1 || alerts[0].Minutes != 0 {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+}
+
+func TestSUAHoles(t *testing.T) {
+	const aixm = `<aixm:AIXMBasicMessage xmlns:aixm="http://www.aixm.aero/schema/5.1" xmlns:gml="http://www.opengis.net/gml/3.2">
+  <aixm:Airspace>
+    <aixm:type>R</aixm:type>
+    <aixm:designator>R-1</aixm:designator>
+    <aixm:AirspaceVolume>
+      <aixm:upperLimit uom="FL">180</aixm:upperLimit>
+      <aixm:lowerLimit uom="FT">0</aixm:lowerLimit>
+      <aixm:horizontalProjection>
+        <gml:PolygonPatch>
+          <gml:exterior><gml:LinearRing><gml:posList>40 -75 40 -74 41 -74 41 -75 40 -75</gml:posList></gml:LinearRing></gml:exterior>
+          <gml:interior><gml:LinearRing><gml:posList>40.4 -74.6 40.4 -74.4 40.6 -74.4 40.6 -74.6</gml:posList></gml:LinearRing></gml:interior>
+        </gml:PolygonPatch>
+      </aixm:horizontalProjection>
+    </aixm:AirspaceVolume>
+  </aixm:Airspace>
+</aixm:AIXMBasicMessage>`
+
+	areas, err := ParseAIXMSUA(strings.NewReader(aixm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(areas) != 1 || len(areas[0].Holes) != 1 || len(areas[0].Holes[0]) != 5 {
+		t.Fatalf("unexpected areas: %+v", areas)
+	}
+	if !areas[0].Inside(Point2LL{-74.8, 40.2}) || areas[0].Inside(Point2LL{-74.5, 40.5}) {
+		t.Errorf("hole not handled")
+	}
+
+	// In the hole, heading east at 6nm/minute; it leaves the hole at
+	// -74.4 in about a minute.
+	v := Point2LL{6 / (60 * cos(radians(40.5))), 0}
+	alerts := suaAlerts(areas, Point2LL{-74.5, 40.5}, v, 5000, 3, time.Now())
+	if len(alerts) != 1 || abs(alerts[0].P[0]+74.4) > 1e-4 || abs(alerts[0].Minutes-0.76) > 0.05 {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+}
+
+// aixmArcRing is an AIXM ring with a 30nm arc around 40.5N 74W that
+// bulges east between 40N and 41N.
+const aixmArcRing = `<gml:Ring><gml:curveMember><gml:Curve><gml:segments>
+  <gml:GeodesicString><gml:posList>40 -75 40 -74</gml:posList></gml:GeodesicString>
+  <gml:ArcByCenterPoint numArc="1">
+    <gml:pointProperty><gml:Point><gml:pos>40.5 -74</gml:pos></gml:Point></gml:pointProperty>
+    <gml:radius uom="[nmi_i]">30</gml:radius>
+    <gml:startAngle uom="deg">180</gml:startAngle>
+    <gml:endAngle uom="deg">0</gml:endAngle>
+  </gml:ArcByCenterPoint>
+  <gml:GeodesicString><gml:posList>41 -74 41 -75 40 -75</gml:posList></gml:GeodesicString>
+</gml:segments></gml:Curve></gml:curveMember></gml:Ring>`
+
+func TestSUACirclesAndArcs(t *testing.T) {
+	const aixm = `<aixm:AIXMBasicMessage xmlns:aixm="http://www.aixm.aero/schema/5.1" xmlns:gml="http://www.opengis.net/gml/3.2">
+  <aixm:Airspace>
+    <aixm:type>TFR</aixm:type>
+    <aixm:designator>TFR-1</aixm:designator>
+    <aixm:AirspaceVolume>
+      <aixm:upperLimit uom="FT">3000</aixm:upperLimit>
+      <aixm:lowerLimit uom="FT">0</aixm:lowerLimit>
+      <aixm:horizontalProjection><gml:PolygonPatch><gml:exterior><gml:Ring><gml:curveMember><gml:Curve><gml:segments>
+        <gml:CircleByCenterPoint numArc="1">
+          <gml:pos>40.5 -74</gml:pos>
+          <gml:radius uom="KM">9.26</gml:radius>
+        </gml:CircleByCenterPoint>
+      </gml:segments></gml:Curve></gml:curveMember></gml:Ring></gml:exterior></gml:PolygonPatch></aixm:horizontalProjection>
+    </aixm:AirspaceVolume>
+  </aixm:Airspace>
+  <aixm:Airspace>
+    <aixm:type>R</aixm:type>
+    <aixm:designator>R-2</aixm:designator>
+    <aixm:AirspaceVolume>
+      <aixm:upperLimit uom="FL">180</aixm:upperLimit>
+      <aixm:lowerLimit uom="FT">0</aixm:lowerLimit>
+      <aixm:horizontalProjection><gml:PolygonPatch><gml:exterior>` + aixmArcRing + `</gml:exterior></gml:PolygonPatch></aixm:horizontalProjection>
+    </aixm:AirspaceVolume>
+  </aixm:Airspace>
+</aixm:AIXMBasicMessage>`
+
+	areas, err := ParseAIXMSUA(strings.NewReader(aixm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(areas) != 2 {
+		t.Fatalf("expected 2 areas, got %d", len(areas))
+	}
+
+	center := Point2LL{-74, 40.5}
+	for _, p := range areas[0].Boundary {
+		if d := nmdistance2ll(center, p); abs(d-5) > 0.05 {
+			t.Errorf("%v: circle point is %fnm from the center", p, d)
+		}
+	}
+	if !areas[0].Inside(center) || areas[0].Inside(Point2LL{-74, 40.6}) {
+		t.Errorf("circle not handled")
+	}
+
+	for _, p := range areas[1].Boundary {
+		if p == center {
+			t.Errorf("arc center included in the boundary")
+		}
+		if p[0] > -74 {
+			if d := nmdistance2ll(center, p); abs(d-30) > 0.3 {
+				t.Errorf("%v: arc point is %fnm from the center", p, d)
+			}
+		}
+	}
+	if !areas[1].Inside(Point2LL{-73.7, 40.5}) || areas[1].Inside(Point2LL{-73.7, 40.02}) {
+		t.Errorf("arc not handled")
+	}
+}
+
+func TestSUAScheduleTimeReference(t *testing.T) {
+	for _, test := range []struct {
+		s      string
+		offset int
+		ok     bool
+	}{
+		{"UTC", 0, true},
+		{"UTC-5", -300, true},
+		{"UTC+05:30", 330, true},
+		{"LT", 0, false},
+		{"UTC*3", 0, false},
+	} {
+		if offset, err := parseAIXMTimeReference(test.s); offset != test.offset || (err == nil) != test.ok {
+			t.Errorf("%q: got %d (err %v), expected %d ok %v", test.s, offset, err, test.offset, test.ok)
+		}
+	}
+
+	timesheet := func(ref string) string {
+		return strings.Replace(testAIXM, "<aixm:timeReference>UTC</aixm:timeReference>",
+			"<aixm:timeReference>"+ref+"</aixm:timeReference>", 1)
+	}
+
+	// 13:00-02:00 on work days in UTC-5 is 18:00-07:00 UTC.
+	areas, err := ParseAIXMSUA(strings.NewReader(timesheet("UTC-5")))
+	if err != nil || len(areas) != 1 {
+		t.Fatalf("unexpected result: %+v %v", areas, err)
+	}
+	for _, test := range []struct {
+		t      string
+		active bool
+	}{
+		{"2023-10-02T14:00:00Z", false}, // Monday 09:00 local
+		{"2023-10-02T19:00:00Z", true},  // Monday 14:00 local
+		{"2023-10-03T06:00:00Z", true},  // Tuesday 01:00 local
+		{"2023-10-03T08:00:00Z", false}, // Tuesday 03:00 local
+		{"2023-10-07T03:00:00Z", true},  // Friday 22:00 local
+		{"2023-10-07T19:00:00Z", false}, // Saturday 14:00 local
+	} {
+		tm, _ := time.Parse(time.RFC3339, test.t)
+		if areas[0].Active(tm) != test.active {
+			t.Errorf("%s: expected active %v", test.t, test.active)
+		}
+	}
+
+	// With daylight saving time, it's 17:00-06:00 UTC in October.
+	dst := strings.Replace(timesheet("UTC-5"), "</aixm:timeReference>",
+		"</aixm:timeReference><aixm:daylightSavingAdjust>YES</aixm:daylightSavingAdjust>", 1)
+	areas, err = ParseAIXMSUA(strings.NewReader(dst))
+	if err != nil || len(areas) != 1 || len(areas[0].Schedule) != 1 || !areas[0].Schedule[0].DST {
+		t.Fatalf("unexpected result: %+v %v", areas, err)
+	}
+	for _, test := range []struct {
+		t      string
+		active bool
+	}{
+		{"2023-10-02T17:30:00Z", true},  // Monday 13:30 local
+		{"2023-10-03T05:30:00Z", true},  // Tuesday 01:30 local
+		{"2023-10-03T06:30:00Z", false}, // Tuesday 02:30 local
+	} {
+		tm, _ := time.Parse(time.RFC3339, test.t)
+		if areas[0].Active(tm) != test.active {
+			t.Errorf("%s: expected active %v with DST", test.t, test.active)
+		}
+	}
+	for _, test := range []struct {
+		t   string
+		dst bool
+	}{
+		{"2023-03-12T06:59:00Z", false},
+		{"2023-03-12T07:00:00Z", true},
+		{"2023-11-05T05:59:00Z", true},
+		{"2023-11-05T06:00:00Z", false},
+		{"2024-01-15T12:00:00Z", false},
+	} {
+		tm, _ := time.Parse(time.RFC3339, test.t)
+		if usDaylightSavingTime(tm, -300) != test.dst {
+			t.Errorf("%s: expected DST %v", test.t, test.dst)
+		}
+	}
+
+	// Unsupported time references are ignored.
+	if areas, err := ParseAIXMSUA(strings.NewReader(timesheet("LT"))); err != nil || len(areas) != 1 ||
+		len(areas[0].Schedule) != 0 {
+		t.Errorf("unexpected result: %+v %v", areas, err)
+	}
+}
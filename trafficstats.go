@@ -0,0 +1,472 @@
+// trafficstats.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+///////////////////////////////////////////////////////////////////////////
+// TrafficStatistics
+
+// TrafficStatistics records the takeoffs and landings reported by the
+// MovementDetector so that throughput at airports can be summarized
+// after the fact.
+type TrafficStatistics struct {
+	// ICAO code -> movements, in the order they happened.
+	movements map[string][]RecordedMovement
+	// callsign -> time the aircraft started taxiing for departure
+	taxiStart map[string]time.Time
+
+	eventsId EventSubscriberId
+}
+
+// RecordedMovement stores the details of a single takeoff or landing.
+type RecordedMovement struct {
+	Airport      string
+	Callsign     string
+	AircraftType string
+	Runway       string
+	Departure    bool
+	Time         time.Time
+	// TaxiOut is the time from when a departure was first seen taxiing
+	// until it took off; it is zero if unknown or for arrivals.
+	TaxiOut time.Duration
+	// Spacing is the distance in nm to the next aircraft on final for
+	// the same runway when an arrival touched down; it is zero if
+	// unknown or for departures.
+	Spacing float32
+}
+
+const (
+	// Aircraft moving faster than this (in knots) on the ground at their
+	// departure airport are taken to be taxiing.
+	taxiMinimumSpeed = 5
+	// Following aircraft further than this (nm) on final aren't
+	// considered to be in trail for spacing purposes.
+	finalSpacingLimit = 15
+)
+
+func NewTrafficStatistics() *TrafficStatistics {
+	return &TrafficStatistics{
+		movements: make(map[string][]RecordedMovement),
+		taxiStart: make(map[string]time.Time),
+		eventsId:  eventStream.Subscribe(),
+	}
+}
+
+// Update should be called once per frame after the MovementDetector has
+// been updated.
+func (ts *TrafficStatistics) Update(es *EventStream) {
+	for _, event := range es.Get(ts.eventsId) {
+		switch v := event.(type) {
+		case *AddedAircraftEvent:
+			ts.checkTaxi(v.ac)
+
+		case *ModifiedAircraftEvent:
+			ts.checkTaxi(v.ac)
+
+		case *RemovedAircraftEvent:
+			delete(ts.taxiStart, v.ac.Callsign)
+
+		case *DepartedEvent:
+			m := ts.newMovement(v.ac, v.airport, v.runway, v.time)
+			m.Departure = true
+			if start, ok := ts.taxiStart[v.ac.Callsign]; ok {
+				m.TaxiOut = v.time.Sub(start)
+				delete(ts.taxiStart, v.ac.Callsign)
+			}
+			ts.movements[v.airport] = append(ts.movements[v.airport], m)
+
+		case *LandedEvent:
+			m := ts.newMovement(v.ac, v.airport, v.runway, v.time)
+			m.Spacing = finalSpacing(v.ac, v.airport, v.runway)
+			ts.movements[v.airport] = append(ts.movements[v.airport], m)
+
+		case *ClosedServerConnectionEvent:
+			ts.taxiStart = make(map[string]time.Time)
+		}
+	}
+}
+
+func (ts *TrafficStatistics) newMovement(ac *Aircraft, airport string, runway string, t time.Time) RecordedMovement {
+	m := RecordedMovement{Airport: airport, Callsign: ac.Callsign, Runway: runway, Time: t}
+	if ac.FlightPlan != nil {
+		m.AircraftType = ac.FlightPlan.AircraftType
+	}
+	return m
+}
+
+// checkTaxi notes the time when an aircraft first starts moving on the
+// ground at its departure airport.
+func (ts *TrafficStatistics) checkTaxi(ac *Aircraft) {
+	if _, ok := ts.taxiStart[ac.Callsign]; ok || ac.FlightPlan == nil || !ac.HaveTrack() {
+		return
+	}
+	ap, ok := database.airports[ac.FlightPlan.DepartureAirport]
+	if !ok || nmdistance2ll(ap.Location, ac.Position()) > 5 {
+		return
+	}
+	if ac.OnGround() && ac.Groundspeed() >= taxiMinimumSpeed {
+		ts.taxiStart[ac.Callsign] = server.CurrentTime()
+	}
+}
+
+// finalSpacing returns the distance from the threshold of the given runway
+// to the closest other aircraft on final for it, or zero if there isn't
+// one.
+func finalSpacing(landed *Aircraft, icao string, runway string) float32 {
+	idx := FindIf(database.runways[icao], func(r Runway) bool { return r.Number == runway })
+	if runway == "" || idx == -1 {
+		return 0
+	}
+	threshold := database.runways[icao][idx].Threshold
+
+	var spacing float32
+	for _, ac := range server.GetFilteredAircraft(func(ac *Aircraft) bool {
+		return ac != landed && ac.FlightPlan != nil && ac.FlightPlan.ArrivalAirport == icao && !ac.OnGround()
+	}) {
+		if rwy := aircraftRunway(ac, icao, false); rwy == nil || rwy.Number != runway {
+			continue
+		}
+		if d := nmdistance2ll(threshold, ac.Position()); d < finalSpacingLimit && (spacing == 0 || d < spacing) {
+			spacing = d
+		}
+	}
+	return spacing
+}
+
+// Movements returns all of the recorded movements at the given airports,
+// sorted by time.
+func (ts *TrafficStatistics) Movements(airports map[string]interface{}) []RecordedMovement {
+	var m []RecordedMovement
+	for ap := range airports {
+		m = append(m, ts.movements[ap]...)
+	}
+	sort.SliceStable(m, func(i, j int) bool { return m[i].Time.Before(m[j].Time) })
+	return m
+}
+
+func (ts *TrafficStatistics) Clear(airports map[string]interface{}) {
+	for ap := range airports {
+		delete(ts.movements, ap)
+	}
+}
+
+// WriteCSV writes the movements at the given airports to the specified
+// file in CSV format, one line per movement.
+func (ts *TrafficStatistics) WriteCSV(filename string, airports map[string]interface{}) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"airport", "callsign", "aircraft_type", "movement", "runway", "time_utc",
+		"taxi_out_minutes", "final_spacing_nm"})
+	for _, m := range ts.Movements(airports) {
+		taxi, spacing := "", ""
+		if m.TaxiOut > 0 {
+			taxi = strconv.FormatFloat(m.TaxiOut.Minutes(), 'f', 1, 64)
+		}
+		if m.Spacing > 0 {
+			spacing = strconv.FormatFloat(float64(m.Spacing), 'f', 1, 32)
+		}
+		w.Write([]string{m.Airport, m.Callsign, m.AircraftType, Select(m.Departure, "departure", "arrival"),
+			m.Runway, m.Time.UTC().Format(time.RFC3339), taxi, spacing})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+///////////////////////////////////////////////////////////////////////////
+// TrafficStatsPane
+
+type TrafficStatsPane struct {
+	Airports map[string]interface{}
+	// Number of hours shown in the hourly bar chart.
+	Hours int32
+
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	exportDialog *FileSelectDialogBox
+
+	cb CommandBuffer
+}
+
+// TrafficSummary holds aggregate statistics for a set of movements.
+type TrafficSummary struct {
+	Departures, Arrivals int
+	// Per-runway departure and arrival counts, indexed by "ICAO runway".
+	Runways map[string][2]int
+	// Per-hour departure and arrival counts, indexed by the start of the
+	// hour.
+	Hourly map[time.Time][2]int
+
+	AverageTaxiOut time.Duration
+	AverageSpacing float32
+
+	PeakHour  time.Time
+	PeakCount int
+}
+
+func SummarizeMovements(movements []RecordedMovement) TrafficSummary {
+	s := TrafficSummary{
+		Runways: make(map[string][2]int),
+		Hourly:  make(map[time.Time][2]int),
+	}
+
+	var taxiSum time.Duration
+	var spacingSum float32
+	nTaxi, nSpacing := 0, 0
+	for _, m := range movements {
+		idx := Select(m.Departure, 0, 1)
+		if m.Departure {
+			s.Departures++
+			if m.TaxiOut > 0 {
+				taxiSum += m.TaxiOut
+				nTaxi++
+			}
+		} else {
+			s.Arrivals++
+			if m.Spacing > 0 {
+				spacingSum += m.Spacing
+				nSpacing++
+			}
+		}
+
+		rwy := m.Airport + " " + Select(m.Runway == "", "?", m.Runway)
+		rc := s.Runways[rwy]
+		rc[idx]++
+		s.Runways[rwy] = rc
+
+		hour := m.Time.UTC().Truncate(time.Hour)
+		hc := s.Hourly[hour]
+		hc[idx]++
+		s.Hourly[hour] = hc
+		if n := hc[0] + hc[1]; n > s.PeakCount {
+			s.PeakHour, s.PeakCount = hour, n
+		}
+	}
+
+	if nTaxi > 0 {
+		s.AverageTaxiOut = taxiSum / time.Duration(nTaxi)
+	}
+	if nSpacing > 0 {
+		s.AverageSpacing = spacingSum / float32(nSpacing)
+	}
+
+	return s
+}
+
+func NewTrafficStatsPane() *TrafficStatsPane {
+	tp := &TrafficStatsPane{
+		Airports: make(map[string]interface{}),
+		Hours:    6,
+	}
+	// Start out with the airports that are being tracked elsewhere.
+	if positionConfig != nil && positionConfig.DisplayRoot != nil {
+		positionConfig.DisplayRoot.VisitPanes(func(p Pane) {
+			if ap, ok := p.(*AirportInfoPane); ok {
+				for icao := range ap.Airports {
+					tp.Airports[icao] = nil
+				}
+			}
+		})
+	}
+	return tp
+}
+
+func (tp *TrafficStatsPane) Duplicate(nameAsCopy bool) Pane {
+	return &TrafficStatsPane{
+		Airports:       DuplicateMap(tp.Airports),
+		Hours:          tp.Hours,
+		FontIdentifier: tp.FontIdentifier,
+		font:           tp.font,
+	}
+}
+
+func (tp *TrafficStatsPane) Activate() {
+	if tp.font = GetFont(tp.FontIdentifier); tp.font == nil {
+		tp.font = GetDefaultFont()
+		tp.FontIdentifier = tp.font.id
+	}
+	if tp.Airports == nil {
+		tp.Airports = make(map[string]interface{})
+	}
+	if tp.Hours <= 0 {
+		tp.Hours = 6
+	}
+}
+
+func (tp *TrafficStatsPane) Deactivate()                {}
+func (tp *TrafficStatsPane) CanTakeKeyboardFocus() bool { return false }
+
+func (tp *TrafficStatsPane) Name() string { return "Traffic Statistics" }
+
+func (tp *TrafficStatsPane) DrawUI() {
+	tp.Airports, _ = drawAirportSelector(tp.Airports, "Airports")
+	if newFont, changed := DrawFontPicker(&tp.FontIdentifier, "Font"); changed {
+		tp.font = newFont
+	}
+	imgui.SliderIntV("Hours shown", &tp.Hours, 1, 24, "%d", 0)
+
+	if imgui.Button("Export CSV...") {
+		if tp.exportDialog == nil {
+			tp.exportDialog = NewDirectorySelectDialogBox("Select directory for CSV export...", "",
+				func(dir string) {
+					fn := path.Join(dir, "avian-traffic-"+server.CurrentTime().UTC().Format("20060102-1504Z")+".csv")
+					if err := trafficStats.WriteCSV(fn, tp.Airports); err != nil {
+						ShowErrorDialog("Unable to write CSV file: %v", err)
+					}
+				})
+		}
+		tp.exportDialog.Activate()
+	}
+	if tp.exportDialog != nil {
+		tp.exportDialog.Draw()
+	}
+	imgui.SameLine()
+	if imgui.Button("Clear statistics") {
+		trafficStats.Clear(tp.Airports)
+	}
+}
+
+func (tp *TrafficStatsPane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	tp.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&tp.cb)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+	trid := GetColoredTrianglesDrawBuilder()
+	defer ReturnColoredTrianglesDrawBuilder(trid)
+	ld := GetColoredLinesDrawBuilder()
+	defer ReturnColoredLinesDrawBuilder(ld)
+
+	style := TextStyle{Font: tp.font, Color: ctx.cs.Text}
+	lineHeight := float32(tp.font.size)
+	sz2 := lineHeight / 2
+	width, height := ctx.paneExtent.Width(), ctx.paneExtent.Height()
+
+	// Text summary at the top, one line per airport and then a total.
+	y := height - sz2
+	summaryLine := func(label string, s TrafficSummary) {
+		line := fmt.Sprintf("%-5s dep %3d arr %3d", label, s.Departures, s.Arrivals)
+		if s.AverageTaxiOut > 0 {
+			line += fmt.Sprintf("  taxi-out %4.1fm", s.AverageTaxiOut.Minutes())
+		}
+		if s.AverageSpacing > 0 {
+			line += fmt.Sprintf("  final %4.1fnm", s.AverageSpacing)
+		}
+		if s.PeakCount > 0 {
+			line += fmt.Sprintf("  peak %s %d/hr", s.PeakHour.Format("1504Z"), s.PeakCount)
+		}
+		td.AddText(line, [2]float32{sz2, y}, style)
+		y -= lineHeight
+	}
+
+	if len(tp.Airports) == 0 {
+		td.AddText("No airports selected", [2]float32{sz2, y}, style)
+		td.GenerateCommands(&tp.cb)
+		cb.Call(tp.cb)
+		return
+	}
+
+	for _, ap := range SortedMapKeys(tp.Airports) {
+		summaryLine(ap, SummarizeMovements(trafficStats.Movements(map[string]interface{}{ap: nil})))
+	}
+	total := SummarizeMovements(trafficStats.Movements(tp.Airports))
+	if len(tp.Airports) > 1 {
+		summaryLine("Total", total)
+	}
+	y -= sz2
+
+	// Hourly chart, ending with the current hour.
+	now := server.CurrentTime().UTC().Truncate(time.Hour)
+	var hourLabels []string
+	var hourCounts [][2]int
+	for i := int(tp.Hours) - 1; i >= 0; i-- {
+		h := now.Add(-time.Duration(i) * time.Hour)
+		hourLabels = append(hourLabels, h.Format("15Z"))
+		hourCounts = append(hourCounts, total.Hourly[h])
+	}
+
+	// Runway chart.
+	rwyLabels := SortedMapKeys(total.Runways)
+	var rwyCounts [][2]int
+	for _, rwy := range rwyLabels {
+		rwyCounts = append(rwyCounts, total.Runways[rwy])
+	}
+
+	// Split the remaining space between the two charts.
+	chartHeight := (y - sz2) / 2
+	if chartHeight > 3*lineHeight {
+		hourExtent := Extent2D{p0: [2]float32{sz2, y - chartHeight}, p1: [2]float32{width - sz2, y}}
+		tp.drawBarChart(ctx, hourExtent, "Movements per hour", hourLabels, hourCounts, td, trid, ld)
+		rwyExtent := Extent2D{p0: [2]float32{sz2, sz2}, p1: [2]float32{width - sz2, y - chartHeight}}
+		tp.drawBarChart(ctx, rwyExtent, "Movements per runway", rwyLabels, rwyCounts, td, trid, ld)
+	}
+
+	trid.GenerateCommands(&tp.cb)
+	ld.GenerateCommands(&tp.cb)
+	td.GenerateCommands(&tp.cb)
+	cb.Call(tp.cb)
+}
+
+// drawBarChart draws paired departure/arrival bars for each of the given
+// labels inside the extent, with a title at the top and labels below.
+func (tp *TrafficStatsPane) drawBarChart(ctx *PaneContext, extent Extent2D, title string, labels []string,
+	counts [][2]int, td *TextDrawBuilder, trid *ColoredTrianglesDrawBuilder, ld *ColoredLinesDrawBuilder) {
+	style := TextStyle{Font: tp.font, Color: ctx.cs.Text}
+	lineHeight := float32(tp.font.size)
+
+	td.AddText(title, [2]float32{extent.p0[0], extent.p1[1]}, style)
+	if len(labels) == 0 {
+		return
+	}
+
+	// Leave room for the title and count above and labels below.
+	x0, x1 := extent.p0[0], extent.p1[0]
+	y0, y1 := extent.p0[1]+lineHeight, extent.p1[1]-2*lineHeight
+	if y1 <= y0 {
+		return
+	}
+	ld.AddLine([2]float32{x0, y0}, [2]float32{x1, y0}, ctx.cs.UIControl)
+
+	maxCount := 1
+	for _, c := range counts {
+		maxCount = max(maxCount, max(c[0], c[1]))
+	}
+
+	slot := (x1 - x0) / float32(len(labels))
+	barWidth := slot * 0.35
+	for i, label := range labels {
+		xc := x0 + (float32(i)+0.5)*slot
+		for j, color := range [2]RGB{ctx.cs.DepartureStrip, ctx.cs.ArrivalStrip} {
+			if counts[i][j] == 0 {
+				continue
+			}
+			bx0 := xc + Select(j == 0, -barWidth, float32(0))
+			bx1 := bx0 + barWidth
+			by1 := y0 + (y1-y0)*float32(counts[i][j])/float32(maxCount)
+			trid.AddQuad([2]float32{bx0, y0}, [2]float32{bx1, y0}, [2]float32{bx1, by1}, [2]float32{bx0, by1}, color)
+			td.AddTextCentered(strconv.Itoa(counts[i][j]), [2]float32{(bx0 + bx1) / 2, by1 + lineHeight/2}, style)
+		}
+		td.AddTextCentered(label, [2]float32{xc, y0 - lineHeight/2}, style)
+	}
+}
@@ -0,0 +1,359 @@
+// traffictable.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mmp/imgui-go/v4"
+)
+
+// trafficColumn describes a single column of the TrafficTablePane.
+type trafficColumn struct {
+	name string
+	// value returns the text to display for the aircraft.
+	value func(tt *TrafficTablePane, ac *Aircraft) string
+	// If non-nil, sortKey provides a numeric value for sorting;
+	// otherwise columns are sorted by their text.
+	sortKey func(tt *TrafficTablePane, ac *Aircraft) float32
+}
+
+func flightPlanValue(f func(fp *FlightPlan) string) func(*TrafficTablePane, *Aircraft) string {
+	return func(tt *TrafficTablePane, ac *Aircraft) string {
+		if ac.FlightPlan == nil {
+			return ""
+		}
+		return f(ac.FlightPlan)
+	}
+}
+
+var trafficColumns = []trafficColumn{
+	{
+		name:  "Callsign",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string { return ac.Callsign },
+	},
+	{
+		name:  "Type",
+		value: flightPlanValue(func(fp *FlightPlan) string { return fp.AircraftType }),
+	},
+	{
+		name:  "Rules",
+		value: flightPlanValue(func(fp *FlightPlan) string { return fp.Rules.String() }),
+	},
+	{
+		name:  "Dep",
+		value: flightPlanValue(func(fp *FlightPlan) string { return fp.DepartureAirport }),
+	},
+	{
+		name:  "Arr",
+		value: flightPlanValue(func(fp *FlightPlan) string { return fp.ArrivalAirport }),
+	},
+	{
+		name:    "Alt",
+		value:   func(tt *TrafficTablePane, ac *Aircraft) string { return fmt.Sprintf("%d", ac.Altitude()) },
+		sortKey: func(tt *TrafficTablePane, ac *Aircraft) float32 { return float32(ac.Altitude()) },
+	},
+	{
+		name:    "GS",
+		value:   func(tt *TrafficTablePane, ac *Aircraft) string { return fmt.Sprintf("%d", ac.Groundspeed()) },
+		sortKey: func(tt *TrafficTablePane, ac *Aircraft) float32 { return float32(ac.Groundspeed()) },
+	},
+	{
+		name: "Squawk",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string {
+			if ac.AssignedSquawk != 0 && ac.AssignedSquawk != ac.Squawk {
+				return ac.Squawk.String() + "/" + ac.AssignedSquawk.String()
+			}
+			return ac.Squawk.String()
+		},
+	},
+	{
+		name: "Freq",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string {
+			return strings.Join(MapSlice(ac.TunedFrequencies, func(f Frequency) string { return f.String() }), ",")
+		},
+	},
+	{
+		name:  "Tracking",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string { return ac.TrackingController },
+	},
+	{
+		name:  "Owner",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string { return ac.FrequencyCheck().Owner },
+	},
+	{
+		name:  "Freq check",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string { return ac.FrequencyCheck().Problem },
+	},
+	{
+		name: "Hours",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string {
+			// Only request hours for aircraft that are actually shown.
+			if h := ac.HoursOnNetwork(false); h != 0 {
+				return fmt.Sprintf("%.0f", h)
+			}
+			return ""
+		},
+		sortKey: func(tt *TrafficTablePane, ac *Aircraft) float32 { return ac.hoursOnNetwork },
+	},
+	{
+		name: "Dist",
+		value: func(tt *TrafficTablePane, ac *Aircraft) string {
+			if d, ok := tt.distance(ac); ok {
+				return fmt.Sprintf("%.1f", d)
+			}
+			return ""
+		},
+		sortKey: func(tt *TrafficTablePane, ac *Aircraft) float32 {
+			if d, ok := tt.distance(ac); ok {
+				return d
+			}
+			return 1e30
+		},
+	},
+}
+
+func getTrafficColumn(name string) *trafficColumn {
+	if idx := FindIf(trafficColumns, func(c trafficColumn) bool { return c.name == name }); idx != -1 {
+		return &trafficColumns[idx]
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////
+// TrafficTablePane
+
+// TrafficTablePane shows aircraft in a table with user-selected columns
+// that can be sorted by clicking on the column headers.
+type TrafficTablePane struct {
+	// Names of the visible columns.
+	Columns       []string
+	SortColumn    string
+	SortAscending bool
+	// Only aircraft that pass the filter are shown.
+	Filter AircraftFilter
+	// Fix, navaid, or airport that distances are measured from.
+	Reference string
+
+	FontIdentifier FontIdentifier
+	font           *Font
+
+	sb *ScrollBar
+	cb CommandBuffer
+}
+
+func NewTrafficTablePane() *TrafficTablePane {
+	return &TrafficTablePane{
+		Columns:       []string{"Callsign", "Type", "Rules", "Dep", "Arr", "Alt", "GS", "Squawk", "Tracking"},
+		SortColumn:    "Callsign",
+		SortAscending: true,
+	}
+}
+
+func (tt *TrafficTablePane) Duplicate(nameAsCopy bool) Pane {
+	return &TrafficTablePane{
+		Columns:        DuplicateSlice(tt.Columns),
+		SortColumn:     tt.SortColumn,
+		SortAscending:  tt.SortAscending,
+		Filter:         AircraftFilter{Expression: tt.Filter.Expression},
+		Reference:      tt.Reference,
+		FontIdentifier: tt.FontIdentifier,
+		font:           tt.font,
+		sb:             NewScrollBar(4, false),
+	}
+}
+
+func (tt *TrafficTablePane) Activate() {
+	if tt.font = GetFont(tt.FontIdentifier); tt.font == nil {
+		tt.font = GetDefaultFont()
+		tt.FontIdentifier = tt.font.id
+	}
+	// Drop any columns that no longer exist.
+	tt.Columns = FilterSlice(tt.Columns, func(c string) bool { return getTrafficColumn(c) != nil })
+	if len(tt.Columns) == 0 {
+		tt.Columns = []string{"Callsign"}
+	}
+	if tt.sb == nil {
+		tt.sb = NewScrollBar(4, false)
+	}
+}
+
+func (tt *TrafficTablePane) Deactivate()                {}
+func (tt *TrafficTablePane) CanTakeKeyboardFocus() bool { return false }
+
+func (tt *TrafficTablePane) Name() string { return "Traffic Table" }
+
+func (tt *TrafficTablePane) DrawUI() {
+	imgui.Text("Columns:")
+	for _, col := range trafficColumns {
+		show := Find(tt.Columns, col.name) != -1
+		if imgui.Checkbox(col.name, &show) {
+			// Keep the columns in the canonical order.
+			var cols []string
+			for _, c := range trafficColumns {
+				if c.name == col.name {
+					if show {
+						cols = append(cols, c.name)
+					}
+				} else if Find(tt.Columns, c.name) != -1 {
+					cols = append(cols, c.name)
+				}
+			}
+			tt.Columns = cols
+		}
+	}
+
+	tt.Filter.DrawUI("Filter")
+	imgui.InputTextV("Distance from", &tt.Reference, imgui.InputTextFlagsCharsUppercase, nil)
+	if tt.Reference != "" {
+		if _, ok := database.Locate(tt.Reference); !ok {
+			imgui.Text(tt.Reference + ": unknown location")
+		}
+	}
+	if newFont, changed := DrawFontPicker(&tt.FontIdentifier, "Font"); changed {
+		tt.font = newFont
+	}
+}
+
+func (tt *TrafficTablePane) distance(ac *Aircraft) (float32, bool) {
+	if tt.Reference == "" || !ac.HaveTrack() {
+		return 0, false
+	}
+	p, ok := database.Locate(tt.Reference)
+	if !ok {
+		return 0, false
+	}
+	return nmdistance2ll(p, ac.Position()), true
+}
+
+func (tt *TrafficTablePane) sortAircraft(aircraft []*Aircraft) {
+	col := getTrafficColumn(tt.SortColumn)
+	if col == nil {
+		col = &trafficColumns[0]
+	}
+
+	less := func(a, b *Aircraft) bool {
+		if col.sortKey != nil {
+			ka, kb := col.sortKey(tt, a), col.sortKey(tt, b)
+			if ka != kb {
+				return ka < kb
+			}
+		} else if va, vb := col.value(tt, a), col.value(tt, b); va != vb {
+			return va < vb
+		}
+		return a.Callsign < b.Callsign
+	}
+	sort.Slice(aircraft, func(i, j int) bool {
+		if tt.SortAscending {
+			return less(aircraft[i], aircraft[j])
+		}
+		return less(aircraft[j], aircraft[i])
+	})
+}
+
+func (tt *TrafficTablePane) Draw(ctx *PaneContext, cb *CommandBuffer) {
+	columns := MapSlice(tt.Columns, getTrafficColumn)
+
+	now := server.CurrentTime()
+	aircraft := server.GetFilteredAircraft(func(ac *Aircraft) bool {
+		return !ac.LostTrack(now) && tt.Filter.Match(ac)
+	})
+	tt.sortAircraft(aircraft)
+
+	lineHeight := float32(tt.font.size)
+	sz2 := lineHeight / 2
+	height := ctx.paneExtent.Height()
+	nVisible := int((height-sz2)/lineHeight) - 1
+	tt.sb.Update(len(aircraft), nVisible, ctx)
+	offset := min(tt.sb.Offset(), len(aircraft))
+	if n := len(aircraft) - offset; n < nVisible {
+		nVisible = max(n, 0)
+	}
+	visible := aircraft[offset : offset+nVisible]
+
+	// Get the text for the visible cells and then size the columns to fit.
+	cells := make([][]string, len(visible))
+	for i, ac := range visible {
+		for _, col := range columns {
+			cells[i] = append(cells[i], col.value(tt, ac))
+		}
+	}
+	spaceWidth, _ := tt.font.BoundText("  ", 0)
+	colX := make([]float32, len(columns)+1)
+	colX[0] = sz2
+	for j, col := range columns {
+		header := col.name + "  " // leave room for the sort arrow
+		w, _ := tt.font.BoundText(header, 0)
+		for i := range cells {
+			bx, _ := tt.font.BoundText(cells[i][j], 0)
+			w = max(w, bx)
+		}
+		colX[j+1] = colX[j] + float32(w+spaceWidth)
+	}
+
+	tt.cb.Reset()
+	ctx.SetWindowCoordinateMatrices(&tt.cb)
+
+	td := GetTextDrawBuilder()
+	defer ReturnTextDrawBuilder(td)
+
+	cs := ctx.cs
+	style := TextStyle{Font: tt.font, Color: cs.Text}
+	headerStyle := TextStyle{Font: tt.font, Color: cs.TextHighlight}
+	flaggedStyle := TextStyle{Font: tt.font, Color: cs.TextHighlight}
+
+	y := height - sz2
+	for j, col := range columns {
+		header := col.name
+		if col.name == tt.SortColumn {
+			header += " " + Select(tt.SortAscending, FontAwesomeIconArrowUp, FontAwesomeIconArrowDown)
+		}
+		td.AddText(header, [2]float32{colX[j], y}, headerStyle)
+	}
+
+	for i, ac := range visible {
+		y -= lineHeight
+		s := Select(positionConfig.IsFlagged(ac.Callsign), flaggedStyle, style)
+		if ac == positionConfig.selectedAircraft {
+			s.DrawBackground = true
+			s.BackgroundColor = cs.AltBackground
+		}
+		for j := range columns {
+			td.AddText(cells[i][j], [2]float32{colX[j], y}, s)
+		}
+	}
+
+	if ctx.mouse != nil {
+		p := ctx.mouse.Pos
+		row := int((height - sz2 - p[1]) / lineHeight)
+		if row == 0 && ctx.mouse.Clicked[MouseButtonPrimary] {
+			// Header click: sort by the column, reversing the order if
+			// it's already the sort column.
+			for j, col := range columns {
+				if p[0] >= colX[j] && p[0] < colX[j+1] {
+					if col.name == tt.SortColumn {
+						tt.SortAscending = !tt.SortAscending
+					} else {
+						tt.SortColumn, tt.SortAscending = col.name, true
+					}
+				}
+			}
+		} else if row > 0 && row <= len(visible) {
+			ac := visible[row-1]
+			if ctx.mouse.DoubleClicked[MouseButtonPrimary] {
+				eventStream.Post(&SelectedAircraftEvent{ac: ac})
+			}
+			if ctx.mouse.Clicked[MouseButtonSecondary] {
+				positionConfig.ToggleFlagged(ac.Callsign)
+			}
+		}
+	}
+
+	td.GenerateCommands(&tt.cb)
+	tt.sb.Draw(ctx, &tt.cb)
+	cb.Call(tt.cb)
+}
@@ -30,19 +30,27 @@ var (
 		errorText     map[string]func() bool
 		menuBarHeight float32
 
-		showColorEditor bool
-		showFilesEditor bool
-		showSoundConfig bool
+		showColorEditor  bool
+		showFilesEditor  bool
+		showSoundConfig  bool
+		showFilterEditor bool
+		newFilterName    string
+		newTemplateName  string
 
 		iconTextureID     uint32
 		sadTowerTextureID uint32
 
 		activeModalDialogs []*ModalDialogBox
 
-		openSectorFileDialog   *FileSelectDialogBox
-		openPositionFileDialog *FileSelectDialogBox
-		openAliasesFileDialog  *FileSelectDialogBox
-		openNotesFileDialog    *FileSelectDialogBox
+		openSectorFileDialog    *FileSelectDialogBox
+		openPositionFileDialog  *FileSelectDialogBox
+		openAliasesFileDialog   *FileSelectDialogBox
+		openNotesFileDialog     *FileSelectDialogBox
+		openNavdataFileDialog   *FileSelectDialogBox
+		openSUAFileDialog       *FileSelectDialogBox
+		openMVAFileDialog       *FileSelectDialogBox
+		openNASRZipDialog       *FileSelectDialogBox
+		openNASRDirectoryDialog *FileSelectDialogBox
 	}
 
 	//go:embed icons/tower-256x256.png
@@ -115,7 +123,7 @@ func uiInit(renderer Renderer) {
 				})
 			}
 		})
-	ui.openPositionFileDialog = NewFileSelectDialogBox("Open Position File...", []string{".pof"},
+	ui.openPositionFileDialog = NewFileSelectDialogBox("Open Position File...", []string{".pof", ".ese"},
 		pos.PositionFile,
 		func(filename string) {
 			if err := database.LoadPositionFile(filename); err == nil {
@@ -136,6 +144,34 @@ func uiInit(renderer Renderer) {
 			globalConfig.NotesFile = filename
 			globalConfig.LoadNotesFile()
 		})
+	// ARINC 424 files don't have a standard extension, so allow anything.
+	ui.openNavdataFileDialog = NewFileSelectDialogBox("Open Navdata File...", nil, "",
+		func(filename string) {
+			globalConfig.NavdataFiles = append(globalConfig.NavdataFiles, filename)
+			database.LoadNavdataFiles([]string{filename})
+		})
+	// OpenAir files are often .txt but may have other extensions.
+	ui.openSUAFileDialog = NewFileSelectDialogBox("Open SUA File...", nil, "",
+		func(filename string) {
+			globalConfig.SUAFiles = append(globalConfig.SUAFiles, filename)
+			database.LoadSUAFiles(globalConfig.SUAFiles)
+		})
+	ui.openMVAFileDialog = NewFileSelectDialogBox("Open MVA File...", []string{".xml", ".geojson", ".json"}, "",
+		func(filename string) {
+			globalConfig.MVAFiles = append(globalConfig.MVAFiles, filename)
+			database.LoadMVAFiles(globalConfig.MVAFiles)
+		})
+	loadNASR := func(filename string) {
+		if err := database.LoadNASR(filename); err != nil {
+			ShowErrorDialog("%s: unable to load NASR data: %v", filename, err)
+		} else {
+			globalConfig.NASRPath = filename
+		}
+	}
+	ui.openNASRZipDialog = NewFileSelectDialogBox("Open NASR Subscription...", []string{".zip"},
+		globalConfig.NASRPath, loadNASR)
+	ui.openNASRDirectoryDialog = NewDirectorySelectDialogBox("Select NASR Directory...",
+		globalConfig.NASRPath, loadNASR)
 }
 
 // uiAddError lets the caller specify an error message to be displayed
@@ -201,6 +237,9 @@ func drawUI(cs *ColorScheme, platform Platform) {
 			if imgui.MenuItem("Sounds...") {
 				ui.showSoundConfig = true
 			}
+			if imgui.MenuItem("Aircraft filters...") {
+				ui.showFilterEditor = true
+			}
 			imgui.EndMenu()
 		}
 
@@ -276,6 +315,11 @@ func drawActiveDialogBoxes() {
 	ui.openPositionFileDialog.Draw()
 	ui.openAliasesFileDialog.Draw()
 	ui.openNotesFileDialog.Draw()
+	ui.openNavdataFileDialog.Draw()
+	ui.openSUAFileDialog.Draw()
+	ui.openMVAFileDialog.Draw()
+	ui.openNASRZipDialog.Draw()
+	ui.openNASRDirectoryDialog.Draw()
 }
 
 func drawActiveSettingsWindows() {
@@ -285,6 +329,12 @@ func drawActiveSettingsWindows() {
 		imgui.End()
 	}
 
+	if ui.showFilterEditor {
+		imgui.BeginV("Aircraft Filters", &ui.showFilterEditor, imgui.WindowFlagsAlwaysAutoResize)
+		globalConfig.DrawAircraftFiltersUI()
+		imgui.End()
+	}
+
 	if ui.showColorEditor {
 		imgui.BeginV("Appearance", &ui.showColorEditor, imgui.WindowFlagsAlwaysAutoResize)
 
@@ -1207,9 +1257,15 @@ func uiDrawNewPaneSelector(label, preview string) (name string, pane Pane) {
 		if imgui.Selectable("Airport information") {
 			name, pane = "Airport information", NewAirportInfoPane()
 		}
+		if imgui.Selectable("Arrival ETA ladder") {
+			name, pane = "Arrival ETA ladder", NewETALadderPane()
+		}
 		if imgui.Selectable("Command-line interface") {
 			name, pane = "Command-line interface", NewCLIPane()
 		}
+		if imgui.Selectable("Controller roster") {
+			name, pane = "Controller roster", NewControllerRosterPane()
+		}
 		if imgui.Selectable("Empty") {
 			name, pane = "Empty", NewEmptyPane()
 		}
@@ -1219,15 +1275,30 @@ func uiDrawNewPaneSelector(label, preview string) (name string, pane Pane) {
 		if imgui.Selectable("Flight plan") {
 			name, pane = "Flight plan", NewFlightPlanPane()
 		}
+		if imgui.Selectable("Flight strips") {
+			name, pane = "Flight strips", NewFlightStripPane()
+		}
 		if imgui.Selectable("Image viewer") {
 			name, pane = "Image viewer", NewImageViewPane()
 		}
+		if imgui.Selectable("Messages") {
+			name, pane = "Messages", NewMessagesPane()
+		}
+		if imgui.Selectable("Notes") {
+			name, pane = "Notes", NewNotesPane()
+		}
 		if imgui.Selectable("Radar scope") {
 			name, pane = "Radar scope", NewRadarScopePane("(Unnamed)")
 		}
 		if imgui.Selectable("Tabbed Window") {
 			name, pane = "Tabbed window", NewTabbedPane()
 		}
+		if imgui.Selectable("Traffic statistics") {
+			name, pane = "Traffic statistics", NewTrafficStatsPane()
+		}
+		if imgui.Selectable("Traffic table") {
+			name, pane = "Traffic table", NewTrafficTablePane()
+		}
 		imgui.EndCombo()
 	}
 	return
@@ -284,26 +284,20 @@ func (co CardinalOrdinalDirection) ShortString() string {
 // headingp2ll returns the heading from the point |from| to the point |to|
 // in degrees.  The provided points should be in latitude-longitude
 // coordinates and the provided magnetic correction is applied to the
-// result.
+// result.  A flat-earth approximation is used for nearby points and the
+// initial great circle bearing is used otherwise.
 func headingp2ll(from Point2LL, to Point2LL, magCorrection float32) float32 {
-	v := Point2LL{to[0] - from[0], to[1] - from[1]}
-	return headingv2ll(v, magCorrection)
-}
+	if !flatEarthValid(from, to) {
+		return normalizeBearing(float64(greatCircleBearing(from, to) + magCorrection))
+	}
 
-// headingv2ll returns the heading in degrees corresponding to the provided
-// vector expressed in latitude-longitude coordinates with the provided
-// magnetic correction applied.
-func headingv2ll(v Point2LL, magCorrection float32) float32 {
 	// Note that atan2() normally measures w.r.t. the +x axis and angles
 	// are positive for counter-clockwise. We want to measure w.r.t. +y and
 	// to have positive angles be clockwise. Happily, swapping the order of
 	// values passed to atan2()--passing (x,y), gives what we want.
-	angle := degrees(atan2(v[0]*database.NmPerLongitude, v[1]*database.NmPerLatitude))
-	angle += magCorrection
-	for angle < 0 {
-		angle += 360
-	}
-	return mod(angle, 360)
+	nmPerLongitude := nmPerLongitudeAt((from[1] + to[1]) / 2)
+	angle := degrees(atan2((to[0]-from[0])*nmPerLongitude, (to[1]-from[1])*nmPerLatitude))
+	return normalizeBearing(float64(angle + magCorrection))
 }
 
 // headingDifference returns the minimum difference between two
@@ -486,6 +480,47 @@ func LineLineIntersect(p1f, p2f, p3f, p4f [2]float32) ([2]float32, bool) {
 	return [2]float32{float32(numx / denom), float32(numy / denom)}, true
 }
 
+// SegmentSegmentIntersect returns the intersection point of the line
+// segments (p0, p1) and (q0, q1), if there is one, along with the
+// parametric distance along the first segment where it is found.
+func SegmentSegmentIntersect(p0f, p1f, q0f, q1f [2]float32) ([2]float32, float32, bool) {
+	// As in LineLineIntersect, use float64 for the computation.
+	p0 := [2]float64{float64(p0f[0]), float64(p0f[1])}
+	q0 := [2]float64{float64(q0f[0]), float64(q0f[1])}
+	dp := [2]float64{float64(p1f[0]) - p0[0], float64(p1f[1]) - p0[1]}
+	dq := [2]float64{float64(q1f[0]) - q0[0], float64(q1f[1]) - q0[1]}
+
+	denom := dp[0]*dq[1] - dp[1]*dq[0]
+	if denom == 0 {
+		// Parallel or degenerate
+		return [2]float32{}, 0, false
+	}
+	d := [2]float64{q0[0] - p0[0], q0[1] - p0[1]}
+	t := (d[0]*dq[1] - d[1]*dq[0]) / denom
+	u := (d[0]*dp[1] - d[1]*dp[0]) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return [2]float32{}, 0, false
+	}
+	return [2]float32{float32(p0[0] + t*dp[0]), float32(p0[1] + t*dp[1])}, float32(t), true
+}
+
+// PointInPolygon returns true if the point is inside the polygon, which
+// is given by its vertices; it may be either open or closed (with the
+// first vertex repeated at the end.) Latitude and longitude are treated
+// as planar coordinates.
+func PointInPolygon(p Point2LL, poly []Point2LL) bool {
+	inside := false
+	for i := range poly {
+		v0, v1 := poly[i], poly[(i+1)%len(poly)]
+		// Count crossings of a ray in the +x direction from p.
+		if (v0[1] > p[1]) != (v1[1] > p[1]) &&
+			p[0] < v0[0]+(p[1]-v0[1])*(v1[0]-v0[0])/(v1[1]-v0[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
 // RayRayMinimumDistance takes two rays p0+d0*t and p1+d1*t and returns the
 // value of t where their distance is minimized.
 func RayRayMinimumDistance(p0, d0, p1, d1 [2]float32) float32 {
@@ -622,6 +657,55 @@ func (p Point2LL) DMSString() string {
 	return s
 }
 
+// ParseLatLong parses a latitude-longitude pair given either in decimal
+// degrees, e.g. "39.860901, -75.274864", or in the format returned by
+// DMSString, e.g. "N039.51.39.243, W075.16.29.511". The two values may be
+// separated by a comma and/or spaces.
+func ParseLatLong(s string) (Point2LL, error) {
+	f := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(f) != 2 {
+		return Point2LL{}, fmt.Errorf("%s: expected latitude and longitude", s)
+	}
+
+	parse := func(v string, pos, neg byte) (float32, error) {
+		v = strings.ToUpper(v)
+		if len(v) > 0 && (v[0] == pos || v[0] == neg) {
+			// Degrees, minutes, seconds
+			c := strings.SplitN(v[1:], ".", 3)
+			if len(c) != 3 {
+				return 0, fmt.Errorf("%s: invalid DMS value", v)
+			}
+			var dms [3]float64
+			for i := range c {
+				var err error
+				if dms[i], err = strconv.ParseFloat(c[i], 64); err != nil {
+					return 0, fmt.Errorf("%s: invalid DMS value", v)
+				}
+			}
+			d := float32(dms[0] + dms[1]/60 + dms[2]/3600)
+			return Select(v[0] == neg, -d, d), nil
+		}
+		d, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid value", v)
+		}
+		return float32(d), nil
+	}
+
+	lat, err := parse(f[0], 'N', 'S')
+	if err != nil {
+		return Point2LL{}, err
+	}
+	long, err := parse(f[1], 'E', 'W')
+	if err != nil {
+		return Point2LL{}, err
+	}
+	if lat < -90 || lat > 90 || long < -180 || long > 180 {
+		return Point2LL{}, fmt.Errorf("%s: out of range", s)
+	}
+	return Point2LL{long, lat}, nil
+}
+
 func (p Point2LL) IsZero() bool {
 	return p[0] == 0 && p[1] == 0
 }
@@ -668,24 +752,28 @@ func nmdistance2ll(a Point2LL, b Point2LL) float32 {
 }
 
 // nmlength2ll returns the length of a vector expressed in lat-long
-// coordinates.
-func nmlength2ll(a Point2LL) float32 {
-	x := a[0] * database.NmPerLongitude
-	y := a[1] * database.NmPerLatitude
+// coordinates, given the number of nautical miles per degree of
+// longitude where it is (see nmPerLongitudeAt).
+func nmlength2ll(a Point2LL, nmPerLongitude float32) float32 {
+	x := a[0] * nmPerLongitude
+	y := a[1] * nmPerLatitude
 	return sqrt(sqr(x) + sqr(y))
 }
 
 // nm2ll converts a point expressed in nautical mile coordinates to
-// lat-long.
-func nm2ll(p [2]float32) Point2LL {
-	return Point2LL{p[0] / database.NmPerLongitude, p[1] / database.NmPerLatitude}
+// lat-long; nmPerLongitude should be the same as was used with ll2nm.
+func nm2ll(p [2]float32, nmPerLongitude float32) Point2LL {
+	return Point2LL{p[0] / nmPerLongitude, p[1] / nmPerLatitude}
 }
 
 // ll2nm converts a point expressed in latitude-longitude coordinates to
 // nautical mile coordinates; this is useful for example for reasoning
-// about distances, since both axes then have the same measure.
-func ll2nm(p Point2LL) [2]float32 {
-	return [2]float32{p[0] * database.NmPerLongitude, p[1] * database.NmPerLatitude}
+// about distances, since both axes then have the same measure. All of
+// the points being compared should be converted using the same number of
+// nautical miles per degree of longitude, computed at a latitude near
+// them with nmPerLongitudeAt.
+func ll2nm(p Point2LL, nmPerLongitude float32) [2]float32 {
+	return [2]float32{p[0] * nmPerLongitude, p[1] * nmPerLatitude}
 }
 
 func normalize2ll(a Point2LL) Point2LL {
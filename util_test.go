@@ -292,3 +292,26 @@ func TestReduceMap(t *testing.T) {
 		t.Errorf("Expected %d from ReduceMap; got %d", 5+5+6+6+1, length)
 	}
 }
+
+func TestParseLatLong(t *testing.T) {
+	for _, test := range []struct {
+		s  string
+		p  Point2LL
+		ok bool
+	}{
+		{"39.860901, -75.274864", Point2LL{-75.274864, 39.860901}, true},
+		{"39.860901 -75.274864", Point2LL{-75.274864, 39.860901}, true},
+		{"N039.51.39.243,W075.16.29.511", Point2LL{-75.274864, 39.860901}, true},
+		{"S033.56.46.000 E151.10.38.000", Point2LL{151.177222, -33.946111}, true},
+		{"39.86", Point2LL{}, false},
+		{"N039.51,W075.16.29.511", Point2LL{}, false},
+		{"95, 10", Point2LL{}, false},
+	} {
+		p, err := ParseLatLong(test.s)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: got error %v, expected ok %v", test.s, err, test.ok)
+		} else if test.ok && distance2f(p, test.p) > 1e-4 {
+			t.Errorf("%s: got %v, expected %v", test.s, p, test.p)
+		}
+	}
+}
@@ -140,6 +140,10 @@ func (vp *VATSIMPublicServer) GetAirportATIS(airport string) []ATIS {
 	return nil
 }
 
+func (vp *VATSIMPublicServer) GetControllerATIS(controller string) string {
+	return vp.controllerATIS[controller]
+}
+
 func (vp *VATSIMPublicServer) RequestControllerATIS(controller string) error {
 	if atis, ok := vp.controllerATIS[controller]; ok {
 		tm := TextMessage{
@@ -281,16 +285,13 @@ func (vp *VATSIMPublicServer) GetUpdates() {
 		// Interpolate track positions
 		now := time.Now()
 		for _, ac := range vp.aircraft {
-			heading := ac.Heading()
-			v := [2]float32{sin(radians(heading)), cos(radians(heading))}
-			nm := nmlength2ll(v)
-			// scale so that it's how far the aircraft goes in 5 seconds
-			v = scale2ll(v, float32(5*ac.Groundspeed())/(3600*nm))
-
-			//lg.Printf("%s: pos %+v, heading %f, v %+v -> pos %+v", ac.Callsign, ac.Position(), heading, v, add2f(ac.Position(), v))
+			// Advance it along its (true) heading by how far the aircraft
+			// goes in 5 seconds.
+			nm := float32(5*ac.Groundspeed()) / 3600
+			p := greatCircleDestination(ac.Position(), ac.Tracks[0].Heading, nm)
 
 			ac.AddTrack(RadarTrack{
-				Position:    add2f(ac.Position(), v),
+				Position:    p,
 				Altitude:    ac.Altitude(),        // TODO: interpolate
 				Groundspeed: ac.Groundspeed(),     // TODO: interpolate
 				Heading:     ac.Tracks[0].Heading, // so no magnetic correction
@@ -0,0 +1,223 @@
+// wmm.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+// This file implements the World Magnetic Model (WMM), which gives the
+// magnetic variation as a function of position and date.
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const wmmDegree = 12
+
+var (
+	// The model coefficients, in the format of NOAA's WMM.COF; updated
+	// coefficients are released every five years and can be dropped in
+	// here directly.
+	// https://www.ncei.noaa.gov/products/world-magnetic-model
+	//go:embed resources/WMM.COF
+	wmmCOF string
+
+	wmmOnce  sync.Once
+	wmmModel *WMM
+
+	// Magnetic variation is cached in 0.1 degree cells.
+	magVarMutex sync.Mutex
+	magVarCache map[[2]int32]float32
+	magVarDay   int
+)
+
+// WMM stores the coefficients of the World Magnetic Model's spherical
+// harmonic expansion of the Earth's main magnetic field.
+type WMM struct {
+	Name  string
+	Epoch float64 // decimal year
+
+	// Gauss coefficients (nT) and their secular variation (nT/year),
+	// indexed by [n][m].
+	g, h, gdot, hdot [wmmDegree + 1][wmmDegree + 1]float64
+}
+
+// ParseWMM parses model coefficients in the format of NOAA's WMM.COF
+// file.
+func ParseWMM(s string) (*WMM, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	header := strings.Fields(lines[0])
+	if len(header) < 2 {
+		return nil, fmt.Errorf("%s: malformed WMM header", lines[0])
+	}
+	w := &WMM{Name: header[1]}
+	var err error
+	if w.Epoch, err = strconv.ParseFloat(header[0], 64); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "9999") {
+			break
+		}
+		f := strings.Fields(line)
+		if len(f) != 6 {
+			return nil, fmt.Errorf("%s: expected 6 fields", line)
+		}
+		var v [6]float64
+		for i := range f {
+			if v[i], err = strconv.ParseFloat(f[i], 64); err != nil {
+				return nil, err
+			}
+		}
+		n, m := int(v[0]), int(v[1])
+		if n < 1 || n > wmmDegree || m < 0 || m > n {
+			return nil, fmt.Errorf("%s: invalid degree/order", line)
+		}
+		w.g[n][m], w.h[n][m], w.gdot[n][m], w.hdot[n][m] = v[2], v[3], v[4], v[5]
+	}
+	return w, nil
+}
+
+// decimalYear returns t as a fractional year, e.g. 2020.5.
+func decimalYear(t time.Time) float64 {
+	t = t.UTC()
+	start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	return float64(t.Year()) + t.Sub(start).Hours()/end.Sub(start).Hours()
+}
+
+// Field returns the north, east, and down components of the main
+// magnetic field in nT at the point p, the given altitude above the WGS84
+// ellipsoid in km, and the given time.
+func (w *WMM) Field(p Point2LL, altitude float64, t time.Time) (x, y, z float64) {
+	const (
+		a  = 6378.137 // WGS84 semi-major axis, km
+		f  = 1 / 298.257223563
+		e2 = f * (2 - f)
+		re = 6371.2 // geomagnetic reference radius, km
+	)
+
+	// Geodetic to geocentric spherical coordinates.
+	lat, lon := float64(p[1])*math.Pi/180, float64(p[0])*math.Pi/180
+	sinLat, cosLat := math.Sin(lat), math.Cos(lat)
+	rc := a / math.Sqrt(1-e2*sinLat*sinLat)
+	px := (rc + altitude) * cosLat
+	pz := (rc*(1-e2) + altitude) * sinLat
+	r := math.Sqrt(px*px + pz*pz)
+	latc := math.Asin(pz / r)
+
+	// Legendre functions of cos(colatitude) and their derivatives with
+	// respect to colatitude; these are Gauss-normalized and the Schmidt
+	// normalization is applied to the coefficients below.
+	ct, st := math.Sin(latc), math.Cos(latc)
+	st = math.Max(st, 1e-10) // avoid dividing by zero at the poles
+	var P, dP [wmmDegree + 1][wmmDegree + 1]float64
+	P[0][0] = 1
+	for n := 1; n <= wmmDegree; n++ {
+		for m := 0; m <= n; m++ {
+			if m == n {
+				P[n][m] = st * P[n-1][m-1]
+				dP[n][m] = st*dP[n-1][m-1] + ct*P[n-1][m-1]
+			} else if n == 1 {
+				P[n][m] = ct * P[n-1][m]
+				dP[n][m] = ct*dP[n-1][m] - st*P[n-1][m]
+			} else {
+				k := 0.
+				if m <= n-2 {
+					k = float64((n-1)*(n-1)-m*m) / float64((2*n-1)*(2*n-3))
+				}
+				P[n][m] = ct*P[n-1][m] - k*P[n-2][m]
+				dP[n][m] = ct*dP[n-1][m] - st*P[n-1][m] - k*dP[n-2][m]
+			}
+		}
+	}
+
+	dt := decimalYear(t) - w.Epoch
+	var xc, yc, zc float64
+	schmidt := 1.
+	for n := 1; n <= wmmDegree; n++ {
+		ar := math.Pow(re/r, float64(n+2))
+		schmidt *= float64(2*n-1) / float64(n)
+		s := schmidt
+		for m := 0; m <= n; m++ {
+			if m > 0 {
+				s *= math.Sqrt(float64((n-m+1)*Select(m == 1, 2, 1)) / float64(n+m))
+			}
+			g := s * (w.g[n][m] + dt*w.gdot[n][m])
+			h := s * (w.h[n][m] + dt*w.hdot[n][m])
+			sinm, cosm := math.Sincos(float64(m) * lon)
+
+			xc += ar * (g*cosm + h*sinm) * dP[n][m]
+			yc += ar * float64(m) * (g*sinm - h*cosm) * P[n][m] / st
+			zc -= ar * float64(n+1) * (g*cosm + h*sinm) * P[n][m]
+		}
+	}
+
+	// Rotate from geocentric to geodetic.
+	sinD, cosD := math.Sincos(latc - lat)
+	return xc*cosD - zc*sinD, yc, xc*sinD + zc*cosD
+}
+
+// Valid returns true if t is within the five years that the model is
+// valid for.
+func (w *WMM) Valid(t time.Time) bool {
+	y := decimalYear(t)
+	return y >= w.Epoch && y < w.Epoch+5
+}
+
+// Declination returns the magnetic declination in degrees at the point p
+// and the given altitude (km) and time; positive values are east.
+func (w *WMM) Declination(p Point2LL, altitude float64, t time.Time) float32 {
+	x, y, _ := w.Field(p, altitude, t)
+	return float32(math.Atan2(y, x) * 180 / math.Pi)
+}
+
+// MagneticCorrection returns the value to add to a true heading at the
+// point p to get the magnetic heading there.  The variation is computed
+// using the World Magnetic Model; the sector file's magnetic variation is
+// only used if the model is unavailable.
+func (db *StaticDatabase) MagneticCorrection(p Point2LL) float32 {
+	wmmOnce.Do(func() {
+		var err error
+		if wmmModel, err = ParseWMM(wmmCOF); err != nil {
+			lg.Errorf("WMM: %v", err)
+			wmmModel = nil
+		}
+	})
+	if wmmModel == nil {
+		return db.MagneticVariation
+	}
+
+	magVarMutex.Lock()
+	defer magVarMutex.Unlock()
+
+	// Use the server's time so that replays of old traces use the
+	// variation at the time they were recorded.
+	now := time.Now()
+	if server != nil {
+		now = server.CurrentTime()
+	}
+	if day := now.YearDay() + 1000*now.Year(); day != magVarDay || magVarCache == nil {
+		magVarCache = make(map[[2]int32]float32)
+		magVarDay = day
+		if !wmmModel.Valid(now) {
+			lg.Errorf("%s: %s is outside of the model's validity period; magnetic variation may be inaccurate",
+				wmmModel.Name, now.UTC().Format("2006-01-02"))
+		}
+	}
+
+	cell := [2]int32{int32(math.Floor(float64(p[0]) * 10)), int32(math.Floor(float64(p[1]) * 10))}
+	if v, ok := magVarCache[cell]; ok {
+		return v
+	}
+	center := Point2LL{(float32(cell[0]) + 0.5) / 10, (float32(cell[1]) + 0.5) / 10}
+	v := -wmmModel.Declination(center, 0, now)
+	magVarCache[cell] = v
+	return v
+}
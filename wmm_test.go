@@ -0,0 +1,169 @@
+// wmm_test.go
+// Copyright(c) 2022 Matt Pharr, licensed under the GNU Public License, Version 3.
+// SPDX: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// The WMM2020 coefficients, which are used to check the field
+// computation against the test values published with that model.
+const testWMM2020 = `    2020.0            WMM-2020        12/10/2019
+  1  0  -29404.5       0.0        6.7        0.0
+  1  1   -1450.7    4652.9        7.7      -25.1
+  2  0   -2500.0       0.0      -11.5        0.0
+  2  1    2982.0   -2991.6       -7.1      -30.2
+  2  2    1676.8    -734.8       -2.2      -23.9
+  3  0    1363.9       0.0        2.8        0.0
+  3  1   -2381.0     -82.2       -6.2        5.7
+  3  2    1236.2     241.8        3.4       -1.0
+  3  3     525.7    -542.9      -12.2        1.1
+  4  0     903.1       0.0       -1.1        0.0
+  4  1     809.4     282.0       -1.6        0.2
+  4  2      86.2    -158.4       -6.0        6.9
+  4  3    -309.4     199.8        5.4        3.7
+  4  4      47.9    -350.1       -5.5       -5.6
+  5  0    -234.4       0.0       -0.3        0.0
+  5  1     363.1      47.7        0.6        0.1
+  5  2     187.8     208.4       -0.7        2.5
+  5  3    -140.7    -121.3        0.1       -0.9
+  5  4    -151.2      32.2        1.2        3.0
+  5  5      13.7      99.1        1.0        0.5
+  6  0      65.9       0.0       -0.6        0.0
+  6  1      65.6     -19.1       -0.4        0.1
+  6  2      73.0      25.0        0.5       -1.8
+  6  3    -121.5      52.7        1.4       -1.4
+  6  4     -36.2     -64.4       -1.4        0.9
+  6  5      13.5       9.0       -0.0        0.1
+  6  6     -64.7      68.1        0.8        1.0
+  7  0      80.6       0.0       -0.1        0.0
+  7  1     -76.8     -51.4       -0.3        0.5
+  7  2      -8.3     -16.8       -0.1        0.6
+  7  3      56.5       2.3        0.7       -0.7
+  7  4      15.8      23.5        0.2       -0.2
+  7  5       6.4      -2.2       -0.5       -1.2
+  7  6      -7.2     -27.2       -0.8        0.2
+  7  7       9.8      -1.9        1.0        0.3
+  8  0      23.6       0.0       -0.1        0.0
+  8  1       9.8       8.4        0.1       -0.3
+  8  2     -17.5     -15.3       -0.1        0.7
+  8  3      -0.4      12.8        0.5       -0.2
+  8  4     -21.1     -11.8       -0.1        0.5
+  8  5      15.3      14.9        0.4       -0.3
+  8  6      13.7       3.6        0.5       -0.5
+  8  7     -16.5      -6.9        0.0        0.4
+  8  8      -0.3       2.8        0.4        0.1
+  9  0       5.0       0.0       -0.1        0.0
+  9  1       8.2     -23.3       -0.2       -0.3
+  9  2       2.9      11.1       -0.0        0.2
+  9  3      -1.4       9.8        0.4       -0.4
+  9  4      -1.1      -5.1       -0.3        0.4
+  9  5     -13.3      -6.2       -0.0        0.1
+  9  6       1.1       7.8        0.3       -0.0
+  9  7       8.9       0.4       -0.0       -0.2
+  9  8      -9.3      -1.5       -0.0        0.5
+  9  9     -11.9       9.7       -0.4        0.2
+ 10  0      -1.9       0.0        0.0        0.0
+ 10  1      -6.2       3.4       -0.0       -0.0
+ 10  2      -0.1      -0.2       -0.0        0.1
+ 10  3       1.7       3.5        0.2       -0.3
+ 10  4      -0.9       4.8       -0.1        0.1
+ 10  5       0.6      -8.6       -0.2       -0.2
+ 10  6      -0.9      -0.1       -0.0        0.1
+ 10  7       1.9      -4.2       -0.1       -0.0
+ 10  8       1.4      -3.4       -0.2       -0.1
+ 10  9      -2.4      -0.1       -0.1        0.2
+ 10 10      -3.9      -8.8       -0.0       -0.0
+ 11  0       3.0       0.0       -0.0        0.0
+ 11  1      -1.4      -0.0       -0.1       -0.0
+ 11  2      -2.5       2.6       -0.0        0.1
+ 11  3       2.4      -0.5        0.0        0.0
+ 11  4      -0.9      -0.4       -0.0        0.2
+ 11  5       0.3       0.6       -0.1       -0.0
+ 11  6      -0.7      -0.2        0.0        0.0
+ 11  7      -0.1      -1.7       -0.0        0.1
+ 11  8       1.4      -1.6       -0.1       -0.0
+ 11  9      -0.6      -3.0       -0.1       -0.1
+ 11 10       0.2      -2.0       -0.1        0.0
+ 11 11       3.1      -2.6       -0.1       -0.0
+ 12  0      -2.0       0.0        0.0        0.0
+ 12  1      -0.1      -1.2       -0.0       -0.0
+ 12  2       0.5       0.5       -0.0        0.0
+ 12  3       1.3       1.3        0.0       -0.1
+ 12  4      -1.2      -1.8       -0.0        0.1
+ 12  5       0.7       0.1       -0.0       -0.0
+ 12  6       0.3       0.7        0.0        0.0
+ 12  7       0.5      -0.1       -0.0       -0.0
+ 12  8      -0.2       0.6        0.0        0.1
+ 12  9      -0.5       0.2       -0.0       -0.0
+ 12 10       0.1      -0.9       -0.0       -0.0
+ 12 11      -1.1      -0.0       -0.0        0.0
+ 12 12      -0.3       0.5       -0.1       -0.1
+999999999999999999999999999999999999999999999999
+999999999999999999999999999999999999999999999999
+`
+
+func TestWMM(t *testing.T) {
+	w, err := ParseWMM(testWMM2020)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test values from the WMM2020 report.
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mid := time.Date(2022, 7, 2, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		t        time.Time
+		altitude float64
+		p        Point2LL
+		x, y, z  float64
+		decl     float32
+	}{
+		{epoch, 0, Point2LL{0, 80}, 6570.4, -146.3, 54606.0, -1.28},
+		{epoch, 0, Point2LL{120, 0}, 39624.3, 109.9, -10932.5, 0.16},
+		{epoch, 0, Point2LL{240, -80}, 5940.6, 15772.1, -52480.8, 69.36},
+		{mid, 100, Point2LL{0, 80}, 6224.0, -44.5, 52527.0, -0.41},
+		{mid, 100, Point2LL{120, 0}, 37694.0, -35.3, -10362.0, -0.05},
+		{mid, 100, Point2LL{240, -80}, 5815.0, 14803.0, -49755.3, 68.55},
+	} {
+		x, y, z := w.Field(test.p, test.altitude, test.t)
+		if abs(x-test.x) > 1 || abs(y-test.y) > 1 || abs(z-test.z) > 1 {
+			t.Errorf("%v: got field (%.1f, %.1f, %.1f), expected (%.1f, %.1f, %.1f)", test.p, x, y, z,
+				test.x, test.y, test.z)
+		}
+		if d := w.Declination(test.p, test.altitude, test.t); abs(d-test.decl) > 0.01 {
+			t.Errorf("%v: got declination %.2f, expected %.2f", test.p, d, test.decl)
+		}
+	}
+}
+
+func TestEmbeddedWMM(t *testing.T) {
+	w, err := ParseWMM(wmmCOF)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Epoch != 2025 || !w.Valid(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)) ||
+		w.Valid(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) || w.Valid(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected epoch %f", w.Epoch)
+	}
+
+	// Approximate 2025 variation at a few airports.
+	tm := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		name string
+		p    Point2LL
+		decl float32
+	}{
+		{"KJFK", Point2LL{-73.78, 40.64}, -12.6},
+		{"KDEN", Point2LL{-104.67, 39.86}, 7.5},
+		{"KSEA", Point2LL{-122.3, 47.45}, 15.1},
+		{"EGLL", Point2LL{-0.46, 51.47}, 0.8},
+	} {
+		if d := w.Declination(test.p, 0, tm); abs(d-test.decl) > 0.5 {
+			t.Errorf("%s: got declination %.2f, expected about %.1f", test.name, d, test.decl)
+		}
+	}
+}